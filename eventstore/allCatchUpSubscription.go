@@ -0,0 +1,275 @@
+package goes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+// checkpointSaveInterval is how often the position of the last processed event is saved to the CheckpointStore
+const checkpointSaveInterval = time.Second
+
+// AllCatchUpSubscription delivers the events already in the transaction log followed by the events that are written to
+// any stream afterwards
+type AllCatchUpSubscription struct {
+	connection    *EventStoreConnection
+	resolveLinks  bool
+	requireMaster bool
+	credentials   UserCredentials
+	handler       func(ResolvedEvent) error
+	subscription  *Subscription
+	ctx           context.Context
+	cancel        context.CancelFunc
+	done          chan struct{}
+	liveAppeared  chan struct{}
+	resubscribed  chan struct{}
+	// store, when set, saves the position of the last processed event every checkpointSaveInterval and once stopped
+	store CheckpointStore
+	saved time.Time
+
+	mutex sync.Mutex
+	// live holds the events received by the subscription that have not been delivered yet
+	live []ResolvedEvent
+	err  error
+}
+
+// SubscribeToAllFrom delivers every event after lastCheckpoint in the transaction log to the handler, and then keeps
+// delivering the events that are written to any stream until the subscription is stopped. lastCheckpoint is the position
+// of the last event that was processed, use PositionStart to process the log from the start. The subscription stops when
+// the handler returns an error.
+func (connection *EventStoreConnection) SubscribeToAllFrom(lastCheckpoint Position, resolveLinks bool, handler func(ResolvedEvent) error, options ...OperationOption) (*AllCatchUpSubscription, error) {
+	return subscribeToAllFrom(connection, lastCheckpoint, nil, resolveLinks, handler, options)
+}
+
+// SubscribeToAllWithCheckpoint is like SubscribeToAllFrom but resumes after the position loaded from the store, and saves
+// the position of the last event the handler processed to the store every second and once the subscription stops. An event
+// may be delivered again after a restart when the subscription did not stop cleanly, the handler should be idempotent.
+func (connection *EventStoreConnection) SubscribeToAllWithCheckpoint(store CheckpointStore, resolveLinks bool, handler func(ResolvedEvent) error, options ...OperationOption) (*AllCatchUpSubscription, error) {
+	lastCheckpoint, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return subscribeToAllFrom(connection, lastCheckpoint, store, resolveLinks, handler, options)
+}
+
+func subscribeToAllFrom(connection *EventStoreConnection, lastCheckpoint Position, store CheckpointStore, resolveLinks bool, handler func(ResolvedEvent) error, options []OperationOption) (*AllCatchUpSubscription, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	catchUp := &AllCatchUpSubscription{
+		connection:    connection,
+		resolveLinks:  resolveLinks,
+		requireMaster: connection.requireMaster(options),
+		credentials:   connection.credentials(options),
+		handler:       handler,
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+		liveAppeared:  make(chan struct{}, 1),
+		resubscribed:  make(chan struct{}, 1),
+		store:         store,
+		saved:         time.Now(),
+	}
+	// subscribe before reading the history so that no event written in the meantime is missed,
+	// events that are both read and received live are de-duplicated by their position
+	subscription, err := subscribe(ctx, connection, allStream, resolveLinks, catchUp.eventAppeared, catchUp.dropped, catchUp.resubscribe, nil, catchUp.credentials)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	subscription.setSubscriptionType(SubscriptionTypeCatchUp)
+	catchUp.subscription = subscription
+	go catchUp.run(ctx, lastCheckpoint)
+	return catchUp, nil
+}
+
+// Stop stops delivering events to the handler and waits for the handler to return and the checkpoint to be saved
+func (subscription *AllCatchUpSubscription) Stop() {
+	subscription.cancel()
+	<-subscription.done
+}
+
+// Done is closed once the subscription has stopped
+func (subscription *AllCatchUpSubscription) Done() <-chan struct{} {
+	return subscription.done
+}
+
+// Err returns the reason the subscription stopped. It is nil while the subscription is running or when it was stopped with Stop.
+func (subscription *AllCatchUpSubscription) Err() error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	return subscription.err
+}
+
+// allCheckpoint is the position of the last delivered event. processed is false until an event was delivered after
+// starting from PositionStart, as the first event of the log may be at PositionStart itself.
+type allCheckpoint struct {
+	position  Position
+	processed bool
+}
+
+// delivered reports whether the event at the position was already delivered
+func (checkpoint allCheckpoint) delivered(position Position) bool {
+	return checkpoint.processed && !checkpoint.position.Less(position)
+}
+
+func (subscription *AllCatchUpSubscription) run(ctx context.Context, lastCheckpoint Position) {
+	defer close(subscription.done)
+	defer subscription.subscription.Unsubscribe()
+
+	last := allCheckpoint{position: lastCheckpoint, processed: lastCheckpoint != PositionStart}
+	// the checkpoint is saved last, once the handler has returned
+	defer func() {
+		subscription.saveCheckpoint(lastCheckpoint, last)
+	}()
+	for {
+		var err error
+		last, err = subscription.readHistory(ctx, last)
+		switch {
+		case err == nil:
+			// the history is read again from the last processed event once resubscribed after a reconnect,
+			// as events written while the connection was lost were not received live
+			last, err = subscription.processLive(ctx, last)
+		case errors.Is(err, ErrConnectionLost):
+			// the read gave up as the connection was lost on every retry, it is read again once resubscribed
+			err = subscription.waitForResubscribe(ctx)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				subscription.fail(err)
+			}
+			return
+		}
+	}
+}
+
+// readHistory delivers the events after the checkpoint up to the end of the log and returns the checkpoint of the last delivered event
+func (subscription *AllCatchUpSubscription) readHistory(ctx context.Context, last allCheckpoint) (allCheckpoint, error) {
+	from := last.position
+	for {
+		slice, err := readAllEvents(ctx, subscription.connection, readAllEventsForward, readAllEventsForwardCompleted, from, catchUpReadBatchSize, subscription.resolveLinks, subscription.requireMaster, subscription.credentials)
+		if err != nil {
+			return last, err
+		}
+		last, err = subscription.deliver(slice.Events, last)
+		if err != nil {
+			return last, err
+		}
+		subscription.saveCheckpointIfDue(last)
+		if slice.IsEndOfStream {
+			return last, nil
+		}
+		from = slice.NextPosition
+	}
+}
+
+// processLive delivers the events received by the subscription, skipping the ones that were already read from the history.
+// It returns the checkpoint of the last delivered event when the subscription has been resubscribed.
+func (subscription *AllCatchUpSubscription) processLive(ctx context.Context, last allCheckpoint) (allCheckpoint, error) {
+	ticker := time.NewTicker(checkpointSaveInterval)
+	defer ticker.Stop()
+	for {
+		var err error
+		last, err = subscription.deliverLive(last)
+		if err != nil {
+			return last, err
+		}
+		select {
+		case <-subscription.liveAppeared:
+		case <-ticker.C:
+			subscription.saveCheckpointIfDue(last)
+		case <-subscription.resubscribed:
+			// deliver the events received before the connection was lost first
+			return subscription.deliverLive(last)
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+	}
+}
+
+func (subscription *AllCatchUpSubscription) deliverLive(last allCheckpoint) (allCheckpoint, error) {
+	subscription.mutex.Lock()
+	live := subscription.live
+	subscription.live = nil
+	subscription.mutex.Unlock()
+	return subscription.deliver(live, last)
+}
+
+func (subscription *AllCatchUpSubscription) deliver(events []ResolvedEvent, last allCheckpoint) (allCheckpoint, error) {
+	for _, evnt := range events {
+		if last.delivered(evnt.Position) {
+			continue
+		}
+		if err := subscription.handler(evnt); err != nil {
+			return last, err
+		}
+		last = allCheckpoint{position: evnt.Position, processed: true}
+	}
+	return last, nil
+}
+
+// saveCheckpointIfDue saves the checkpoint when checkpointSaveInterval has passed since it was last saved
+func (subscription *AllCatchUpSubscription) saveCheckpointIfDue(last allCheckpoint) {
+	if subscription.store == nil || time.Since(subscription.saved) < checkpointSaveInterval {
+		return
+	}
+	subscription.saved = time.Now()
+	if err := subscription.store.Save(last.position); err != nil {
+		subscription.connection.reportError(fmt.Errorf("failed to save the checkpoint %+v: %s", last.position, err.Error()))
+	}
+}
+
+// saveCheckpoint saves the checkpoint once the subscription stops, unless no event was processed since it started
+func (subscription *AllCatchUpSubscription) saveCheckpoint(lastCheckpoint Position, last allCheckpoint) {
+	if subscription.store == nil || !last.processed || last.position == lastCheckpoint {
+		return
+	}
+	if err := subscription.store.Save(last.position); err != nil {
+		subscription.connection.reportError(fmt.Errorf("failed to save the checkpoint %+v: %s", last.position, err.Error()))
+	}
+}
+
+func (subscription *AllCatchUpSubscription) waitForResubscribe(ctx context.Context) error {
+	select {
+	case <-subscription.resubscribed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (subscription *AllCatchUpSubscription) resubscribe() {
+	select {
+	case subscription.resubscribed <- struct{}{}:
+	default:
+	}
+}
+
+func (subscription *AllCatchUpSubscription) eventAppeared(appeared *protobuf.StreamEventAppeared) {
+	subscription.mutex.Lock()
+	subscription.live = append(subscription.live, newPositionedEvent(appeared.GetEvent()))
+	subscription.mutex.Unlock()
+	select {
+	case subscription.liveAppeared <- struct{}{}:
+	default:
+	}
+}
+
+func (subscription *AllCatchUpSubscription) dropped(dropped *protobuf.SubscriptionDropped) {
+	if subscription.ctx.Err() != nil {
+		// the subscription was dropped because the catch-up subscription stopped
+		return
+	}
+	subscription.fail(fmt.Errorf("subscription to all dropped: %s", dropped.GetReason().String()))
+}
+
+func (subscription *AllCatchUpSubscription) fail(err error) {
+	subscription.mutex.Lock()
+	if subscription.err == nil {
+		subscription.err = err
+	}
+	subscription.mutex.Unlock()
+	subscription.cancel()
+}
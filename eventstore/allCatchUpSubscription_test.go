@@ -0,0 +1,131 @@
+package goes_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+func newTestPositionedEvent(stream string, position int64) *protobuf.ResolvedEvent {
+	return &protobuf.ResolvedEvent{
+		Event:           newTestEventRecord(stream, 0),
+		CommitPosition:  proto.Int64(position),
+		PreparePosition: proto.Int64(position),
+	}
+}
+
+func TestSubscribeToAllWithCheckpoint(t *testing.T) {
+	requests := make(chan *protobuf.ReadAllEvents, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		subscribe, err := confirmTestSubscription(t, socket)
+		if err != nil {
+			return
+		}
+		// the event at 200 is both received live and read from the history, the event at 300 is only received live
+		for _, position := range []int64{200, 300} {
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       streamEventAppearedCommand,
+				CorrelationID: subscribe.CorrelationID,
+				Data: marshalTestMessage(t, &protobuf.StreamEventAppeared{
+					Event: newTestPositionedEvent("order", position),
+				}),
+			}))
+		}
+		requests <- respondToTestReadAllEvents(t, socket, &protobuf.ReadAllEventsCompleted{
+			CommitPosition:  proto.Int64(100),
+			PreparePosition: proto.Int64(100),
+			Events: []*protobuf.ResolvedEvent{
+				newTestPositionedEvent("order", 100),
+				newTestPositionedEvent("order", 200),
+			},
+			NextCommitPosition:  proto.Int64(300),
+			NextPreparePosition: proto.Int64(300),
+			Result:              protobuf.ReadAllEventsCompleted_Success.Enum(),
+		})
+		respondToUnsubscribe(t, socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	store := goes.NewMemoryCheckpointStore(goes.Position{CommitPosition: 100, PreparePosition: 100})
+	received := make(chan goes.Position, 10)
+	subscription, err := conn.SubscribeToAllWithCheckpoint(store, false, func(evnt goes.ResolvedEvent) error {
+		received <- evnt.Position
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	request := <-requests
+	if request.GetCommitPosition() != 100 || request.GetPreparePosition() != 100 {
+		t.Fatalf("Expected the read to resume from %v got %v/%v", 100, request.GetCommitPosition(), request.GetPreparePosition())
+	}
+	for _, expected := range []int64{200, 300} {
+		select {
+		case actual := <-received:
+			if actual.CommitPosition != expected {
+				t.Fatalf("Expected %v got %v", expected, actual.CommitPosition)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the event at %v to be delivered", expected)
+		}
+	}
+	subscription.Stop()
+	select {
+	case actual := <-received:
+		t.Fatalf("Expected no more events got %v", actual)
+	default:
+	}
+	position, err := store.Load()
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	expected := goes.Position{CommitPosition: 300, PreparePosition: 300}
+	if position != expected {
+		t.Fatalf("Expected %v got %v", expected, position)
+	}
+	if err := subscription.Err(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestSubscribeToAllFrom_WhenTheHandlerFails(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		if _, err := confirmTestSubscription(t, socket); err != nil {
+			return
+		}
+		respondToTestReadAllEvents(t, socket, &protobuf.ReadAllEventsCompleted{
+			CommitPosition:      proto.Int64(0),
+			PreparePosition:     proto.Int64(0),
+			Events:              []*protobuf.ResolvedEvent{newTestPositionedEvent("order", 0)},
+			NextCommitPosition:  proto.Int64(100),
+			NextPreparePosition: proto.Int64(100),
+			Result:              protobuf.ReadAllEventsCompleted_Success.Enum(),
+		})
+		respondToUnsubscribe(t, socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	handlerErr := errors.New("projection failed")
+	subscription, err := conn.SubscribeToAllFrom(goes.PositionStart, false, func(goes.ResolvedEvent) error {
+		return handlerErr
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	select {
+	case <-subscription.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the subscription to stop")
+	}
+	if subscription.Err() != handlerErr {
+		t.Fatalf("Expected %v got %v", handlerErr, subscription.Err())
+	}
+}
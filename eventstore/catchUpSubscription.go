@@ -0,0 +1,243 @@
+package goes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+const (
+	// StreamCheckpointStart is the checkpoint to use when a catch-up subscription should deliver a stream from its first event
+	StreamCheckpointStart = -1
+	// StreamCheckpointEnd is the checkpoint to use when a catch-up subscription should only deliver the events that are
+	// written to a stream from now on. StreamPositionEnd can not be used, as it is the same number as StreamCheckpointStart.
+	StreamCheckpointEnd = -2
+)
+
+const catchUpReadBatchSize = 500
+
+// CatchUpSubscription delivers the events already in a stream followed by the events that are written to it afterwards
+type CatchUpSubscription struct {
+	connection    *EventStoreConnection
+	stream        string
+	resolveLinks  bool
+	requireMaster bool
+	credentials   UserCredentials
+	handler       func(ResolvedEvent) error
+	subscription  *Subscription
+	ctx           context.Context
+	cancel        context.CancelFunc
+	done          chan struct{}
+	liveAppeared  chan struct{}
+	resubscribed  chan struct{}
+
+	mutex sync.Mutex
+	// live holds the events received by the subscription that have not been delivered yet
+	live []ResolvedEvent
+	err  error
+}
+
+// SubscribeToStreamFrom delivers every event after lastCheckpoint in the stream to the handler, and then keeps delivering the
+// events that are written to the stream until the subscription is stopped. lastCheckpoint is the number of the last event that
+// was processed, use StreamCheckpointStart to process the stream from the start. The subscription stops when the handler returns an error.
+//
+// Use StreamCheckpointEnd to skip the events already in the stream. The last event number is resolved once subscribed, and
+// only the events after it are delivered: every event written after SubscribeToStreamFrom returns is delivered, an event
+// written while it subscribes may be skipped. As with any checkpoint, an event is delivered at least once: after a restart
+// from a checkpoint that was saved before the handler's last event, that event is delivered again.
+func (connection *EventStoreConnection) SubscribeToStreamFrom(stream string, lastCheckpoint int64, resolveLinks bool, handler func(ResolvedEvent) error, options ...OperationOption) (*CatchUpSubscription, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	catchUp := &CatchUpSubscription{
+		connection:    connection,
+		stream:        stream,
+		resolveLinks:  resolveLinks,
+		requireMaster: connection.requireMaster(options),
+		credentials:   connection.credentials(options),
+		handler:       handler,
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+		liveAppeared:  make(chan struct{}, 1),
+		resubscribed:  make(chan struct{}, 1),
+	}
+	// subscribe before reading the history so that no event written in the meantime is missed,
+	// events that are both read and received live are de-duplicated by their number
+	subscription, err := subscribe(ctx, connection, stream, resolveLinks, catchUp.eventAppeared, catchUp.dropped, catchUp.resubscribe, nil, catchUp.credentials)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	subscription.setSubscriptionType(SubscriptionTypeCatchUp)
+	catchUp.subscription = subscription
+	if lastCheckpoint == StreamCheckpointEnd {
+		// the end is resolved after subscribing, so that the events written after it are received live
+		lastCheckpoint, err = streamVersion(ctx, connection, stream, catchUp.requireMaster, catchUp.credentials)
+		if err == ErrNoStream {
+			lastCheckpoint, err = StreamCheckpointStart, nil
+		}
+		if err != nil {
+			subscription.Unsubscribe()
+			cancel()
+			return nil, err
+		}
+	}
+	go catchUp.run(ctx, lastCheckpoint)
+	return catchUp, nil
+}
+
+// Stop stops delivering events to the handler and waits for the handler to return
+func (subscription *CatchUpSubscription) Stop() {
+	subscription.cancel()
+	<-subscription.done
+}
+
+// Done is closed once the subscription has stopped
+func (subscription *CatchUpSubscription) Done() <-chan struct{} {
+	return subscription.done
+}
+
+// Err returns the reason the subscription stopped. It is nil while the subscription is running or when it was stopped with Stop.
+func (subscription *CatchUpSubscription) Err() error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	return subscription.err
+}
+
+func (subscription *CatchUpSubscription) run(ctx context.Context, lastCheckpoint int64) {
+	defer close(subscription.done)
+	defer subscription.subscription.Unsubscribe()
+
+	last := lastCheckpoint
+	for {
+		var err error
+		last, err = subscription.readHistory(ctx, last)
+		switch {
+		case err == nil:
+			// the history is read again from the last processed event once resubscribed after a reconnect,
+			// as events written while the connection was lost were not received live
+			last, err = subscription.processLive(ctx, last)
+		case errors.Is(err, ErrConnectionLost):
+			// the read gave up as the connection was lost on every retry, it is read again once resubscribed
+			err = subscription.waitForResubscribe(ctx)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				subscription.fail(err)
+			}
+			return
+		}
+	}
+}
+
+// readHistory delivers the events after lastCheckpoint up to the end of the stream and returns the number of the last delivered event
+func (subscription *CatchUpSubscription) readHistory(ctx context.Context, lastCheckpoint int64) (int64, error) {
+	last := lastCheckpoint
+	from := lastCheckpoint + 1
+	for {
+		message, err := readStreamEventsCompleted(ctx, subscription.connection, readStreamEventsForward, readStreamEventsForwardCompleted, subscription.stream, from, catchUpReadBatchSize, subscription.resolveLinks, subscription.requireMaster, subscription.credentials)
+		if err == ErrNoStream {
+			return last, nil
+		}
+		if err != nil {
+			return last, err
+		}
+		for _, resolved := range message.GetEvents() {
+			evnt := newResolvedEvent(resolved.GetEvent(), resolved.GetLink())
+			if evnt.OriginalEventNumber() <= last {
+				continue
+			}
+			if err := subscription.handler(evnt); err != nil {
+				return last, err
+			}
+			last = evnt.OriginalEventNumber()
+		}
+		if message.GetIsEndOfStream() {
+			return last, nil
+		}
+		from = int64(message.GetNextEventNumber())
+	}
+}
+
+// processLive delivers the events received by the subscription, skipping the ones that were already read from the history.
+// It returns the number of the last delivered event when the subscription has been resubscribed.
+func (subscription *CatchUpSubscription) processLive(ctx context.Context, last int64) (int64, error) {
+	for {
+		var err error
+		last, err = subscription.deliverLive(last)
+		if err != nil {
+			return last, err
+		}
+		select {
+		case <-subscription.liveAppeared:
+		case <-subscription.resubscribed:
+			// deliver the events received before the connection was lost first
+			return subscription.deliverLive(last)
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+	}
+}
+
+func (subscription *CatchUpSubscription) deliverLive(last int64) (int64, error) {
+	subscription.mutex.Lock()
+	live := subscription.live
+	subscription.live = nil
+	subscription.mutex.Unlock()
+
+	for _, evnt := range live {
+		if evnt.OriginalEventNumber() <= last {
+			continue
+		}
+		if err := subscription.handler(evnt); err != nil {
+			return last, err
+		}
+		last = evnt.OriginalEventNumber()
+	}
+	return last, nil
+}
+
+func (subscription *CatchUpSubscription) waitForResubscribe(ctx context.Context) error {
+	select {
+	case <-subscription.resubscribed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (subscription *CatchUpSubscription) resubscribe() {
+	select {
+	case subscription.resubscribed <- struct{}{}:
+	default:
+	}
+}
+
+func (subscription *CatchUpSubscription) eventAppeared(appeared *protobuf.StreamEventAppeared) {
+	subscription.mutex.Lock()
+	subscription.live = append(subscription.live, newPositionedEvent(appeared.GetEvent()))
+	subscription.mutex.Unlock()
+	select {
+	case subscription.liveAppeared <- struct{}{}:
+	default:
+	}
+}
+
+func (subscription *CatchUpSubscription) dropped(dropped *protobuf.SubscriptionDropped) {
+	if subscription.ctx.Err() != nil {
+		// the subscription was dropped because the catch-up subscription stopped
+		return
+	}
+	subscription.fail(fmt.Errorf("subscription to %s dropped: %s", subscription.stream, dropped.GetReason().String()))
+}
+
+func (subscription *CatchUpSubscription) fail(err error) {
+	subscription.mutex.Lock()
+	if subscription.err == nil {
+		subscription.err = err
+	}
+	subscription.mutex.Unlock()
+	subscription.cancel()
+}
@@ -0,0 +1,96 @@
+package goes
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CheckpointStore keeps the position of the last event a subscription to all the events has processed, so that it can
+// resume after it once restarted
+type CheckpointStore interface {
+	// Load returns the saved position, or PositionStart when no position was saved yet
+	Load() (Position, error)
+	// Save replaces the saved position
+	Save(position Position) error
+}
+
+// MemoryCheckpointStore keeps the checkpoint in memory, e.g. for tests or for projections that are rebuilt on every start
+type MemoryCheckpointStore struct {
+	mutex    sync.Mutex
+	position Position
+}
+
+// NewMemoryCheckpointStore creates a store that starts out with the position
+func NewMemoryCheckpointStore(position Position) *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{position: position}
+}
+
+// Load returns the saved position
+func (store *MemoryCheckpointStore) Load() (Position, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.position, nil
+}
+
+// Save replaces the saved position
+func (store *MemoryCheckpointStore) Save(position Position) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.position = position
+	return nil
+}
+
+// FileCheckpointStore keeps the checkpoint as json in a file. The file is replaced as a whole on every save, so that a
+// crash while saving leaves the previous checkpoint in place.
+type FileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore creates a store that keeps the checkpoint in the file at path, which is created on the first save
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Load returns the position saved in the file, or PositionStart when the file does not exist
+func (store *FileCheckpointStore) Load() (Position, error) {
+	data, err := ioutil.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return PositionStart, nil
+	}
+	if err != nil {
+		return PositionStart, err
+	}
+	position := Position{}
+	err = json.Unmarshal(data, &position)
+	if err != nil {
+		return PositionStart, err
+	}
+	return position, nil
+}
+
+// Save writes the position to a temporary file next to the checkpoint and renames it over the checkpoint
+func (store *FileCheckpointStore) Save(position Position) error {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), store.path)
+}
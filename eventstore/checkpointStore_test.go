@@ -0,0 +1,43 @@
+package goes_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pgermishuys/goes/eventstore"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoints")
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer os.RemoveAll(dir)
+	store := goes.NewFileCheckpointStore(filepath.Join(dir, "projection.checkpoint"))
+
+	position, err := store.Load()
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if position != goes.PositionStart {
+		t.Fatalf("Expected %v got %v", goes.PositionStart, position)
+	}
+	for _, expected := range []goes.Position{{CommitPosition: 100, PreparePosition: 90}, {CommitPosition: 200, PreparePosition: 200}} {
+		if err := store.Save(expected); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		position, err = goes.NewFileCheckpointStore(filepath.Join(dir, "projection.checkpoint")).Load()
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		if position != expected {
+			t.Fatalf("Expected %v got %v", expected, position)
+		}
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("Expected %v got %v", 1, len(files))
+	}
+}
@@ -71,12 +71,16 @@ const (
 
 	scavengeDatabase          = 0xD0
 	scavengeDatabaseCompleted = 0xD1
+	filteredSubscribeToStream = 0xD2
+	checkpointReached         = 0xD3
 
 	badRequest       = 0xF0
 	notHandled       = 0xF1
 	authenticate     = 0xF2
 	authenticated    = 0xF3
 	notAuthenticated = 0xF4
+	identifyClient   = 0xF5
+	clientIdentified = 0xF6
 )
 
 func (c Command) String() string {
@@ -0,0 +1,75 @@
+package goes
+
+// validate checks that the fields of the configuration are in range and fills in the defaults of NewConfiguration for
+// the fields whose zero value would keep the connection from working
+func (config *Configuration) validate() error {
+	if config.EndpointDiscoverer == nil {
+		if len(config.Address) == 0 {
+			return &ErrInvalidConfiguration{Field: "Address", Value: config.Address, Reason: "cannot be an empty string"}
+		}
+		if config.Port <= 0 || config.Port > 65535 {
+			return &ErrInvalidConfiguration{Field: "Port", Value: config.Port, Reason: "must be between 1 and 65535"}
+		}
+	}
+	if len(config.Login) == 0 && len(config.Password) > 0 {
+		return &ErrInvalidConfiguration{Field: "Login", Value: config.Login, Reason: "cannot be an empty string when a Password is set"}
+	}
+	if len(config.Login) > 0 && len(config.Password) == 0 {
+		return &ErrInvalidConfiguration{Field: "Password", Value: "", Reason: "cannot be an empty string when a Login is set"}
+	}
+	if config.ReconnectionDelayMultiplier < 0 {
+		return &ErrInvalidConfiguration{Field: "ReconnectionDelayMultiplier", Value: config.ReconnectionDelayMultiplier, Reason: "cannot be negative"}
+	}
+	fields := []struct {
+		name  string
+		value int
+	}{
+		{"ReconnectionDelay", config.ReconnectionDelay},
+		{"MaxReconnectionDelay", config.MaxReconnectionDelay},
+		{"MaxReconnects", config.MaxReconnects},
+		{"MaxOperationRetries", config.MaxOperationRetries},
+		{"DiscoveryCacheTTL", config.DiscoveryCacheTTL},
+		{"MaxPackageSize", config.MaxPackageSize},
+		{"HeartbeatTimeout", config.HeartbeatTimeout},
+		{"KeepAliveInterval", config.KeepAliveInterval},
+		{"MaxInflight", config.MaxInflight},
+		{"OperationTimeout", config.OperationTimeout},
+		{"TCPKeepAlivePeriod", config.TCPKeepAlivePeriod},
+		{"ConnectTimeout", config.ConnectTimeout},
+		{"WriteTimeout", config.WriteTimeout},
+		{"SubscriptionBufferSize", config.SubscriptionBufferSize},
+		{"MaxQueueSize", config.MaxQueueSize},
+		{"MaxResubscribes", config.MaxResubscribes},
+		{"ResubscribeDelay", config.ResubscribeDelay},
+		{"MaxResubscribeDelay", config.MaxResubscribeDelay},
+	}
+	for _, field := range fields {
+		if field.value < 0 {
+			return &ErrInvalidConfiguration{Field: field.name, Value: field.value, Reason: "cannot be negative"}
+		}
+	}
+	if config.MaxReconnectionDelay > 0 && config.MaxReconnectionDelay < config.ReconnectionDelay {
+		return &ErrInvalidConfiguration{Field: "MaxReconnectionDelay", Value: config.MaxReconnectionDelay, Reason: "cannot be less than the ReconnectionDelay"}
+	}
+	if config.DefaultExpectedVersion < ExpectedVersionStreamExists {
+		return &ErrInvalidConfiguration{Field: "DefaultExpectedVersion", Value: config.DefaultExpectedVersion, Reason: "is not an expected version"}
+	}
+	if config.MaxResubscribeDelay > 0 && config.MaxResubscribeDelay < config.ResubscribeDelay {
+		return &ErrInvalidConfiguration{Field: "MaxResubscribeDelay", Value: config.MaxResubscribeDelay, Reason: "cannot be less than the ResubscribeDelay"}
+	}
+
+	defaults := NewConfiguration()
+	if config.MaxReconnects == 0 {
+		config.MaxReconnects = defaults.MaxReconnects
+	}
+	if config.MaxPackageSize == 0 {
+		config.MaxPackageSize = defaults.MaxPackageSize
+	}
+	if config.SubscriptionBufferSize == 0 {
+		config.SubscriptionBufferSize = defaults.SubscriptionBufferSize
+	}
+	if config.QueueWhileDisconnected && config.MaxQueueSize == 0 {
+		config.MaxQueueSize = defaults.MaxQueueSize
+	}
+	return nil
+}
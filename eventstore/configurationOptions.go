@@ -0,0 +1,147 @@
+package goes
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// ConfigurationOption changes a setting of the configuration built by BuildConfiguration
+type ConfigurationOption func(*Configuration)
+
+// BuildConfiguration starts from the defaults of NewConfiguration, applies the options in order and validates the
+// result, so that a connection can be set up without knowing which fields have to be set:
+//
+//	config, err := goes.BuildConfiguration(
+//		goes.WithAddress("127.0.0.1", 1113),
+//		goes.WithDefaultCredentials("admin", "changeit"),
+//	)
+//
+// An *ErrInvalidConfiguration is returned when a setting is out of range.
+func BuildConfiguration(options ...ConfigurationOption) (*Configuration, error) {
+	config := NewConfiguration()
+	for _, option := range options {
+		option(config)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// WithAddress connects to the single node listening for tcp connections on the address and port
+func WithAddress(address string, port int) ConfigurationOption {
+	return func(config *Configuration) {
+		config.Address = address
+		config.Port = port
+	}
+}
+
+// WithGossipSeeds discovers the node of a cluster to connect to by gossiping with the seeds, the http endpoints of the
+// nodes, e.g. http://127.0.0.1:2113
+func WithGossipSeeds(seeds ...string) ConfigurationOption {
+	return func(config *Configuration) {
+		config.EndpointDiscoverer = &GossipSeedDiscoverer{GossipSeeds: seeds}
+	}
+}
+
+// WithClusterDNS discovers the node of a cluster to connect to by gossiping with the addresses the host name resolves to
+func WithClusterDNS(clusterDNS string) ConfigurationOption {
+	return func(config *Configuration) {
+		config.EndpointDiscoverer = NewDNSDiscoverer(clusterDNS)
+	}
+}
+
+// WithDefaultCredentials authenticates the operations that are not given credentials with WithCredentials
+func WithDefaultCredentials(login string, password string) ConfigurationOption {
+	return func(config *Configuration) {
+		config.Login = login
+		config.Password = password
+	}
+}
+
+// WithTLS encrypts the connection. tlsConfig may be nil to verify the server certificate against the address.
+func WithTLS(tlsConfig *tls.Config) ConfigurationOption {
+	return func(config *Configuration) {
+		config.UseTLS = true
+		config.TLSConfig = tlsConfig
+	}
+}
+
+// WithRetryPolicy sets how often an operation is sent again before it fails with ErrRetriesExhausted and how often a
+// lost connection is reconnected before giving up. Zero operation retries turn the retries off, the operations are sent
+// once, while zero reconnects are replaced by the default.
+func WithRetryPolicy(maxOperationRetries int, maxReconnects int) ConfigurationOption {
+	return func(config *Configuration) {
+		config.MaxOperationRetries = maxOperationRetries
+		config.MaxReconnects = maxReconnects
+	}
+}
+
+// WithReconnectionDelay sets the delay before the first reconnect attempt and the delay it grows to at most, zero
+// leaves the delay unbounded
+func WithReconnectionDelay(delay time.Duration, maxDelay time.Duration) ConfigurationOption {
+	return func(config *Configuration) {
+		config.ReconnectionDelay = milliseconds(delay)
+		config.MaxReconnectionDelay = milliseconds(maxDelay)
+	}
+}
+
+// WithResubscribes subscribes again up to maxResubscribes times in a row to a subscription that was dropped for a
+// transient reason, waiting from the delay up to the maxDelay in between
+func WithResubscribes(maxResubscribes int, delay time.Duration, maxDelay time.Duration) ConfigurationOption {
+	return func(config *Configuration) {
+		config.MaxResubscribes = maxResubscribes
+		config.ResubscribeDelay = milliseconds(delay)
+		config.MaxResubscribeDelay = milliseconds(maxDelay)
+	}
+}
+
+// WithDefaultExpectedVersion sets the expected version the writes that are given ExpectedVersionUnset are made at
+func WithDefaultExpectedVersion(expectedVersion int64) ConfigurationOption {
+	return func(config *Configuration) {
+		config.DefaultExpectedVersion = expectedVersion
+	}
+}
+
+// WithOperationTimeout sets how long to wait for the response to an operation, zero waits until the operation's
+// context is cancelled
+func WithOperationTimeout(timeout time.Duration) ConfigurationOption {
+	return func(config *Configuration) {
+		config.OperationTimeout = milliseconds(timeout)
+	}
+}
+
+// WithHeartbeatTimeout sets how long the connection may go without receiving any data before it is reconnected, zero
+// disables the timeout
+func WithHeartbeatTimeout(timeout time.Duration) ConfigurationOption {
+	return func(config *Configuration) {
+		config.HeartbeatTimeout = milliseconds(timeout)
+	}
+}
+
+// WithConnectionName sets the name the connection identifies itself to the server with
+func WithConnectionName(name string) ConfigurationOption {
+	return func(config *Configuration) {
+		config.ConnectionName = name
+	}
+}
+
+// WithLogger sends the connection's log output to the logger
+func WithLogger(logger Logger) ConfigurationOption {
+	return func(config *Configuration) {
+		config.Logger = logger
+	}
+}
+
+// WithQueueWhileDisconnected holds up to maxQueueSize operations while a lost connection is being re-established
+func WithQueueWhileDisconnected(maxQueueSize int) ConfigurationOption {
+	return func(config *Configuration) {
+		config.QueueWhileDisconnected = true
+		config.MaxQueueSize = maxQueueSize
+	}
+}
+
+// milliseconds converts the duration to the number of milliseconds the configuration holds durations in
+func milliseconds(duration time.Duration) int {
+	return int(duration / time.Millisecond)
+}
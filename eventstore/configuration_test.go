@@ -0,0 +1,161 @@
+package goes_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+func TestNewEventStoreConnection_WithInvalidConfiguration(t *testing.T) {
+	tests := []struct {
+		field     string
+		configure func(config *goes.Configuration)
+	}{
+		{"Address", func(config *goes.Configuration) { config.Address = "" }},
+		{"Port", func(config *goes.Configuration) { config.Port = 0 }},
+		{"Port", func(config *goes.Configuration) { config.Port = 65536 }},
+		{"Login", func(config *goes.Configuration) { config.Login = "" }},
+		{"Password", func(config *goes.Configuration) { config.Password = "" }},
+		{"ReconnectionDelay", func(config *goes.Configuration) { config.ReconnectionDelay = -1 }},
+		{"ReconnectionDelayMultiplier", func(config *goes.Configuration) { config.ReconnectionDelayMultiplier = -1 }},
+		{"MaxReconnectionDelay", func(config *goes.Configuration) { config.MaxReconnectionDelay = -1 }},
+		{"MaxReconnectionDelay", func(config *goes.Configuration) { config.MaxReconnectionDelay = config.ReconnectionDelay - 1 }},
+		{"MaxReconnects", func(config *goes.Configuration) { config.MaxReconnects = -1 }},
+		{"MaxOperationRetries", func(config *goes.Configuration) { config.MaxOperationRetries = -1 }},
+		{"DiscoveryCacheTTL", func(config *goes.Configuration) { config.DiscoveryCacheTTL = -1 }},
+		{"MaxPackageSize", func(config *goes.Configuration) { config.MaxPackageSize = -1 }},
+		{"HeartbeatTimeout", func(config *goes.Configuration) { config.HeartbeatTimeout = -1 }},
+		{"KeepAliveInterval", func(config *goes.Configuration) { config.KeepAliveInterval = -1 }},
+		{"MaxInflight", func(config *goes.Configuration) { config.MaxInflight = -1 }},
+		{"OperationTimeout", func(config *goes.Configuration) { config.OperationTimeout = -1 }},
+		{"TCPKeepAlivePeriod", func(config *goes.Configuration) { config.TCPKeepAlivePeriod = -1 }},
+		{"ConnectTimeout", func(config *goes.Configuration) { config.ConnectTimeout = -1 }},
+		{"WriteTimeout", func(config *goes.Configuration) { config.WriteTimeout = -1 }},
+		{"SubscriptionBufferSize", func(config *goes.Configuration) { config.SubscriptionBufferSize = -1 }},
+		{"MaxQueueSize", func(config *goes.Configuration) { config.MaxQueueSize = -1 }},
+		{"DefaultExpectedVersion", func(config *goes.Configuration) { config.DefaultExpectedVersion = goes.ExpectedVersionUnset }},
+		{"MaxResubscribes", func(config *goes.Configuration) { config.MaxResubscribes = -1 }},
+		{"ResubscribeDelay", func(config *goes.Configuration) { config.ResubscribeDelay = -1 }},
+		{"MaxResubscribeDelay", func(config *goes.Configuration) { config.MaxResubscribeDelay = -1 }},
+		{"MaxResubscribeDelay", func(config *goes.Configuration) { config.MaxResubscribeDelay = config.ResubscribeDelay - 1 }},
+	}
+	for _, test := range tests {
+		config := goes.NewConfiguration()
+		config.Address = "127.0.0.1"
+		config.Port = 1113
+		config.Login = "admin"
+		config.Password = "changeit"
+		test.configure(config)
+
+		_, err := goes.NewEventStoreConnection(config)
+		invalid, ok := err.(*goes.ErrInvalidConfiguration)
+		if !ok {
+			t.Fatalf("Expected %T for %v got %v", invalid, test.field, err)
+		}
+		if invalid.Field != test.field {
+			t.Fatalf("Expected %v got %v", test.field, invalid.Field)
+		}
+	}
+}
+
+func TestNewEventStoreConnection_WithoutAddressWhenUsingAnEndpointDiscoverer(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.EndpointDiscoverer = &goes.GossipSeedDiscoverer{}
+
+	if _, err := goes.NewEventStoreConnection(config); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestNewEventStoreConnection_FillsInTheDefaults(t *testing.T) {
+	conn, err := goes.NewEventStoreConnection(&goes.Configuration{Address: "127.0.0.1", Port: 1113, QueueWhileDisconnected: true})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	config := conn.Config
+	defaults := goes.NewConfiguration()
+	if config.MaxReconnects != defaults.MaxReconnects {
+		t.Fatalf("Expected %v got %v", defaults.MaxReconnects, config.MaxReconnects)
+	}
+	if config.MaxOperationRetries != 0 {
+		t.Fatalf("Expected %v got %v", 0, config.MaxOperationRetries)
+	}
+	if config.MaxPackageSize != defaults.MaxPackageSize {
+		t.Fatalf("Expected %v got %v", defaults.MaxPackageSize, config.MaxPackageSize)
+	}
+	if config.SubscriptionBufferSize != defaults.SubscriptionBufferSize {
+		t.Fatalf("Expected %v got %v", defaults.SubscriptionBufferSize, config.SubscriptionBufferSize)
+	}
+	if config.MaxQueueSize != defaults.MaxQueueSize {
+		t.Fatalf("Expected %v got %v", defaults.MaxQueueSize, config.MaxQueueSize)
+	}
+}
+
+func TestNewEventStoreConnection_WithASharedConfiguration(t *testing.T) {
+	config := &goes.Configuration{Address: "127.0.0.1", Port: 1113}
+	shared := *config
+
+	first, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	second, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if !reflect.DeepEqual(*config, shared) {
+		t.Fatalf("Expected %+v got %+v", shared, *config)
+	}
+	if first.Config == config || second.Config == config || first.Config == second.Config {
+		t.Fatalf("Expected every connection to have its own configuration")
+	}
+	first.Config.MaxReconnects = 1
+	if second.Config.MaxReconnects != goes.NewConfiguration().MaxReconnects {
+		t.Fatalf("Expected %v got %v", goes.NewConfiguration().MaxReconnects, second.Config.MaxReconnects)
+	}
+}
+
+func TestBuildConfiguration(t *testing.T) {
+	config, err := goes.BuildConfiguration(
+		goes.WithAddress("127.0.0.1", 1113),
+		goes.WithDefaultCredentials("admin", "changeit"),
+		goes.WithRetryPolicy(3, 5),
+		goes.WithOperationTimeout(2*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if config.Address != "127.0.0.1" || config.Port != 1113 {
+		t.Fatalf("Expected %v got %v", "127.0.0.1:1113", fmt.Sprintf("%s:%d", config.Address, config.Port))
+	}
+	if config.Login != "admin" || config.Password != "changeit" {
+		t.Fatalf("Expected %v got %v", "admin", config.Login)
+	}
+	if config.MaxOperationRetries != 3 || config.MaxReconnects != 5 {
+		t.Fatalf("Expected %v got %v", []int{3, 5}, []int{config.MaxOperationRetries, config.MaxReconnects})
+	}
+	if config.OperationTimeout != 2000 {
+		t.Fatalf("Expected %v got %v", 2000, config.OperationTimeout)
+	}
+	if config.HeartbeatTimeout != goes.NewConfiguration().HeartbeatTimeout {
+		t.Fatalf("Expected %v got %v", goes.NewConfiguration().HeartbeatTimeout, config.HeartbeatTimeout)
+	}
+}
+
+func TestBuildConfiguration_WithInvalidOptions(t *testing.T) {
+	_, err := goes.BuildConfiguration(goes.WithAddress("127.0.0.1", 1113), goes.WithOperationTimeout(-time.Second))
+	invalid, ok := err.(*goes.ErrInvalidConfiguration)
+	if !ok {
+		t.Fatalf("Expected %T got %v", invalid, err)
+	}
+	if invalid.Field != "OperationTimeout" {
+		t.Fatalf("Expected %v got %v", "OperationTimeout", invalid.Field)
+	}
+
+	if _, err := goes.BuildConfiguration(goes.WithGossipSeeds("http://127.0.0.1:2113")); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
@@ -1,142 +1,549 @@
 package goes
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
 	"fmt"
-	"log"
+	"io"
+	"math"
+	"math/rand"
 	"net"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"sync"
+	"sync/atomic"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pgermishuys/goes/protobuf"
 	"github.com/satori/go.uuid"
 )
 
+// DefaultMaxPackageSize is the largest package that is read from the server when the configuration does not set MaxPackageSize
+const DefaultMaxPackageSize = 64 * 1024 * 1024
+
+// clientVersion is the version of the client protocol that is sent when identifying the connection
+const clientVersion = 1
+
 // Configuration for an Event Store Connection
 type Configuration struct {
-	Address             string
-	Port                int
-	Login               string
-	Password            string
-	ReconnectionDelay   int
-	MaxReconnects       int
+	Address  string
+	Port     int
+	Login    string
+	Password string
+	// ReconnectionDelay is the number of milliseconds to wait before the first reconnect attempt
+	ReconnectionDelay int
+	// ReconnectionDelayMultiplier grows the delay after every failed reconnect attempt, the delay stays the same when it is not set
+	ReconnectionDelayMultiplier float64
+	// MaxReconnectionDelay is the maximum number of milliseconds to wait between reconnect attempts, zero leaves the delay unbounded
+	MaxReconnectionDelay int
+	// MaxReconnects is the number of attempts made to connect before giving up, the default is used when it is not set
+	MaxReconnects int
+	// MaxOperationRetries is the number of times an operation is sent again when the server timed out or did not handle it, or
+	// the connection was lost before the response arrived, before it fails with ErrRetriesExhausted. The reasons share the
+	// count, so an operation is sent at most MaxOperationRetries+1 times. Operations are not sent again when it is zero.
 	MaxOperationRetries int
 	EndpointDiscoverer  EndpointDiscoverer
+	// DiscoveryCacheTTL is the number of milliseconds a node found by the EndpointDiscoverer is reconnected to without discovering
+	// the cluster again. The cluster is always discovered again when the node cannot be connected to. Zero disables the cache.
+	DiscoveryCacheTTL int
+	// OnError is called when the connection encounters an error that is not tied to a specific operation
+	OnError func(err error)
+	// Logger receives the connection's log output. The standard library logger is used when nil
+	Logger Logger
+	// UseTLS encrypts the connection. The server certificate is verified against the Address unless
+	// TLSConfig sets a different ServerName or InsecureSkipVerify
+	UseTLS    bool
+	TLSConfig *tls.Config
+	// MaxPackageSize is the largest package in bytes that is accepted from the server, larger packages are discarded
+	// and reported to OnError. DefaultMaxPackageSize is used when it is not set.
+	MaxPackageSize int
+	// HeartbeatTimeout is the number of milliseconds without receiving any data from the server, including heartbeats,
+	// after which the connection is considered dead and is reconnected. Zero disables the timeout.
+	HeartbeatTimeout int
+	// KeepAliveInterval is the number of milliseconds between the pings sent to the server to keep the connection alive.
+	// Zero disables the pings.
+	KeepAliveInterval int
+	// ConnectionName identifies the connection to the server, it defaults to the host name and the connection id
+	ConnectionName string
+	// OnStateChange is called after every change of the connection state
+	OnStateChange func(old ConnectionState, new ConnectionState)
+	// OnConnected is called whenever a connection to the server has been established, both when connecting and after
+	// a lost connection was re-established, e.g. to prime caches again. It may use the connection.
+	OnConnected func()
+	// OnDisconnected is called with the error the connection was lost with, e.g. io.EOF, or ErrServerShutdown when the node
+	// is going down, as soon as the loss is detected and before the connection is re-established. It is not called when
+	// the connection is closed.
+	OnDisconnected func(err error)
+	// MaxInflight is the number of operations that can be waiting for a response at the same time. Further operations
+	// block until an operation completes or their context is cancelled. Zero leaves the number of operations unbounded.
+	MaxInflight int
+	// OperationTimeout is the number of milliseconds to wait for the response to an operation before it fails with
+	// ErrOperationTimeout. Zero waits until the operation's context is cancelled.
+	OperationTimeout int
+	// TCPKeepAlivePeriod is the number of milliseconds between the tcp keep-alive probes that let the operating system detect
+	// a dead peer. Zero disables the keep-alive probes.
+	TCPKeepAlivePeriod int
+	// TCPNoDelay sends packages as soon as they are written instead of delaying small packages with Nagle's algorithm
+	TCPNoDelay bool
+	// Dialer opens the connection to the server, e.g. through a proxy. A net.Dialer is used when nil
+	Dialer func(ctx context.Context, network string, address string) (net.Conn, error)
+	// ConnectTimeout is the number of milliseconds a single connection attempt, including the tls handshake, may take before
+	// it fails. Zero waits until the context of Connect is cancelled.
+	ConnectTimeout int
+	// WriteTimeout is the number of milliseconds writing a package to the socket may take. A write that takes longer fails
+	// with ErrWriteTimeout and the connection is re-established. Zero waits for the write to complete.
+	WriteTimeout int
+	// SubscriptionBufferSize is the number of messages that are buffered for each subscription while its handler is busy
+	SubscriptionBufferSize int
+	// SubscriptionOverflowPolicy decides what happens to a message for a subscription whose buffer is full
+	SubscriptionOverflowPolicy OverflowPolicy
+	// Metrics records counters, latencies and gauges for the operations and the health of the connection
+	Metrics Metrics
+	// Tracer starts a span for every operation and adds the trace context to the metadata of the written events
+	Tracer Tracer
+	// BeforeWrite is called for every EventData that is written, including the events written in a Transaction, before
+	// it is serialized, e.g. to add a correlation id or a timestamp to the metadata of every event in one place.
+	// It is called after the trace context has been added, so the metadata holds what the caller supplied by then and
+	// should be merged with rather than overwritten. The event is a copy, the caller's events are left as they are, but
+	// the Metadata and Data should be replaced rather than modified in place.
+	BeforeWrite func(evnt *EventData)
+	// DefaultExpectedVersion is the expected version the writes and transactions that are given ExpectedVersionUnset are
+	// made at, e.g. ExpectedVersionStreamExists. Zero, the default, makes them at ExpectedVersionAny.
+	DefaultExpectedVersion int64
+	// QueueWhileDisconnected holds the operations that are submitted while a lost connection is being re-established and
+	// sends them in the order they were submitted once it is restored, instead of failing them. The operations fail with
+	// ErrConnectionClosed when the connection can not be re-established within MaxReconnects.
+	QueueWhileDisconnected bool
+	// MaxQueueSize is the number of operations that are held while disconnected, further operations fail with ErrConnectionClosed
+	MaxQueueSize int
+	// RequireMaster sends reads and writes to the master of a cluster, a node that is not the master answers by redirecting
+	// the connection to the master and the operation is sent again. Reads then always see the latest writes. Without it
+	// any node serves the operation, which spreads the reads over the cluster but a read from a follower may not yet
+	// include a write that was acknowledged by the master. It can be overridden per operation with WithRequireMaster.
+	RequireMaster bool
+	// MaxResubscribes is the number of times in a row a subscription that was dropped for a transient reason, such as
+	// ServerShutdown or SubscriberMaxCountReached, is subscribed again before it is dropped for good with an
+	// *ErrResubscribesExhausted. The count starts over once the subscription receives an event. Zero drops the
+	// subscription right away.
+	MaxResubscribes int
+	// ResubscribeDelay is the number of milliseconds to wait before the first attempt to subscribe again, the delay grows
+	// by the ReconnectionDelayMultiplier after every attempt
+	ResubscribeDelay int
+	// MaxResubscribeDelay is the maximum number of milliseconds to wait between the attempts to subscribe again, zero
+	// leaves the delay unbounded
+	MaxResubscribeDelay int
+	// OnResubscribe is called before every attempt to subscribe again with the stream, the number of the attempt and
+	// the error the subscription was dropped with
+	OnResubscribe func(stream string, attempt int, err error)
 }
 
 // EventStoreConnection will manage the lifetime and connection to an Event Store Node/Cluster
 type EventStoreConnection struct {
 	Config        *Configuration
-	Socket        *net.TCPConn
-	connected     bool
+	Socket        net.Conn
+	state         ConnectionState
 	requests      map[uuid.UUID]chan<- TCPPackage
 	subscriptions map[uuid.UUID]*Subscription
 	ConnectionID  uuid.UUID
-	Mutex         *sync.Mutex
+	// mutex guards the state of the connection, its zero value is ready to use so that a connection that was not set up
+	// with NewEventStoreConnection does not panic
+	mutex sync.Mutex
+	// stopKeepAlive is closed to stop sending pings on the current socket
+	stopKeepAlive chan struct{}
+	discoverer    *cachingDiscoverer
+	// inflight holds a slot for every operation waiting for a response when MaxInflight is set
+	inflight chan struct{}
+	// writer writes the packages to the current socket
+	writer *socketWriter
+	// done is closed when the connection is closed, which fails the pending operations with ErrConnectionClosed
+	done chan struct{}
+	// inflightCount is the number of operations waiting for a response
+	inflightCount int64
+	stats         connectionStats
+	// queue holds the packages submitted while the connection is re-established when QueueWhileDisconnected is set
+	queue []TCPPackage
+	// workers tracks the readers and the pings of the sockets, so that Reconnect can wait for them to stop
+	workers sync.WaitGroup
+	// readerStopped is closed when the reader of the current socket stops
+	readerStopped chan struct{}
+	// stateChanged is closed on the next change of state, it is created by WaitForConnection while it waits
+	stateChanged chan struct{}
 }
 
 // NewConfiguration creates a configuration with default settings
 func NewConfiguration() *Configuration {
 	return &Configuration{
-		ReconnectionDelay:   10000,
-		MaxReconnects:       10,
-		MaxOperationRetries: 10,
+		ReconnectionDelay:           10000,
+		ReconnectionDelayMultiplier: 2,
+		MaxReconnectionDelay:        60000,
+		MaxReconnects:               10,
+		MaxOperationRetries:         10,
+		MaxPackageSize:              DefaultMaxPackageSize,
+		HeartbeatTimeout:            10000,
+		KeepAliveInterval:           5000,
+		DiscoveryCacheTTL:           30000,
+		MaxInflight:                 5000,
+		OperationTimeout:            7000,
+		TCPKeepAlivePeriod:          30000,
+		TCPNoDelay:                  true,
+		ConnectTimeout:              1000,
+		WriteTimeout:                10000,
+		SubscriptionBufferSize:      1000,
+		RequireMaster:               true,
+		MaxQueueSize:                5000,
+		ResubscribeDelay:            500,
+		MaxResubscribeDelay:         30000,
 	}
 }
 
 // Connect attempts to connect to Event Store using the given configuration
 func (connection *EventStoreConnection) Connect() error {
+	return connection.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to connect to Event Store using the given configuration, giving up when ctx is cancelled
+func (connection *EventStoreConnection) ConnectWithContext(ctx context.Context) error {
+	connection.mutex.Lock()
 	connection.requests = make(map[uuid.UUID]chan<- TCPPackage)
 	connection.subscriptions = make(map[uuid.UUID]*Subscription)
-	return connectWithRetries(connection, connection.Config.MaxReconnects)
+	select {
+	case <-connection.done:
+		connection.done = make(chan struct{})
+	default:
+		if connection.done == nil {
+			connection.done = make(chan struct{})
+		}
+	}
+	connection.discoverer = nil
+	if connection.Config.EndpointDiscoverer != nil {
+		connection.discoverer = newCachingDiscoverer(connection.Config.EndpointDiscoverer, time.Duration(connection.Config.DiscoveryCacheTTL)*time.Millisecond)
+	}
+	old := connection.setStateLocked(ConnectionStateConnecting)
+	connection.mutex.Unlock()
+	connection.notifyStateChange(old, ConnectionStateConnecting)
+	return connectWithRetries(ctx, connection, connection.Config.MaxReconnects)
+}
+
+// Reconnect closes the connection unless it is closed already and connects again with the same configuration and a new
+// connection id, e.g. for a supervisor to restore a connection that gave up after MaxReconnects. The pending operations
+// fail and the subscriptions are dropped with ErrConnectionClosed. It must not be called from the callbacks of the
+// configuration, which may run on the goroutines it waits for.
+func (connection *EventStoreConnection) Reconnect() error {
+	return connection.ReconnectWithContext(context.Background())
+}
+
+// ReconnectWithContext closes the connection unless it is closed already and connects again, giving up when ctx is cancelled
+func (connection *EventStoreConnection) ReconnectWithContext(ctx context.Context) error {
+	if connection.State() != ConnectionStateClosed {
+		connection.Close()
+	}
+	// the reader and the pings of the previous socket must not see the new connection
+	connection.workers.Wait()
+	connection.mutex.Lock()
+	connection.ConnectionID = uuid.NewV4()
+	connection.mutex.Unlock()
+	connection.logger().Infof("reconnecting the connection (id: %+v) to event store...", connection.ConnectionID)
+	return connection.ConnectWithContext(ctx)
 }
 
 // Close attempts to close the connection to Event Store
 func (connection *EventStoreConnection) Close() error {
-	connection.Mutex.Lock()
-	connection.connected = false
-	connection.Mutex.Unlock()
-	log.Printf("[info] closing the connection (id: %+v) to event store...\n'", connection.ConnectionID)
-	err := connection.Socket.Close()
+	connection.mutex.Lock()
+	old := connection.setStateLocked(ConnectionStateClosed)
+	socket := connection.Socket
 	connection.Socket = nil
+	connection.stopKeepAliveLocked()
+	connection.stopWriterLocked()
+	connection.mutex.Unlock()
+	connection.notifyStateChange(old, ConnectionStateClosed)
+	connection.logger().Infof("closing the connection (id: %+v) to event store...", connection.ConnectionID)
+	// done is closed before the socket, so that the reader stops without reporting the failed read
+	closeConnection(connection)
+	if socket == nil {
+		return nil
+	}
+	err := socket.Close()
 	if err != nil {
-		log.Printf("[error] failed closing the connection to event store...%+v\n'", err)
+		connection.logger().Errorf("failed closing the connection to event store...%+v", err)
 	}
-	closeConnection(connection)
 	return err
 }
 
-// NewEventStoreConnection sets up a new Event Store Connection but does not open the connection
-func NewEventStoreConnection(config *Configuration) (*EventStoreConnection, error) {
-	if config.EndpointDiscoverer == nil {
-		if len(config.Address) == 0 {
-			return nil, fmt.Errorf("The address (%v) cannot be an empty string", config.Address)
-		}
-		if config.Port <= 0 {
-			return nil, fmt.Errorf("The port (%v) cannot be less or equal to 0", config.Port)
-		}
+// NewEventStoreConnection sets up a new Event Store Connection but does not open the connection. It returns an
+// ErrInvalidConfiguration when a field of the configuration is out of range and fills in the defaults of NewConfiguration
+// for MaxReconnects, MaxPackageSize, SubscriptionBufferSize and MaxQueueSize when they are not set.
+// The defaults are filled in on a copy of the configuration, which becomes the Config of the connection, so that a
+// configuration can be shared between connections.
+func NewEventStoreConnection(configuration *Configuration) (*EventStoreConnection, error) {
+	config := *configuration
+	if err := config.validate(); err != nil {
+		return nil, err
 	}
 	conn := &EventStoreConnection{
-		Config:       config,
+		Config:       &config,
 		ConnectionID: uuid.NewV4(),
-		Mutex:        &sync.Mutex{},
+		done:         make(chan struct{}),
+	}
+	if config.MaxInflight > 0 {
+		conn.inflight = make(chan struct{}, config.MaxInflight)
 	}
-	log.Printf("[info] created new event store connection : %+v", conn)
+	conn.logger().Infof("created new event store connection : %+v", conn)
 	return conn, nil
 }
 
-func connectWithRetries(connection *EventStoreConnection, retryAttempts int) error {
-	if connection.Config.EndpointDiscoverer != nil {
-		memberInfo, err := connection.Config.EndpointDiscoverer.Discover()
-		if err != nil {
-			return err
+func connectWithRetries(ctx context.Context, connection *EventStoreConnection, retryAttempts int) error {
+	for attempt := 1; attempt <= retryAttempts; attempt++ {
+		if connection.State() == ConnectionStateClosed {
+			return ErrConnectionClosed
 		}
-		connection.Config.Address = memberInfo.ExternalTCPIP
-		connection.Config.Port = memberInfo.ExternalTCPPort
-	}
-	if retryAttempts > 0 {
-		err := connect(connection)
-		if err != nil {
-			log.Printf("[info] reconnect attempt %v of %v failed: %v", (connection.Config.MaxReconnects-retryAttempts)+1, connection.Config.MaxReconnects, err.Error())
-			time.Sleep(time.Duration(connection.Config.ReconnectionDelay) * time.Millisecond)
-			//extract to appropriate method
-			if connection.Config.EndpointDiscoverer != nil {
-				log.Printf("[info] checking nodes")
-				memberInfo, _ := connection.Config.EndpointDiscoverer.Discover()
-				connection.Config.Address = memberInfo.ExternalTCPIP
-				connection.Config.Port = memberInfo.ExternalTCPPort
+		err := discover(ctx, connection)
+		if err == nil {
+			err = connect(ctx, connection)
+			if err != nil {
+				connection.invalidateDiscovery()
 			}
-			return connectWithRetries(connection, retryAttempts-1)
 		}
-		return nil
+		if err == nil {
+			return nil
+		}
+		connection.logger().Infof("reconnect attempt %v of %v failed: %v", (connection.Config.MaxReconnects-retryAttempts)+attempt, connection.Config.MaxReconnects, err.Error())
+		if ctx.Err() != nil {
+			connection.setState(ConnectionStateDisconnected)
+			return ctx.Err()
+		}
+		if attempt == retryAttempts {
+			break
+		}
+		select {
+		case <-time.After(connection.Config.ReconnectionBackoff(attempt)):
+		case <-ctx.Done():
+			connection.setState(ConnectionStateDisconnected)
+			return ctx.Err()
+		}
 	}
+	connection.setState(ConnectionStateClosed)
 	closeConnection(connection)
 	return fmt.Errorf("failed to reconnect. Retry limit of %v reached", connection.Config.MaxReconnects)
 }
 
-func connect(connection *EventStoreConnection) error {
-	log.Printf("[info] connecting (id: %+v) to event store...\n", connection.ConnectionID)
-
-	address := fmt.Sprintf("%s:%v", connection.Config.Address, connection.Config.Port)
-	resolvedAddress, err := net.ResolveTCPAddr("tcp", address)
+// discover looks up the node to connect to when the connection uses an endpoint discoverer
+func discover(ctx context.Context, connection *EventStoreConnection) error {
+	connection.mutex.Lock()
+	discoverer := connection.discoverer
+	connection.mutex.Unlock()
+	if discoverer == nil {
+		return nil
+	}
+	connection.logger().Infof("checking nodes")
+	memberInfo, err := discoverer.DiscoverContext(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to resolve tcp address %s\n", address)
+		return err
 	}
-	conn, err := net.DialTCP("tcp", nil, resolvedAddress)
+	connection.Config.Address = memberInfo.ExternalTCPIP
+	connection.Config.Port = memberInfo.ExternalTCPPort
+	return nil
+}
+
+// invalidateDiscovery makes the next reconnect discover the cluster again instead of using the cached node
+func (connection *EventStoreConnection) invalidateDiscovery() {
+	connection.mutex.Lock()
+	discoverer := connection.discoverer
+	connection.mutex.Unlock()
+	if discoverer != nil {
+		discoverer.invalidate()
+	}
+}
+
+// ReconnectionBackoff returns the delay before the next reconnect after the given number of failed attempts. The delay starts
+// at ReconnectionDelay and is multiplied by ReconnectionDelayMultiplier after every failed attempt, up to MaxReconnectionDelay.
+// A random jitter of up to half the delay is subtracted so that clients do not reconnect in lockstep.
+func (config *Configuration) ReconnectionBackoff(attempt int) time.Duration {
+	return backoff(config.ReconnectionDelay, config.ReconnectionDelayMultiplier, config.MaxReconnectionDelay, attempt)
+}
+
+// ResubscribeBackoff returns how long to wait before the given attempt to subscribe again, starting at 1. It backs off
+// like ReconnectionBackoff, from the ResubscribeDelay up to the MaxResubscribeDelay.
+func (config *Configuration) ResubscribeBackoff(attempt int) time.Duration {
+	return backoff(config.ResubscribeDelay, config.ReconnectionDelayMultiplier, config.MaxResubscribeDelay, attempt)
+}
+
+// backoff grows the delay in milliseconds by the multiplier for every attempt after the first, up to maxDelay unless
+// it is zero, and takes off up to half of it at random
+func backoff(initialDelay int, multiplier float64, maxDelay int, attempt int) time.Duration {
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(initialDelay) * math.Pow(multiplier, float64(attempt-1))
+	upperBound := float64(math.MaxInt64 / int64(time.Millisecond))
+	if maxDelay > 0 {
+		upperBound = float64(maxDelay)
+	}
+	if delay > upperBound {
+		delay = upperBound
+	}
+	backoff := time.Duration(delay) * time.Millisecond
+	jitter := int64(backoff / 2)
+	if jitter <= 0 {
+		return backoff
+	}
+	return backoff - time.Duration(rand.Int63n(jitter+1))
+}
+
+// joinHostPort formats the address of a node, an IPv6 host is enclosed in brackets whether or not it already was
+func joinHostPort(host string, port int) string {
+	return net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), strconv.Itoa(port))
+}
+
+func connect(ctx context.Context, connection *EventStoreConnection) error {
+	connection.logger().Infof("connecting (id: %+v) to event store...", connection.ConnectionID)
+
+	address := joinHostPort(connection.Config.Address, connection.Config.Port)
+	if connection.Config.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(connection.Config.ConnectTimeout)*time.Millisecond)
+		defer cancel()
+	}
+	socket, err := connection.dialer()(ctx, "tcp", address)
 	if err != nil {
 		return fmt.Errorf("failed to connect to event store on %+v. details: %s\n", address, err.Error())
 	}
-	log.Printf("[info] successfully connected to event store on %s (id: %+v)\n", address, connection.ConnectionID)
-	connection.Socket = conn
-	connection.connected = true
+	err = configureSocket(connection.Config, socket)
+	if err != nil {
+		socket.Close()
+		return fmt.Errorf("failed to configure the connection to event store on %+v. details: %s\n", address, err.Error())
+	}
+	if connection.Config.UseTLS {
+		socket, err = secure(ctx, connection.Config, socket)
+		if err != nil {
+			return fmt.Errorf("failed to secure the connection to event store on %+v. details: %s\n", address, err.Error())
+		}
+	}
+	connection.logger().Infof("successfully connected to event store on %s (id: %+v)", address, connection.ConnectionID)
+	connection.mutex.Lock()
+	if connection.state == ConnectionStateClosed {
+		// the connection was closed while connecting
+		connection.mutex.Unlock()
+		socket.Close()
+		return ErrConnectionClosed
+	}
+	connection.Socket = socket
+	connection.stopWriterLocked()
+	connection.writer = newSocketWriter(socket, time.Duration(connection.Config.WriteTimeout)*time.Millisecond)
+	old := connection.setStateLocked(ConnectionStateConnected)
+	atomic.StoreInt64(&connection.stats.connectedAt, time.Now().UnixNano())
+	if connection.Config.KeepAliveInterval > 0 {
+		connection.stopKeepAliveLocked()
+		connection.stopKeepAlive = make(chan struct{})
+		connection.workers.Add(1)
+		go func(stop <-chan struct{}) {
+			defer connection.workers.Done()
+			keepAlive(connection, stop)
+		}(connection.stopKeepAlive)
+	}
+	connection.workers.Add(1)
+	done := connection.done
+	readerStopped := make(chan struct{})
+	connection.readerStopped = readerStopped
+	connection.mutex.Unlock()
+	connection.notifyStateChange(old, ConnectionStateConnected)
 
-	go readFromSocket(connection)
+	go func() {
+		defer connection.workers.Done()
+		defer close(readerStopped)
+		readFromSocket(connection, socket, done)
+	}()
+	err = identify(connection)
+	if err != nil {
+		connection.reportError(fmt.Errorf("failed to identify the connection: %s", err.Error()))
+	}
+	if connection.Config.OnConnected != nil {
+		connection.Config.OnConnected()
+	}
 	return nil
 }
 
+func (connection *EventStoreConnection) dialer() func(ctx context.Context, network string, address string) (net.Conn, error) {
+	if connection.Config.Dialer != nil {
+		return connection.Config.Dialer
+	}
+	// the keep-alive is configured on the socket instead of by the dialer
+	dialer := &net.Dialer{KeepAlive: -1}
+	return dialer.DialContext
+}
+
+// configureSocket applies the tcp options of the configuration to the socket
+func configureSocket(config *Configuration, socket net.Conn) error {
+	tcpSocket, ok := socket.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+	err := tcpSocket.SetNoDelay(config.TCPNoDelay)
+	if err != nil {
+		return err
+	}
+	if config.TCPKeepAlivePeriod <= 0 {
+		return tcpSocket.SetKeepAlive(false)
+	}
+	err = tcpSocket.SetKeepAlive(true)
+	if err != nil {
+		return err
+	}
+	return tcpSocket.SetKeepAlivePeriod(time.Duration(config.TCPKeepAlivePeriod) * time.Millisecond)
+}
+
+// identify tells the server which client owns the connection
+func identify(connection *EventStoreConnection) error {
+	identifyData := &protobuf.IdentifyClient{
+		Version:        proto.Int32(clientVersion),
+		ConnectionName: proto.String(connection.connectionName()),
+	}
+	data, err := proto.Marshal(identifyData)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return err
+	}
+	pkg, err := newPackage(identifyClient, data, uuid.NewV4().Bytes(), "", "")
+	if err != nil {
+		return err
+	}
+	return pkg.write(connection)
+}
+
+func (connection *EventStoreConnection) connectionName() string {
+	if connection.Config.ConnectionName != "" {
+		return connection.Config.ConnectionName
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return connection.ConnectionID.String()
+	}
+	return fmt.Sprintf("%s-%s", hostname, connection.ConnectionID)
+}
+
+// secure performs the tls handshake over the socket, the socket is closed when the handshake fails
+func secure(ctx context.Context, config *Configuration, socket net.Conn) (net.Conn, error) {
+	tlsConfig := &tls.Config{}
+	if config.TLSConfig != nil {
+		tlsConfig = config.TLSConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = strings.TrimSuffix(strings.TrimPrefix(config.Address, "["), "]")
+	}
+	tlsSocket := tls.Client(socket, tlsConfig)
+	err := tlsSocket.HandshakeContext(ctx)
+	if err != nil {
+		socket.Close()
+		return nil, err
+	}
+	return tlsSocket, nil
+}
+
 func closeConnection(connection *EventStoreConnection) {
-	log.Printf("[error] connection (id: %+v) closed\n", connection.ConnectionID)
+	connection.logger().Errorf("connection (id: %+v) closed", connection.ConnectionID)
 
 	reason := protobuf.SubscriptionDropped_Unsubscribed
 	subDropped := &protobuf.SubscriptionDropped{
@@ -144,90 +551,444 @@ func closeConnection(connection *EventStoreConnection) {
 	}
 	data, err := proto.Marshal(subDropped)
 	if err != nil {
-		log.Fatal("[fatal] marshalling error: ", err)
+		connection.reportError(fmt.Errorf("failed to marshal subscription dropped: %s", err.Error()))
 	}
 
-	for _, sub := range connection.subscriptions {
+	connection.mutex.Lock()
+	subscriptions := connection.subscriptions
+	connection.requests = make(map[uuid.UUID]chan<- TCPPackage)
+	connection.subscriptions = make(map[uuid.UUID]*Subscription)
+	connection.queue = nil
+	select {
+	case <-connection.done:
+	default:
+		if connection.done != nil {
+			close(connection.done)
+		}
+	}
+	connection.mutex.Unlock()
+
+	for _, sub := range subscriptions {
 		pkg, err := newPackage(subscriptionDropped, data, sub.CorrelationID.Bytes(), connection.Config.Login, connection.Config.Password)
 		if err != nil {
-			log.Printf("[error] failed to drop subscription %v", sub.CorrelationID)
+			connection.logger().Errorf("failed to drop subscription %v", sub.CorrelationID)
 		}
-		sub.Channel <- pkg
+		sub.dropping(SubscriptionDropReasonConnectionClosed, ErrConnectionClosed)
+		sub.enqueue(pkg)
 	}
+}
+
+// disconnect closes the socket after the connection was lost. Pending operations fail with ErrConnectionLost while the
+// subscriptions are kept so that they can be resubscribed once the connection is re-established. Unless it is called by
+// the reader of the socket, it waits for the reader to stop before failing the pending operations, as the reader may be
+// delivering to them.
+func disconnect(connection *EventStoreConnection, byReader bool) {
+	connection.logger().Errorf("connection (id: %+v) lost", connection.ConnectionID)
+	connection.mutex.Lock()
+	old := connection.setStateLocked(ConnectionStateReconnecting)
+	socket := connection.Socket
+	connection.Socket = nil
+	readerStopped := connection.readerStopped
+	connection.stopKeepAliveLocked()
+	connection.stopWriterLocked()
+	requests := connection.requests
 	connection.requests = make(map[uuid.UUID]chan<- TCPPackage)
+	for correlationID, subscription := range connection.subscriptions {
+		connection.requests[correlationID] = subscription.Channel
+		delete(requests, correlationID)
+	}
+	connection.mutex.Unlock()
+	connection.notifyStateChange(old, ConnectionStateReconnecting)
+
+	if socket != nil {
+		socket.Close()
+	}
+	if !byReader && readerStopped != nil {
+		<-readerStopped
+	}
+	// the socket reader is the only sender on the request channels and it has stopped
+	for _, request := range requests {
+		close(request)
+	}
+}
+
+// reestablish replaces the connection that was lost with err. The subscriptions are subscribed again and the queued
+// operations are sent once the connection is re-established. It is called by the reader of the lost socket.
+func reestablish(connection *EventStoreConnection, err error) {
+	disconnect(connection, true)
+	if connection.Config.OnDisconnected != nil {
+		connection.Config.OnDisconnected(err)
+	}
+	err = connectWithRetries(context.Background(), connection, connection.Config.MaxReconnects)
+	connection.reconnected(err)
+	if err != nil {
+		connection.logger().Errorf("(id: %+v) %s", connection.ConnectionID, err.Error())
+		return
+	}
+	connection.logger().Infof("connection (id: %+v) reconnected", connection.ConnectionID)
+	resubscribe(connection)
+	connection.flushQueue()
+}
+
+// reconnectToMaster replaces the connection with one to the master after a node reported that it is not the master.
+// The discoverer is not consulted as the node already told us where the master is, unless the master cannot be reached.
+func reconnectToMaster(ctx context.Context, connection *EventStoreConnection, master *protobuf.NotHandled_MasterInfo) error {
+	address := master.GetExternalTcpAddress()
+	port := int(master.GetExternalTcpPort())
+	connection.mutex.Lock()
+	reconnected := connection.state == ConnectionStateConnected && connection.Config.Address == address && connection.Config.Port == port
+	if !reconnected {
+		connection.Config.Address = address
+		connection.Config.Port = port
+	}
+	connection.mutex.Unlock()
+	if reconnected {
+		// another operation already reconnected to the master
+		return nil
+	}
+
+	connection.logger().Infof("connection (id: %+v) is not connected to the master, reconnecting to %s:%v", connection.ConnectionID, address, port)
+	// the cached node is not the master anymore
+	connection.invalidateDiscovery()
+	disconnect(connection, false)
+	err := connect(ctx, connection)
+	if err != nil {
+		connection.logger().Errorf("failed to connect to the master: %s", err.Error())
+		err = connectWithRetries(ctx, connection, connection.Config.MaxReconnects)
+	}
+	connection.reconnected(err)
+	if err != nil {
+		return err
+	}
+	resubscribe(connection)
+	connection.flushQueue()
+	return nil
+}
+
+// resubscribe sends the subscribe packages of the subscriptions that were active when the connection was lost.
+// Each subscription gets a new correlation id and keeps delivering to the same channel.
+func resubscribe(connection *EventStoreConnection) {
+	connection.mutex.Lock()
+	subscriptions := connection.subscriptions
 	connection.subscriptions = make(map[uuid.UUID]*Subscription)
+	for oldCorrelationID, subscription := range subscriptions {
+		delete(connection.requests, oldCorrelationID)
+		subscription.CorrelationID = uuid.NewV4()
+		connection.requests[subscription.CorrelationID] = subscription.Channel
+		connection.subscriptions[subscription.CorrelationID] = subscription
+	}
+	connection.mutex.Unlock()
+
+	for _, subscription := range subscriptions {
+		pkg, err := newPackage(subscription.subscribeCommand, subscription.subscribeData, subscription.correlationID().Bytes(), subscription.credentials.Login, subscription.credentials.Password)
+		if err == nil {
+			err = pkg.write(connection)
+		}
+		if err != nil {
+			connection.reportError(fmt.Errorf("failed to resubscribe: %s", err.Error()))
+		}
+	}
+}
+
+// keepAlive pings the server every KeepAliveInterval until stop is closed. The pong is matched to the ping by its correlation id.
+func keepAlive(connection *EventStoreConnection, stop <-chan struct{}) {
+	interval := time.Duration(connection.Config.KeepAliveInterval) * time.Millisecond
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := connection.PingWithContext(ctx)
+		cancel()
+		if err != nil {
+			connection.logger().Debugf("no pong received for ping (id: %+v): %s", connection.ConnectionID, err.Error())
+		}
+	}
+}
+
+func (connection *EventStoreConnection) stopKeepAliveLocked() {
+	if connection.stopKeepAlive != nil {
+		close(connection.stopKeepAlive)
+		connection.stopKeepAlive = nil
+	}
 }
 
-func readFromSocket(connection *EventStoreConnection) {
-	buffer := make([]byte, 40000)
+func (connection *EventStoreConnection) stopWriterLocked() {
+	if connection.writer != nil {
+		connection.writer.close()
+		connection.writer = nil
+	}
+}
+
+// deadlineReader fails a read when no data arrives from the socket within the timeout
+type deadlineReader struct {
+	socket  net.Conn
+	timeout time.Duration
+}
+
+func (reader deadlineReader) Read(p []byte) (int, error) {
+	err := reader.socket.SetReadDeadline(time.Now().Add(reader.timeout))
+	if err != nil {
+		return 0, err
+	}
+	return reader.socket.Read(p)
+}
+
+// readFromSocket delivers the packages read from the socket until the socket is replaced or done is closed by closing
+// the connection. A read that fails because the connection was closed is the expected way for it to stop.
+func readFromSocket(connection *EventStoreConnection, socket net.Conn, done <-chan struct{}) {
+	var reader *bufio.Reader
+	if connection.Config.HeartbeatTimeout > 0 {
+		reader = bufio.NewReader(deadlineReader{socket: socket, timeout: time.Duration(connection.Config.HeartbeatTimeout) * time.Millisecond})
+	} else {
+		reader = bufio.NewReader(socket)
+	}
 	for {
-		connection.Mutex.Lock()
-		if connection.connected == false {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if !connection.isConnected() {
 			break
 		}
-		connection.Mutex.Unlock()
-		_, err := connection.Socket.Read(buffer)
+		buffer := getBuffer()
+		packageBytes, err := readPackage(reader, connection.maxPackageSize(), buffer)
+		if tooLarge, ok := err.(*ErrPackageTooLarge); ok {
+			putBuffer(buffer)
+			atomic.AddInt64(&connection.stats.bytesRead, int64(4+tooLarge.PackageLength))
+			connection.reportError(tooLarge)
+			continue
+		}
 		if err != nil {
-			if connection.connected && err.Error() != "EOF" {
-				log.Fatalf("[fatal] (id: %+v) failed to read with %+v\n", connection.ConnectionID, err.Error())
+			putBuffer(buffer)
+			select {
+			case <-done:
+				// the socket was closed by closing the connection
+				return
+			default:
 			}
-			if err.Error() == "EOF" {
-				connection.Close()
-				err = connectWithRetries(connection, connection.Config.MaxReconnects)
-				if err != nil {
-					log.Printf("[error] (id: %+v) %s\n", connection.ConnectionID, err.Error())
-				} else {
-					log.Printf("[info] connection (id: %+v) reconnected\n", connection.ConnectionID)
+			if connection.socket() != socket {
+				// the connection was closed or replaced, e.g. when reconnecting to the master
+				break
+			}
+			lost := err == io.EOF || err == io.ErrUnexpectedEOF
+			if connection.writeTimedOut(socket) {
+				// the writer closed the socket as a package could not be written in time
+				connection.logger().Errorf("failed to write to event store in %vms (id: %+v), reconnecting", connection.Config.WriteTimeout, connection.ConnectionID)
+				err = ErrWriteTimeout
+				lost = true
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() && connection.isConnected() {
+				connection.logger().Errorf("no data received from event store in %vms (id: %+v), reconnecting", connection.Config.HeartbeatTimeout, connection.ConnectionID)
+				if connection.Config.Metrics != nil {
+					connection.Config.Metrics.HeartbeatTimedOut()
 				}
+				lost = true
+			}
+			if connection.isConnected() && !lost {
+				connection.reportError(fmt.Errorf("failed to read from socket: %s", err.Error()))
+				connection.Close()
+			}
+			if lost {
+				reestablish(connection, err)
 			}
 			break
 		}
 
-		msg, err := parsePackage(buffer)
+		msg, err := parsePackage(packageBytes)
+		putBuffer(buffer)
 		if err != nil {
-			log.Fatalf("[fatal] could not decode tcp package: %+v\n", err.Error())
+			atomic.AddInt64(&connection.stats.bytesRead, int64(len(packageBytes)))
+			connection.reportError(fmt.Errorf("could not decode tcp package: %s", err.Error()))
+			continue
 		}
+		connection.stats.packageReceived(msg.Command, len(packageBytes))
 		switch msg.Command {
 		case heartbeatRequest:
+			atomic.StoreInt64(&connection.stats.lastHeartbeat, time.Now().UnixNano())
+			if connection.Config.Metrics != nil {
+				connection.Config.Metrics.HeartbeatReceived()
+			}
 			pkg, err := newPackage(heartbeatResponse, nil, msg.CorrelationID, "", "")
 			if err != nil {
-				log.Printf("[error] failed to create new heartbeat response package\n")
+				connection.logger().Errorf("failed to create new heartbeat response package")
+				break
 			}
-			channel := make(chan<- TCPPackage)
-			go sendPackage(pkg, connection, channel)
+			// the response is not an operation, no response is expected for it
+			go pkg.write(connection)
 			break
-		case pong:
-			pkg, err := newPackage(ping, nil, uuid.NewV4().Bytes(), "", "")
-			if err != nil {
-				log.Printf("[error] failed to create new ping response package")
+		case pong, writeEventsCompleted, transactionStartCompleted, transactionWriteCompleted, transactionCommitCompleted, readEventCompleted, deleteStreamCompleted, readStreamEventsForwardCompleted, readStreamEventsBackwardCompleted, readAllEventsForwardCompleted, readAllEventsBackwardCompleted, subscriptionConfirmation, streamEventAppeared, checkpointReached, subscriptionDropped, persistentSubscriptionStreamEventAppeared, createPersistentSubscriptionCompleted, updatePersistentSubscriptionCompleted, deletePersistentSubscriptionCompleted, persistentSubscriptionConfirmation:
+			correlationID, _ := uuid.FromBytes(msg.CorrelationID)
+			if !connection.deliver(correlationID, msg) {
+				connection.logger().Debugf("dropping %s for unknown correlation id %v", msg.Command.String(), correlationID)
+			}
+			if isServerShutdown(msg) {
+				// the dropped subscription is not subscribed again on the node the connection moves to
+				connection.mutex.Lock()
+				delete(connection.subscriptions, correlationID)
+				// the subscription's channel is not closed with the pending requests, it may still be subscribed again
+				delete(connection.requests, correlationID)
+				connection.mutex.Unlock()
+				connection.logger().Infof("the node of connection (id: %+v) is shutting down, reconnecting", connection.ConnectionID)
+				// another node is discovered rather than reconnecting to the node that is going down
+				connection.invalidateDiscovery()
+				reestablish(connection, ErrServerShutdown)
+				return
 			}
-			channel := make(chan<- TCPPackage)
-			go sendPackage(pkg, connection, channel)
 			break
-		case writeEventsCompleted, readEventCompleted, deleteStreamCompleted, readStreamEventsForwardCompleted, readStreamEventsBackwardCompleted, subscriptionConfirmation, streamEventAppeared, createPersistentSubscriptionCompleted, persistentSubscriptionConfirmation:
+		case notAuthenticated, badRequest, notHandled:
 			correlationID, _ := uuid.FromBytes(msg.CorrelationID)
-			if request, ok := connection.requests[correlationID]; ok {
-				request <- msg
+			if !connection.deliver(correlationID, msg) {
+				connection.reportError(fmt.Errorf("received %s for unknown correlation id %v", msg.Command.String(), correlationID))
 			}
-			break
-		case notAuthenticated:
+		case clientIdentified:
+			// the identification of the client is not waited for
+		default:
+			// a command this client does not know, e.g. one added by a newer server, is passed to the request waiting for
+			// it, which fails with an unexpected response, and dropped otherwise
 			correlationID, _ := uuid.FromBytes(msg.CorrelationID)
-			if request, ok := connection.requests[correlationID]; ok {
-				request <- msg
+			if !connection.deliver(correlationID, msg) {
+				connection.logger().Debugf("dropping unknown command %#x for correlation id %v", byte(msg.Command), correlationID)
 			}
-		case 0x0F:
-			log.Fatal("[fatal] bad request sent")
-			break
 		}
 	}
 }
 
+// deliver routes a package received from the server by its correlation id. Packages of a registered subscription go to
+// the subscription, which receives any number of them. Any other request is completed by its first response and is
+// forgotten, so that a duplicate or late response can not hold up the reader, unless the package is meant for a subscription
+// that is still being registered. It returns false when the correlation id is unknown, in which case the package is dropped.
+func (connection *EventStoreConnection) deliver(correlationID uuid.UUID, pkg TCPPackage) bool {
+	connection.mutex.Lock()
+	channel, ok := connection.requests[correlationID]
+	subscription := connection.subscriptions[correlationID]
+	if ok && subscription == nil && !isSubscriptionCommand(pkg.Command) {
+		delete(connection.requests, correlationID)
+	}
+	connection.mutex.Unlock()
+	if !ok {
+		return false
+	}
+	if subscription != nil {
+		connection.deliverToSubscription(subscription, channel, pkg)
+		return true
+	}
+	channel <- pkg
+	return true
+}
+
+// isSubscriptionCommand reports whether the command is sent to a subscription, which keeps receiving packages after it
+func isSubscriptionCommand(command Command) bool {
+	switch command {
+	case subscriptionConfirmation, streamEventAppeared, checkpointReached, subscriptionDropped, persistentSubscriptionConfirmation, persistentSubscriptionStreamEventAppeared:
+		return true
+	}
+	return false
+}
+
 func sendPackage(pkg TCPPackage, connection *EventStoreConnection, channel chan<- TCPPackage) error {
 	correlationID, _ := uuid.FromBytes(pkg.CorrelationID)
+	connection.mutex.Lock()
 	connection.requests[correlationID] = channel
-	err := pkg.write(connection)
+	queued, err := connection.queueLocked(pkg)
+	connection.mutex.Unlock()
+	if queued || err != nil {
+		return err
+	}
+	err = pkg.write(connection)
 	if err != nil {
 		return err
 	}
+	if connection.Config.Metrics != nil {
+		connection.Config.Metrics.OperationSent(pkg.Command)
+	}
 	return nil
 }
+
+func (connection *EventStoreConnection) maxPackageSize() int {
+	if connection.Config.MaxPackageSize <= 0 {
+		return DefaultMaxPackageSize
+	}
+	return connection.Config.MaxPackageSize
+}
+
+func (connection *EventStoreConnection) logger() Logger {
+	if connection.Config.Logger == nil {
+		return stdLogger{}
+	}
+	return connection.Config.Logger
+}
+
+func (connection *EventStoreConnection) reportError(err error) {
+	connection.logger().Errorf("(id: %+v) %s", connection.ConnectionID, err.Error())
+	if connection.Config.OnError != nil {
+		connection.Config.OnError(err)
+	}
+}
+
+// acquireInflight waits for a slot for an operation to be sent, giving up when ctx is cancelled
+func (connection *EventStoreConnection) acquireInflight(ctx context.Context) error {
+	if connection.inflight == nil {
+		return nil
+	}
+	select {
+	case connection.inflight <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (connection *EventStoreConnection) releaseInflight() {
+	if connection.inflight != nil {
+		<-connection.inflight
+	}
+}
+
+// closed returns a channel that is closed when the connection is closed
+func (connection *EventStoreConnection) closed() <-chan struct{} {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return connection.done
+}
+
+func (connection *EventStoreConnection) removeRequest(correlationID uuid.UUID) {
+	connection.mutex.Lock()
+	delete(connection.requests, correlationID)
+	connection.mutex.Unlock()
+}
+
+// registerSubscription keeps track of a confirmed subscription. It fails when the connection was lost before the
+// subscription could be registered, in which case its channel has been closed.
+func (connection *EventStoreConnection) registerSubscription(subscription *Subscription) bool {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	if _, ok := connection.requests[subscription.CorrelationID]; !ok {
+		return false
+	}
+	connection.subscriptions[subscription.CorrelationID] = subscription
+	return true
+}
+
+func (connection *EventStoreConnection) socketWriter() *socketWriter {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return connection.writer
+}
+
+func (connection *EventStoreConnection) socket() net.Conn {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return connection.Socket
+}
+
+func (connection *EventStoreConnection) isConnected() bool {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return connection.state == ConnectionStateConnected
+}
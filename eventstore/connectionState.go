@@ -0,0 +1,114 @@
+package goes
+
+import (
+	"context"
+)
+
+// ConnectionState is the state of the connection to Event Store
+type ConnectionState int
+
+const (
+	// ConnectionStateDisconnected is the state of a connection that has not been connected yet
+	ConnectionStateDisconnected ConnectionState = iota
+	// ConnectionStateConnecting is the state while the connection is being established by Connect
+	ConnectionStateConnecting
+	// ConnectionStateConnected is the state while operations can be sent to the server
+	ConnectionStateConnected
+	// ConnectionStateReconnecting is the state while the connection is re-established after it was lost
+	ConnectionStateReconnecting
+	// ConnectionStateClosed is the state after the connection was closed or could not be re-established
+	ConnectionStateClosed
+)
+
+func (state ConnectionState) String() string {
+	switch state {
+	case ConnectionStateDisconnected:
+		return "Disconnected"
+	case ConnectionStateConnecting:
+		return "Connecting"
+	case ConnectionStateConnected:
+		return "Connected"
+	case ConnectionStateReconnecting:
+		return "Reconnecting"
+	case ConnectionStateClosed:
+		return "Closed"
+	}
+	return "Unknown"
+}
+
+// State returns the current state of the connection
+func (connection *EventStoreConnection) State() ConnectionState {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return connection.state
+}
+
+// WaitForConnection blocks until the connection is connected, e.g. while Connect is called from another goroutine or
+// while a lost connection is re-established. It fails with ErrConnectionClosed once the connection is closed and with
+// the error of ctx when ctx is done first.
+func (connection *EventStoreConnection) WaitForConnection(ctx context.Context) error {
+	return connection.waitForConnection(ctx, false)
+}
+
+// waitForConnection is WaitForConnection, which also fails with ErrConnectionClosed when the connection is disconnected
+// if failWhenDisconnected is set, as it is when reconnecting gave up
+func (connection *EventStoreConnection) waitForConnection(ctx context.Context, failWhenDisconnected bool) error {
+	for {
+		connection.mutex.Lock()
+		switch connection.state {
+		case ConnectionStateConnected:
+			connection.mutex.Unlock()
+			return nil
+		case ConnectionStateClosed:
+			connection.mutex.Unlock()
+			return ErrConnectionClosed
+		case ConnectionStateDisconnected:
+			if failWhenDisconnected {
+				connection.mutex.Unlock()
+				return ErrConnectionClosed
+			}
+		}
+		if connection.stateChanged == nil {
+			connection.stateChanged = make(chan struct{})
+		}
+		stateChanged := connection.stateChanged
+		connection.mutex.Unlock()
+		select {
+		case <-stateChanged:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// setStateLocked changes the state and returns the previous state. The caller holds the mutex and calls
+// notifyStateChange once it has been released.
+func (connection *EventStoreConnection) setStateLocked(state ConnectionState) ConnectionState {
+	old := connection.state
+	connection.state = state
+	if old != state && connection.stateChanged != nil {
+		close(connection.stateChanged)
+		connection.stateChanged = nil
+	}
+	return old
+}
+
+func (connection *EventStoreConnection) setState(state ConnectionState) {
+	connection.mutex.Lock()
+	old := connection.setStateLocked(state)
+	connection.mutex.Unlock()
+	connection.notifyStateChange(old, state)
+}
+
+func (connection *EventStoreConnection) notifyStateChange(old ConnectionState, state ConnectionState) {
+	if old == state {
+		return
+	}
+	connection.logger().Debugf("connection (id: %+v) state changed from %s to %s", connection.ConnectionID, old, state)
+	if connection.Config.Metrics != nil {
+		connection.Config.Metrics.ConnectionStateChanged(state)
+	}
+	if connection.Config.OnStateChange != nil {
+		connection.Config.OnStateChange(old, state)
+	}
+}
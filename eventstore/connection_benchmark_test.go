@@ -0,0 +1,96 @@
+package goes_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+// BenchmarkReadThroughput measures reading events delivered to a subscription, one event per operation
+func BenchmarkReadThroughput(b *testing.B) {
+	data, err := proto.Marshal(&protobuf.StreamEventAppeared{
+		Event: &protobuf.ResolvedEvent{
+			Event:           newTestEventRecord("testStream", 0),
+			CommitPosition:  proto.Int64(0),
+			PreparePosition: proto.Int64(0),
+		},
+	})
+	if err != nil {
+		b.Fatalf("Unexpected failure marshalling stream event appeared: %s", err.Error())
+	}
+	confirmation, err := proto.Marshal(&protobuf.SubscriptionConfirmation{
+		LastCommitPosition: proto.Int64(0),
+	})
+	if err != nil {
+		b.Fatalf("Unexpected failure marshalling subscription confirmation: %s", err.Error())
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	defer listener.Close()
+	start := make(chan struct{})
+	go func() {
+		socket, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer socket.Close()
+		subscribe, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionConfirmationCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data:          confirmation,
+		}))
+		frame := encodeTestPackage(testPackage{
+			Command:       streamEventAppearedCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data:          data,
+		})
+		frames := bytes.Repeat(frame, b.N)
+		<-start
+		socket.Write(frames)
+		readTestPackage(socket)
+	}()
+
+	config := goes.NewConfiguration()
+	config.Address = "127.0.0.1"
+	config.Port = listener.Addr().(*net.TCPAddr).Port
+	config.MaxReconnects = 1
+	config.Logger = &testLogger{}
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		b.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	err = conn.Connect()
+	if err != nil {
+		b.Fatalf("Unexpected failure connecting: %s", err.Error())
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	received := 0
+	_, err = conn.SubscribeToStream("testStream", false, func(goes.ResolvedEvent) {
+		received++
+		if received == b.N {
+			close(done)
+		}
+	})
+	if err != nil {
+		b.Fatalf("Unexpected failure %+v", err)
+	}
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	close(start)
+	<-done
+}
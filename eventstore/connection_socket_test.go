@@ -0,0 +1,90 @@
+//go:build linux
+// +build linux
+
+package goes
+
+import (
+	"crypto/tls"
+	"net"
+	"syscall"
+	"testing"
+)
+
+// testSocketOption reads an option of the socket as the kernel applied it
+func testSocketOption(t *testing.T, socket *net.TCPConn, level int, option int) int {
+	raw, err := socket.SyscallConn()
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	var value int
+	var optionErr error
+	err = raw.Control(func(fd uintptr) {
+		value, optionErr = syscall.GetsockoptInt(int(fd), level, option)
+	})
+	if err != nil || optionErr != nil {
+		t.Fatalf("Unexpected failure %+v %+v", err, optionErr)
+	}
+	return value
+}
+
+func TestConfigureSocket_WithATCPConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	defer listener.Close()
+	go func() {
+		socket, err := listener.Accept()
+		if err == nil {
+			defer socket.Close()
+			socket.Read(make([]byte, 1))
+		}
+	}()
+
+	tests := []struct {
+		noDelay           bool
+		keepAlivePeriod   int
+		expectedNoDelay   int
+		expectedKeepAlive int
+		expectedKeepIdle  int
+	}{
+		{noDelay: true, keepAlivePeriod: 2000, expectedNoDelay: 1, expectedKeepAlive: 1, expectedKeepIdle: 2},
+		{noDelay: false, keepAlivePeriod: 0, expectedNoDelay: 0, expectedKeepAlive: 0},
+	}
+	for _, test := range tests {
+		socket, err := (&net.Dialer{KeepAlive: -1}).Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Unexpected failure connecting: %s", err.Error())
+		}
+		config := &Configuration{TCPNoDelay: test.noDelay, TCPKeepAlivePeriod: test.keepAlivePeriod}
+		if err := configureSocket(config, socket); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		tcpSocket := socket.(*net.TCPConn)
+		if noDelay := testSocketOption(t, tcpSocket, syscall.IPPROTO_TCP, syscall.TCP_NODELAY); noDelay != test.expectedNoDelay {
+			t.Fatalf("Expected TCP_NODELAY %v got %v", test.expectedNoDelay, noDelay)
+		}
+		if keepAlive := testSocketOption(t, tcpSocket, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE); keepAlive != test.expectedKeepAlive {
+			t.Fatalf("Expected SO_KEEPALIVE %v got %v", test.expectedKeepAlive, keepAlive)
+		}
+		if test.expectedKeepAlive == 1 {
+			if keepIdle := testSocketOption(t, tcpSocket, syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE); keepIdle != test.expectedKeepIdle {
+				t.Fatalf("Expected TCP_KEEPIDLE %v got %v", test.expectedKeepIdle, keepIdle)
+			}
+		}
+		socket.Close()
+	}
+}
+
+func TestConfigureSocket_SkipsConnectionsThatAreNotTCP(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	config := &Configuration{TCPNoDelay: true, TCPKeepAlivePeriod: 2000}
+
+	for _, socket := range []net.Conn{client, tls.Client(client, &tls.Config{InsecureSkipVerify: true})} {
+		if err := configureSocket(config, socket); err != nil {
+			t.Fatalf("Expected %v got %v", nil, err)
+		}
+	}
+}
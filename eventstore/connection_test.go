@@ -0,0 +1,1696 @@
+package goes_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	writeEventsCompletedCommand byte = 0x83
+	readEventCompletedCommand   byte = 0xB1
+	badRequestCommand           byte = 0xF0
+	notHandledCommand           byte = 0xF1
+	notAuthenticatedCommand     byte = 0xF4
+	identifyClientCommand       byte = 0xF5
+	pingCommand                 byte = 0x03
+	pongCommand                 byte = 0x04
+)
+
+type testPackage struct {
+	Command       byte
+	CorrelationID []byte
+	Login         string
+	Password      string
+	Data          []byte
+}
+
+// startTestServer starts a tcp listener on the loopback interface and returns a connection configured to connect to it
+func startTestServer(t *testing.T, handler func(net.Conn)) (*goes.EventStoreConnection, net.Listener) {
+	return startTestServerWithConfiguration(t, goes.NewConfiguration(), handler)
+}
+
+func startTestServerWithConfiguration(t *testing.T, config *goes.Configuration, handler func(net.Conn)) (*goes.EventStoreConnection, net.Listener) {
+	return startTestServerWithHandlers(t, config, handler)
+}
+
+// startTestServerWithHandlers serves each accepted connection with the next handler, so that reconnects can be tested
+func startTestServerWithHandlers(t *testing.T, config *goes.Configuration, handlers ...func(net.Conn)) (*goes.EventStoreConnection, net.Listener) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	go func() {
+		for _, handler := range handlers {
+			socket, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			handler(socket)
+		}
+	}()
+
+	return connectTestConnection(t, config, listener.Addr().(*net.TCPAddr).Port), listener
+}
+
+// startTestFakeServer starts a fake server and returns a connection configured to connect to it. The responses of the
+// server can be scripted per test with Handle.
+func startTestFakeServer(tb testing.TB, config *goes.Configuration) (*goes.EventStoreConnection, *fakeserver.Server) {
+	server, err := fakeserver.New()
+	if err != nil {
+		tb.Fatalf("Unexpected failure starting the fake server: %s", err.Error())
+	}
+	return connectTestConnection(tb, config, server.Port()), server
+}
+
+// connectTestConnection connects to the test server listening on the loopback interface on the port
+func connectTestConnection(tb testing.TB, config *goes.Configuration, port int) *goes.EventStoreConnection {
+	config.Address = "127.0.0.1"
+	config.Port = port
+	config.MaxReconnects = 1
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		tb.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	err = conn.Connect()
+	if err != nil {
+		tb.Fatalf("Unexpected failure connecting: %s", err.Error())
+	}
+	return conn
+}
+
+// readTestPackage reads the next package sent by the client, skipping the packages that identify the client and keep the connection alive
+func readTestPackage(reader io.Reader) (testPackage, error) {
+	for {
+		pkg, err := readRawTestPackage(reader)
+		if err != nil || (pkg.Command != identifyClientCommand && pkg.Command != pingCommand) {
+			return pkg, err
+		}
+	}
+}
+
+func readRawTestPackage(reader io.Reader) (testPackage, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return testPackage{}, err
+	}
+	body := make([]byte, binary.LittleEndian.Uint32(header))
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return testPackage{}, err
+	}
+	pkg := testPackage{
+		Command:       body[0],
+		CorrelationID: body[2:18],
+	}
+	offset := 18
+	if body[1]&0x01 == 0x01 {
+		loginLength := int(body[offset])
+		pkg.Login = string(body[offset+1 : offset+1+loginLength])
+		offset += 1 + loginLength
+		passwordLength := int(body[offset])
+		pkg.Password = string(body[offset+1 : offset+1+passwordLength])
+		offset += 1 + passwordLength
+	}
+	pkg.Data = body[offset:]
+	return pkg, nil
+}
+
+func encodeTestPackage(pkg testPackage) []byte {
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, uint32(18+len(pkg.Data)))
+	buffer.WriteByte(pkg.Command)
+	buffer.WriteByte(0x00)
+	buffer.Write(pkg.CorrelationID)
+	buffer.Write(pkg.Data)
+	return buffer.Bytes()
+}
+
+func newTestReadEventCompleted(t *testing.T, data []byte) []byte {
+	result := protobuf.ReadEventCompleted_Success
+	message := &protobuf.ReadEventCompleted{
+		Result: &result,
+		Event: &protobuf.ResolvedIndexedEvent{
+			Event: &protobuf.EventRecord{
+				EventStreamId:       proto.String("testStream"),
+				EventNumber:         proto.Int32(0),
+				EventId:             goes.EncodeNetUUID(uuid.NewV4().Bytes()),
+				EventType:           proto.String("TestEvent"),
+				DataContentType:     proto.Int32(0),
+				MetadataContentType: proto.Int32(0),
+				Data:                data,
+			},
+		},
+	}
+	bytes, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("Unexpected failure marshalling read event completed: %s", err.Error())
+	}
+	return bytes
+}
+
+func TestReadFromSocket_WithPackageLargerThanReadBuffer(t *testing.T) {
+	data := bytes.Repeat([]byte{0x01}, 100000)
+	response := newTestReadEventCompleted(t, data)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		request, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readEventCompletedCommand,
+			CorrelationID: request.CorrelationID,
+			Data:          response,
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	result, err := goes.ReadSingleEvent(conn, "testStream", 0, true, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(result.GetEvent().GetEvent().GetData()) != len(data) {
+		t.Fatalf("Expected %d bytes of data got %d", len(data), len(result.GetEvent().GetEvent().GetData()))
+	}
+}
+
+func TestReadFromSocket_WithMultiplePackagesInASingleWrite(t *testing.T) {
+	response := newTestReadEventCompleted(t, []byte("{}"))
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		var frames []byte
+		for i := 0; i < 2; i++ {
+			request, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			frames = append(frames, encodeTestPackage(testPackage{
+				Command:       readEventCompletedCommand,
+				CorrelationID: request.CorrelationID,
+				Data:          response,
+			})...)
+		}
+		socket.Write(frames)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := goes.ReadSingleEvent(conn, "testStream", 0, true, true)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+}
+
+func TestSendPackage_WithConcurrentWrites(t *testing.T) {
+	result := protobuf.OperationResult_Success
+	response, err := proto.Marshal(&protobuf.WriteEventsCompleted{
+		Result:           &result,
+		FirstEventNumber: proto.Int32(0),
+		LastEventNumber:  proto.Int32(0),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure marshalling write events completed: %s", err.Error())
+	}
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		for {
+			request, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       writeEventsCompletedCommand,
+				CorrelationID: request.CorrelationID,
+				Data:          response,
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	writers := 50
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := goes.AppendToStream(conn, uuid.NewV4().String(), -2, []goes.Event{createTestEvent()})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+}
+
+func TestReadFromSocket_WithBadRequestResponse(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		request, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       badRequestCommand,
+			CorrelationID: request.CorrelationID,
+			Data:          []byte("invalid package"),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := goes.ReadSingleEvent(conn, "testStream", 0, true, true)
+	if err == nil {
+		t.Fatalf("Expected failure")
+	}
+	if !strings.Contains(err.Error(), "invalid package") {
+		t.Fatalf("Expected error to contain %s got %s", "invalid package", err.Error())
+	}
+}
+
+func TestReadFromSocket_WithUnexpectedBadRequestCallsOnError(t *testing.T) {
+	errs := make(chan error, 1)
+	config := goes.NewConfiguration()
+	config.OnError = func(err error) {
+		errs <- err
+	}
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       badRequestCommand,
+			CorrelationID: uuid.NewV4().Bytes(),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected OnError to be called")
+	}
+}
+
+func TestConnectWithContext_WhenContextIsCancelled(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.Address = "127.0.0.1"
+	config.Port = 1113
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = conn.ConnectWithContext(ctx)
+	if err != context.Canceled {
+		t.Fatalf("Expected %v got %v", context.Canceled, err)
+	}
+}
+
+func TestPerformOperation_WhenContextDeadlineIsExceeded(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := goes.ReadSingleEventWithContext(ctx, conn, "testStream", 0, true, true)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v got %v", context.DeadlineExceeded, err)
+	}
+}
+
+type testLogger struct {
+	sync.Mutex
+	messages []string
+}
+
+func (logger *testLogger) log(format string, args ...interface{}) {
+	logger.Lock()
+	defer logger.Unlock()
+	logger.messages = append(logger.messages, fmt.Sprintf(format, args...))
+}
+
+func (logger *testLogger) Debugf(format string, args ...interface{}) { logger.log(format, args...) }
+func (logger *testLogger) Infof(format string, args ...interface{})  { logger.log(format, args...) }
+func (logger *testLogger) Errorf(format string, args ...interface{}) { logger.log(format, args...) }
+
+func TestConnect_WithCustomLogger(t *testing.T) {
+	logger := &testLogger{}
+	config := goes.NewConfiguration()
+	config.Logger = logger
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {})
+	defer listener.Close()
+	defer conn.Close()
+
+	logger.Lock()
+	defer logger.Unlock()
+	if len(logger.messages) == 0 {
+		t.Fatalf("Expected the connection to log to the configured logger")
+	}
+}
+
+// newTestCertificate creates a self signed certificate for the loopback address
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected failure generating key: %s", err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Unexpected failure creating certificate: %s", err.Error())
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Unexpected failure parsing certificate: %s", err.Error())
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, certificate
+}
+
+// startTestTLSServer starts a tls listener on the loopback interface that answers read event requests
+func startTestTLSServer(t *testing.T, certificate tls.Certificate) net.Listener {
+	response := newTestReadEventCompleted(t, []byte("{}"))
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{certificate}})
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	go func() {
+		for {
+			socket, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer socket.Close()
+				request, err := readTestPackage(socket)
+				if err != nil {
+					return
+				}
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       readEventCompletedCommand,
+					CorrelationID: request.CorrelationID,
+					Data:          response,
+				}))
+				readTestPackage(socket)
+			}()
+		}
+	}()
+	return listener
+}
+
+func newTestTLSConfiguration(listener net.Listener, tlsConfig *tls.Config) *goes.Configuration {
+	config := goes.NewConfiguration()
+	config.Address = "127.0.0.1"
+	config.Port = listener.Addr().(*net.TCPAddr).Port
+	config.MaxReconnects = 1
+	config.ReconnectionDelay = 1
+	config.UseTLS = true
+	config.TLSConfig = tlsConfig
+	return config
+}
+
+func TestConnect_WithTLS(t *testing.T) {
+	certificate, parsed := newTestCertificate(t)
+	listener := startTestTLSServer(t, certificate)
+	defer listener.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(parsed)
+
+	conn, err := goes.NewEventStoreConnection(newTestTLSConfiguration(listener, &tls.Config{RootCAs: roots}))
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	err = conn.Connect()
+	if err != nil {
+		t.Fatalf("Unexpected failure connecting: %s", err.Error())
+	}
+	defer conn.Close()
+
+	_, err = goes.ReadSingleEvent(conn, "testStream", 0, true, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestConnect_WithTLSAndUntrustedCertificate(t *testing.T) {
+	certificate, _ := newTestCertificate(t)
+	listener := startTestTLSServer(t, certificate)
+	defer listener.Close()
+
+	conn, err := goes.NewEventStoreConnection(newTestTLSConfiguration(listener, nil))
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	err = conn.Connect()
+	if err == nil {
+		conn.Close()
+		t.Fatalf("Expected the untrusted certificate to be rejected")
+	}
+}
+
+func TestConnect_WithTLSAndInsecureSkipVerify(t *testing.T) {
+	certificate, _ := newTestCertificate(t)
+	listener := startTestTLSServer(t, certificate)
+	defer listener.Close()
+
+	conn, err := goes.NewEventStoreConnection(newTestTLSConfiguration(listener, &tls.Config{InsecureSkipVerify: true}))
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	err = conn.Connect()
+	if err != nil {
+		t.Fatalf("Unexpected failure connecting: %s", err.Error())
+	}
+	defer conn.Close()
+}
+
+func TestConnect_IdentifiesClient(t *testing.T) {
+	identified := make(chan *protobuf.IdentifyClient, 1)
+	config := goes.NewConfiguration()
+	config.ConnectionName = "testConnection"
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		pkg, err := readRawTestPackage(socket)
+		if err != nil || pkg.Command != identifyClientCommand {
+			identified <- nil
+			return
+		}
+		identifyClient := &protobuf.IdentifyClient{}
+		proto.Unmarshal(pkg.Data, identifyClient)
+		identified <- identifyClient
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	select {
+	case identifyClient := <-identified:
+		if identifyClient == nil {
+			t.Fatalf("Expected the first package to identify the client")
+		}
+		if identifyClient.GetConnectionName() != "testConnection" {
+			t.Fatalf("Expected %v got %v", "testConnection", identifyClient.GetConnectionName())
+		}
+		if identifyClient.GetVersion() != 1 {
+			t.Fatalf("Expected %v got %v", 1, identifyClient.GetVersion())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the client to be identified")
+	}
+}
+
+func TestReadFromSocket_WithPackageLargerThanMaxPackageSize(t *testing.T) {
+	errs := make(chan error, 1)
+	response := newTestReadEventCompleted(t, []byte("{}"))
+	config := goes.NewConfiguration()
+	config.MaxPackageSize = 1024
+	config.OnError = func(err error) {
+		errs <- err
+	}
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		request, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readEventCompletedCommand,
+			CorrelationID: uuid.NewV4().Bytes(),
+			Data:          bytes.Repeat([]byte{0x01}, 2048),
+		}))
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readEventCompletedCommand,
+			CorrelationID: request.CorrelationID,
+			Data:          response,
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := goes.ReadSingleEvent(conn, "testStream", 0, true, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	select {
+	case err := <-errs:
+		if _, ok := err.(*goes.ErrPackageTooLarge); !ok {
+			t.Fatalf("Expected %T got %v", &goes.ErrPackageTooLarge{}, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected OnError to be called")
+	}
+}
+
+func TestReadFromSocket_WhenHeartbeatTimeoutIsExceeded(t *testing.T) {
+	reconnected := make(chan struct{})
+	silent := make(chan struct{})
+	config := goes.NewConfiguration()
+	config.HeartbeatTimeout = 100
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			// the server stops responding without closing the connection
+			go func() {
+				<-silent
+				socket.Close()
+			}()
+		},
+		func(socket net.Conn) {
+			close(reconnected)
+		})
+	defer listener.Close()
+	defer conn.Close()
+	defer close(silent)
+
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the connection to be re-established after the heartbeat timeout")
+	}
+}
+
+func TestConnect_SendsKeepAlivePings(t *testing.T) {
+	pongs := make(chan struct{}, 10)
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 50
+	config.Logger = &testLogger{}
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		for {
+			pkg, err := readRawTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command != pingCommand {
+				continue
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       pongCommand,
+				CorrelationID: pkg.CorrelationID,
+			}))
+			pongs <- struct{}{}
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-pongs:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the client to ping the server")
+		}
+	}
+	logger := config.Logger.(*testLogger)
+	logger.Lock()
+	defer logger.Unlock()
+	for _, message := range logger.messages {
+		if strings.Contains(message, "no pong received") {
+			t.Fatalf("Expected the pongs to be matched to the pings got %s", message)
+		}
+	}
+}
+
+func TestReconnectionBackoff_GrowsUpToTheMaximumDelay(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.ReconnectionDelay = 100
+	config.ReconnectionDelayMultiplier = 2
+	config.MaxReconnectionDelay = 1000
+
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, maximum := range expected {
+		attempt := i + 1
+		maximum = maximum * time.Millisecond
+		for j := 0; j < 100; j++ {
+			delay := config.ReconnectionBackoff(attempt)
+			if delay > maximum || delay < maximum/2 {
+				t.Fatalf("Expected the delay after attempt %v to be between %v and %v got %v", attempt, maximum/2, maximum, delay)
+			}
+		}
+	}
+}
+
+func TestReconnectionBackoff_WithoutMultiplier(t *testing.T) {
+	config := &goes.Configuration{ReconnectionDelay: 100}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := config.ReconnectionBackoff(attempt)
+		if delay > 100*time.Millisecond || delay < 50*time.Millisecond {
+			t.Fatalf("Expected the delay after attempt %v to be between %v and %v got %v", attempt, 50*time.Millisecond, 100*time.Millisecond, delay)
+		}
+	}
+}
+
+func newTestWriteEventsCompleted(t *testing.T) []byte {
+	bytes, err := proto.Marshal(&protobuf.WriteEventsCompleted{
+		Result:           protobuf.OperationResult_Success.Enum(),
+		FirstEventNumber: proto.Int32(0),
+		LastEventNumber:  proto.Int32(0),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure marshalling write events completed: %s", err.Error())
+	}
+	return bytes
+}
+
+func newTestNotHandled(t *testing.T, reason protobuf.NotHandled_NotHandledReason, master *protobuf.NotHandled_MasterInfo) []byte {
+	message := &protobuf.NotHandled{Reason: reason.Enum()}
+	if master != nil {
+		info, err := proto.Marshal(master)
+		if err != nil {
+			t.Fatalf("Unexpected failure marshalling master info: %s", err.Error())
+		}
+		message.AdditionalInfo = info
+	}
+	bytes, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("Unexpected failure marshalling not handled: %s", err.Error())
+	}
+	return bytes
+}
+
+func TestPerformOperation_WhenNotHandledByTheMaster(t *testing.T) {
+	master, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	defer master.Close()
+	go func() {
+		socket, err := master.Accept()
+		if err != nil {
+			return
+		}
+		write, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       writeEventsCompletedCommand,
+			CorrelationID: write.CorrelationID,
+			Data:          newTestWriteEventsCompleted(t),
+		}))
+	}()
+	masterPort := master.Addr().(*net.TCPAddr).Port
+
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		write, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       notHandledCommand,
+			CorrelationID: write.CorrelationID,
+			Data: newTestNotHandled(t, protobuf.NotHandled_NotMaster, &protobuf.NotHandled_MasterInfo{
+				ExternalTcpAddress:  proto.String("127.0.0.1"),
+				ExternalTcpPort:     proto.Int32(int32(masterPort)),
+				ExternalHttpAddress: proto.String("127.0.0.1"),
+				ExternalHttpPort:    proto.Int32(2113),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err = conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if conn.Config.Port != masterPort {
+		t.Fatalf("Expected %v got %v", masterPort, conn.Config.Port)
+	}
+}
+
+func TestPerformOperation_WhenAlwaysRedirected(t *testing.T) {
+	reads := make(chan struct{}, 10)
+	config := goes.NewConfiguration()
+	config.MaxOperationRetries = 3
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		// the node claims that it is not the master and keeps pointing at itself
+		port := socket.LocalAddr().(*net.TCPAddr).Port
+		for {
+			read, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			reads <- struct{}{}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       notHandledCommand,
+				CorrelationID: read.CorrelationID,
+				Data: newTestNotHandled(t, protobuf.NotHandled_NotMaster, &protobuf.NotHandled_MasterInfo{
+					ExternalTcpAddress:  proto.String("127.0.0.1"),
+					ExternalTcpPort:     proto.Int32(int32(port)),
+					ExternalHttpAddress: proto.String("127.0.0.1"),
+					ExternalHttpPort:    proto.Int32(2113),
+				}),
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := conn.ReadStreamEventsForward("testStream", 0, 10, false)
+	exhausted, ok := err.(*goes.ErrRetriesExhausted)
+	if !ok {
+		t.Fatalf("Expected %T got %v", exhausted, err)
+	}
+	if exhausted.Retries != config.MaxOperationRetries {
+		t.Fatalf("Expected %v got %v", config.MaxOperationRetries, exhausted.Retries)
+	}
+	if !errors.Is(err, goes.ErrNoMaster) {
+		t.Fatalf("Expected %v to be %v", err, goes.ErrNoMaster)
+	}
+	if len(reads) != config.MaxOperationRetries+1 {
+		t.Fatalf("Expected %v got %v", config.MaxOperationRetries+1, len(reads))
+	}
+}
+
+func TestPerformOperation_WhenTheMasterIsUnreachable(t *testing.T) {
+	unreachable, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	unreachablePort := unreachable.Addr().(*net.TCPAddr).Port
+	unreachable.Close()
+
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		read, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       notHandledCommand,
+			CorrelationID: read.CorrelationID,
+			Data: newTestNotHandled(t, protobuf.NotHandled_NotMaster, &protobuf.NotHandled_MasterInfo{
+				ExternalTcpAddress:  proto.String("127.0.0.1"),
+				ExternalTcpPort:     proto.Int32(int32(unreachablePort)),
+				ExternalHttpAddress: proto.String("127.0.0.1"),
+				ExternalHttpPort:    proto.Int32(2113),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err = conn.ReadStreamEventsForward("testStream", 0, 10, false)
+	if err != goes.ErrNoMaster {
+		t.Fatalf("Expected %v got %v", goes.ErrNoMaster, err)
+	}
+}
+
+func TestPerformOperation_WhenNodeIsTooBusy(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		for _, reason := range []protobuf.NotHandled_NotHandledReason{protobuf.NotHandled_TooBusy, protobuf.NotHandled_NotReady} {
+			write, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       notHandledCommand,
+				CorrelationID: write.CorrelationID,
+				Data:          newTestNotHandled(t, reason, nil),
+			}))
+		}
+		write, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       writeEventsCompletedCommand,
+			CorrelationID: write.CorrelationID,
+			Data:          newTestWriteEventsCompleted(t),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+// testDiscoverer returns the next member on every discovery and keeps returning the last one
+type testDiscoverer struct {
+	sync.Mutex
+	members     []goes.MemberInfo
+	discoveries int
+}
+
+func (discoverer *testDiscoverer) Discover() (goes.MemberInfo, error) {
+	discoverer.Lock()
+	defer discoverer.Unlock()
+	member := discoverer.members[0]
+	if len(discoverer.members) > 1 {
+		discoverer.members = discoverer.members[1:]
+	}
+	discoverer.discoveries++
+	return member, nil
+}
+
+func (discoverer *testDiscoverer) count() int {
+	discoverer.Lock()
+	defer discoverer.Unlock()
+	return discoverer.discoveries
+}
+
+func TestConnect_ReusesTheDiscoveredNodeWhenReconnecting(t *testing.T) {
+	reconnected := make(chan struct{})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	defer listener.Close()
+	go func() {
+		socket, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		socket.Close()
+		socket, err = listener.Accept()
+		if err != nil {
+			return
+		}
+		defer socket.Close()
+		close(reconnected)
+		readTestPackage(socket)
+	}()
+
+	discoverer := &testDiscoverer{members: []goes.MemberInfo{
+		{ExternalTCPIP: "127.0.0.1", ExternalTCPPort: listener.Addr().(*net.TCPAddr).Port},
+	}}
+	config := goes.NewConfiguration()
+	config.EndpointDiscoverer = discoverer
+	config.ReconnectionDelay = 1
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	if err = conn.Connect(); err != nil {
+		t.Fatalf("Unexpected failure connecting: %s", err.Error())
+	}
+	defer conn.Close()
+
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the connection to be re-established")
+	}
+	if discoverer.count() != 1 {
+		t.Fatalf("Expected %v got %v", 1, discoverer.count())
+	}
+}
+
+func TestConnect_DiscoversAgainWhenTheCachedNodeIsUnavailable(t *testing.T) {
+	unavailable, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	unavailablePort := unavailable.Addr().(*net.TCPAddr).Port
+	unavailable.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	defer listener.Close()
+	go func() {
+		socket, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer socket.Close()
+		readTestPackage(socket)
+	}()
+
+	discoverer := &testDiscoverer{members: []goes.MemberInfo{
+		{ExternalTCPIP: "127.0.0.1", ExternalTCPPort: unavailablePort},
+		{ExternalTCPIP: "127.0.0.1", ExternalTCPPort: listener.Addr().(*net.TCPAddr).Port},
+	}}
+	config := goes.NewConfiguration()
+	config.EndpointDiscoverer = discoverer
+	config.ReconnectionDelay = 1
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	if err = conn.Connect(); err != nil {
+		t.Fatalf("Unexpected failure connecting: %s", err.Error())
+	}
+	defer conn.Close()
+
+	if discoverer.count() != 2 {
+		t.Fatalf("Expected %v got %v", 2, discoverer.count())
+	}
+}
+
+func TestPing(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		pkg, err := readRawTestPackage(socket)
+		for err == nil && pkg.Command != pingCommand {
+			pkg, err = readRawTestPackage(socket)
+		}
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       pongCommand,
+			CorrelationID: pkg.CorrelationID,
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestPingWithContext_WhenNoPongIsReceived(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := conn.PingWithContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestPerformOperation_WaitsWhenMaxInflightIsReached(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.MaxInflight = 1
+	pings := make(chan testPackage, 10)
+	release := make(chan struct{})
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		received := 0
+		for {
+			pkg, err := readRawTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command != pingCommand {
+				continue
+			}
+			pings <- pkg
+			if received++; received == 1 {
+				<-release
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       pongCommand,
+				CorrelationID: pkg.CorrelationID,
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	first := make(chan error, 1)
+	go func() {
+		first <- conn.Ping()
+	}()
+	<-pings
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := conn.PingWithContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v got %v", context.DeadlineExceeded, err)
+	}
+	if len(pings) != 0 {
+		t.Fatalf("Expected the operation to wait for the inflight operation to complete")
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestPerformOperation_WhenOperationTimeoutExpires(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.OperationTimeout = 100
+	timedOut := make(chan struct{})
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		var pings []testPackage
+		for {
+			pkg, err := readRawTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command != pingCommand {
+				continue
+			}
+			pings = append(pings, pkg)
+			if len(pings) == 1 {
+				continue
+			}
+			// respond late to the ping that timed out before responding to the current one
+			<-timedOut
+			for _, ping := range pings {
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       pongCommand,
+					CorrelationID: ping.CorrelationID,
+				}))
+			}
+			pings = pings[:0]
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	if err := conn.Ping(); err != goes.ErrOperationTimeout {
+		t.Fatalf("Expected %v got %v", goes.ErrOperationTimeout, err)
+	}
+	close(timedOut)
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestConnect_WithCustomDialer(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		pkg, err := readRawTestPackage(server)
+		for err == nil && pkg.Command != pingCommand {
+			pkg, err = readRawTestPackage(server)
+		}
+		if err != nil {
+			return
+		}
+		server.Write(encodeTestPackage(testPackage{
+			Command:       pongCommand,
+			CorrelationID: pkg.CorrelationID,
+		}))
+		for err == nil {
+			_, err = readRawTestPackage(server)
+		}
+	}()
+	defer server.Close()
+
+	addresses := make(chan string, 1)
+	config := goes.NewConfiguration()
+	config.Address = "eventstore.internal"
+	config.Port = 1113
+	config.KeepAliveInterval = 0
+	config.Dialer = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		addresses <- address
+		return client, nil
+	}
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer conn.Close()
+	if address := <-addresses; address != "eventstore.internal:1113" {
+		t.Fatalf("Expected %v got %v", "eventstore.internal:1113", address)
+	}
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestConnect_WithIPv6Address(t *testing.T) {
+	listener, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback is not available: %s", err.Error())
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			socket, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				pkg, err := readRawTestPackage(socket)
+				for err == nil {
+					if pkg.Command == pingCommand {
+						socket.Write(encodeTestPackage(testPackage{
+							Command:       pongCommand,
+							CorrelationID: pkg.CorrelationID,
+						}))
+					}
+					pkg, err = readRawTestPackage(socket)
+				}
+			}()
+		}
+	}()
+
+	for _, address := range []string{"::1", "[::1]"} {
+		config := goes.NewConfiguration()
+		config.Address = address
+		config.Port = listener.Addr().(*net.TCPAddr).Port
+		config.MaxReconnects = 1
+		config.KeepAliveInterval = 0
+		conn, err := goes.NewEventStoreConnection(config)
+		if err != nil {
+			t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+		}
+		if err := conn.Connect(); err != nil {
+			t.Fatalf("Unexpected failure connecting to %s: %+v", address, err)
+		}
+		if err := conn.Ping(); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		conn.Close()
+	}
+}
+
+func TestConnect_WithUnreachableAddress(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.Address = "10.255.255.1"
+	config.Port = 1113
+	config.ConnectTimeout = 100
+	// a non-routable address drops the connection request, so the dial blocks until the attempt times out
+	config.Dialer = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	config.MaxReconnects = 2
+	config.ReconnectionDelay = 1
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+
+	start := time.Now()
+	if err := conn.Connect(); err == nil {
+		conn.Close()
+		t.Fatalf("Expected connecting to %s to fail", config.Address)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected every attempt to fail within %vms, connecting took %v", config.ConnectTimeout, elapsed)
+	}
+}
+
+func TestConnect_NotifiesStateChanges(t *testing.T) {
+	changes := make(chan goes.ConnectionState, 10)
+	config := goes.NewConfiguration()
+	config.ReconnectionDelay = 1
+	config.OnStateChange = func(old goes.ConnectionState, new goes.ConnectionState) {
+		changes <- new
+	}
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			socket.Close()
+		},
+		func(socket net.Conn) {
+			readTestPackage(socket)
+		})
+	defer listener.Close()
+
+	expected := []goes.ConnectionState{goes.ConnectionStateConnecting, goes.ConnectionStateConnected, goes.ConnectionStateReconnecting, goes.ConnectionStateConnected}
+	for _, state := range expected {
+		select {
+		case actual := <-changes:
+			if actual != state {
+				t.Fatalf("Expected %v got %v", state, actual)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the state to change to %v", state)
+		}
+	}
+	if conn.State() != goes.ConnectionStateConnected {
+		t.Fatalf("Expected %v got %v", goes.ConnectionStateConnected, conn.State())
+	}
+	conn.Close()
+	if actual := <-changes; actual != goes.ConnectionStateClosed {
+		t.Fatalf("Expected %v got %v", goes.ConnectionStateClosed, actual)
+	}
+}
+
+func TestWaitForConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	defer listener.Close()
+	go func() {
+		socket, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		readTestPackage(socket)
+	}()
+	config := goes.NewConfiguration()
+	config.Address = "127.0.0.1"
+	config.Port = listener.Addr().(*net.TCPAddr).Port
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := conn.WaitForConnection(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v got %v", context.DeadlineExceeded, err)
+	}
+
+	waited := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		waited <- conn.WaitForConnection(ctx)
+	}()
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Unexpected failure connecting: %s", err.Error())
+	}
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatalf("Expected the wait to end once connected")
+	}
+
+	conn.Close()
+	if err := conn.WaitForConnection(context.Background()); err != goes.ErrConnectionClosed {
+		t.Fatalf("Expected %v got %v", goes.ErrConnectionClosed, err)
+	}
+}
+
+func TestConnect_CallsOnConnectedAndOnDisconnected(t *testing.T) {
+	var conn *goes.EventStoreConnection
+	start := make(chan struct{})
+	connected := make(chan goes.ConnectionState, 2)
+	disconnected := make(chan error, 1)
+	config := goes.NewConfiguration()
+	config.ReconnectionDelay = 1
+	config.KeepAliveInterval = 0
+	config.OnConnected = func() {
+		select {
+		case <-start:
+			// the connection can be used from the callback
+			connected <- conn.State()
+		default:
+			connected <- goes.ConnectionStateConnected
+		}
+	}
+	config.OnDisconnected = func(err error) {
+		disconnected <- err
+	}
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			// the identification is read so that closing the socket does not reset the connection
+			readRawTestPackage(socket)
+			<-start
+			socket.Close()
+		},
+		func(socket net.Conn) {
+			readTestPackage(socket)
+		})
+	defer listener.Close()
+	defer conn.Close()
+	close(start)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case state := <-connected:
+			if state != goes.ConnectionStateConnected {
+				t.Fatalf("Expected %v got %v", goes.ConnectionStateConnected, state)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected OnConnected to be called")
+		}
+		if i == 0 {
+			select {
+			case err := <-disconnected:
+				if err != io.EOF {
+					t.Fatalf("Expected %v got %v", io.EOF, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Expected OnDisconnected to be called")
+			}
+		}
+	}
+}
+
+func TestReconnect_AfterTheRetryLimitWasReached(t *testing.T) {
+	var available int32
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.ReconnectionDelay = 1
+	config.Dialer = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		if atomic.LoadInt32(&available) == 0 {
+			return nil, errors.New("node unavailable")
+		}
+		dialer := &net.Dialer{}
+		return dialer.DialContext(ctx, network, address)
+	}
+	atomic.StoreInt32(&available, 1)
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			atomic.StoreInt32(&available, 0)
+			readRawTestPackage(socket)
+			socket.Close()
+		},
+		func(socket net.Conn) {
+			readRawTestPackage(socket)
+			readRawTestPackage(socket)
+		})
+	defer listener.Close()
+	defer conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.State() != goes.ConnectionStateClosed {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v got %v", goes.ConnectionStateClosed, conn.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	connectionID := conn.ConnectionID
+	atomic.StoreInt32(&available, 1)
+
+	if err := conn.Reconnect(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if conn.State() != goes.ConnectionStateConnected {
+		t.Fatalf("Expected %v got %v", goes.ConnectionStateConnected, conn.State())
+	}
+	if conn.ConnectionID == connectionID {
+		t.Fatalf("Expected a new connection id got %v", conn.ConnectionID)
+	}
+}
+
+func TestConnect_OpensAndClosesManyTimes(t *testing.T) {
+	const connections = 50
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			socket, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				for {
+					if _, err := readRawTestPackage(socket); err != nil {
+						socket.Close()
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	config := goes.NewConfiguration()
+	config.Address = "127.0.0.1"
+	config.Port = listener.Addr().(*net.TCPAddr).Port
+	config.MaxReconnects = 1
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < connections; i++ {
+			if err := conn.Connect(); err != nil {
+				done <- err
+				return
+			}
+			conn.Close()
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Expected the connection to be opened and closed %v times", connections)
+	}
+	if conn.State() != goes.ConnectionStateClosed {
+		t.Fatalf("Expected %v got %v", goes.ConnectionStateClosed, conn.State())
+	}
+}
+
+func TestConnect_WithAConnectionThatWasNotSetUpByNewEventStoreConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected failure starting test server: %s", err.Error())
+	}
+	defer listener.Close()
+	go func() {
+		socket, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		for {
+			if _, err := readRawTestPackage(socket); err != nil {
+				socket.Close()
+				return
+			}
+		}
+	}()
+
+	config := goes.NewConfiguration()
+	config.Address = "127.0.0.1"
+	config.Port = listener.Addr().(*net.TCPAddr).Port
+	config.MaxReconnects = 1
+	conn := &goes.EventStoreConnection{Config: config}
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if conn.State() != goes.ConnectionStateConnected {
+		t.Fatalf("Expected %v got %v", goes.ConnectionStateConnected, conn.State())
+	}
+	conn.Close()
+	if conn.State() != goes.ConnectionStateClosed {
+		t.Fatalf("Expected %v got %v", goes.ConnectionStateClosed, conn.State())
+	}
+}
+
+func TestClose_StopsTheReaderWithoutReportingAnError(t *testing.T) {
+	var errs []error
+	var mutex sync.Mutex
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.OnError = func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		errs = append(errs, err)
+	}
+	config.OnDisconnected = func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		errs = append(errs, err)
+	}
+	readUntilClosed := func(socket net.Conn) {
+		for {
+			if _, err := readRawTestPackage(socket); err != nil {
+				return
+			}
+		}
+	}
+	conn, listener := startTestServerWithHandlers(t, config, readUntilClosed, readUntilClosed)
+	defer listener.Close()
+	defer conn.Close()
+
+	conn.Close()
+	// waits for the reader of the closed socket to stop
+	if err := conn.Reconnect(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors got %v", errs)
+	}
+}
+
+func TestPerformOperation_WhenTheConnectionIsLostBeforeTheResponse(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	dropWrite := func(socket net.Conn) {
+		readTestPackage(socket)
+		socket.Close()
+	}
+	conn, listener := startTestServerWithHandlers(t, config, dropWrite, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       writeEventsCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data:          newTestWriteEventsCompleted(t),
+		}))
+		readRawTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{createTestEventData()}); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestPerformOperation_WhenTheConnectionIsLostOnEveryRetry(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.MaxOperationRetries = 1
+	dropWrite := func(socket net.Conn) {
+		readTestPackage(socket)
+		socket.Close()
+	}
+	conn, listener := startTestServerWithHandlers(t, config, dropWrite, dropWrite)
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{createTestEventData()})
+	exhausted, ok := err.(*goes.ErrRetriesExhausted)
+	if !ok {
+		t.Fatalf("Expected %T got %v", exhausted, err)
+	}
+	if exhausted.Retries != 1 || exhausted.Err != goes.ErrConnectionLost {
+		t.Fatalf("Expected %v after %v retries got %v after %v", goes.ErrConnectionLost, 1, exhausted.Err, exhausted.Retries)
+	}
+	if !errors.Is(err, goes.ErrRetryLimitReached) {
+		t.Fatalf("Expected %v to be %v", err, goes.ErrRetryLimitReached)
+	}
+}
+
+// stallingTestConn stops writing to the socket once stalled, as a peer that stopped reading does, until the write deadline expires
+type stallingTestConn struct {
+	net.Conn
+	stalled *int32
+
+	mutex    sync.Mutex
+	deadline time.Time
+}
+
+func (conn *stallingTestConn) SetWriteDeadline(deadline time.Time) error {
+	conn.mutex.Lock()
+	conn.deadline = deadline
+	conn.mutex.Unlock()
+	return conn.Conn.SetWriteDeadline(deadline)
+}
+
+func (conn *stallingTestConn) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(conn.stalled) == 1 {
+		conn.mutex.Lock()
+		deadline := conn.deadline
+		conn.mutex.Unlock()
+		if deadline.IsZero() {
+			select {}
+		}
+		time.Sleep(time.Until(deadline))
+	}
+	return conn.Conn.Write(p)
+}
+
+func TestSendPackage_WhenTheWriteTimeoutExpires(t *testing.T) {
+	var stalled, dials int32
+	disconnected := make(chan error, 1)
+	reconnected := make(chan struct{})
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.WriteTimeout = 100
+	config.OnDisconnected = func(err error) {
+		disconnected <- err
+	}
+	config.Dialer = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		dialer := &net.Dialer{}
+		socket, err := dialer.DialContext(ctx, network, address)
+		if err != nil || atomic.AddInt32(&dials, 1) > 1 {
+			return socket, err
+		}
+		return &stallingTestConn{Conn: socket, stalled: &stalled}, nil
+	}
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			for {
+				if _, err := readRawTestPackage(socket); err != nil {
+					return
+				}
+			}
+		},
+		func(socket net.Conn) {
+			close(reconnected)
+			readTestPackage(socket)
+		})
+	defer listener.Close()
+	defer conn.Close()
+
+	atomic.StoreInt32(&stalled, 1)
+	err := conn.PingWithContext(context.Background())
+	if err != goes.ErrWriteTimeout {
+		t.Fatalf("Expected %v got %v", goes.ErrWriteTimeout, err)
+	}
+	select {
+	case err := <-disconnected:
+		if err != goes.ErrWriteTimeout {
+			t.Fatalf("Expected %v got %v", goes.ErrWriteTimeout, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the connection to be lost after the write timeout")
+	}
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the connection to be re-established after the write timeout")
+	}
+}
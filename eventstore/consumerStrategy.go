@@ -0,0 +1,23 @@
+package goes
+
+// The named consumer strategies that decide how a persistent subscription group distributes events over its consumers
+const (
+	// ConsumerStrategyRoundRobin spreads the events over the consumers in turn, which balances the load but does not preserve ordering
+	ConsumerStrategyRoundRobin = "RoundRobin"
+	// ConsumerStrategyDispatchToSingle sends the events to a single consumer for as long as it has capacity, others only receive
+	// events when it is full
+	ConsumerStrategyDispatchToSingle = "DispatchToSingle"
+	// ConsumerStrategyPinned assigns the events to consumers by a hash of their source stream, so that the events of a
+	// stream are handled in order by the same consumer
+	ConsumerStrategyPinned = "Pinned"
+)
+
+// validateConsumerStrategy rejects strategies the server does not know before a package is sent. An empty strategy leaves
+// the choice to PreferRoundRobin.
+func validateConsumerStrategy(strategy string) error {
+	switch strategy {
+	case "", ConsumerStrategyRoundRobin, ConsumerStrategyDispatchToSingle, ConsumerStrategyPinned:
+		return nil
+	}
+	return &ErrUnknownConsumerStrategy{Strategy: strategy}
+}
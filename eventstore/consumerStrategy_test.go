@@ -0,0 +1,132 @@
+package goes_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+const createPersistentSubscriptionCompletedCommand byte = 0xC9
+
+func TestCreatePersistentSubscription_SendsTheNamedConsumerStrategy(t *testing.T) {
+	received := make(chan *protobuf.CreatePersistentSubscription, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		message := &protobuf.CreatePersistentSubscription{}
+		if err := proto.Unmarshal(pkg.Data, message); err != nil {
+			t.Errorf("Unexpected failure unmarshalling create persistent subscription: %s", err.Error())
+			return
+		}
+		received <- message
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       createPersistentSubscriptionCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.CreatePersistentSubscriptionCompleted{
+				Result: protobuf.CreatePersistentSubscriptionCompleted_Success.Enum(),
+			}),
+		}))
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	settings := goes.NewPersistentSubscriptionSettings()
+	settings.NamedConsumerStrategy = goes.ConsumerStrategyPinned
+	if err := conn.CreatePersistentSubscription("testStream", "testGroup", *settings); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if strategy := (<-received).GetNamedConsumerStrategy(); strategy != goes.ConsumerStrategyPinned {
+		t.Fatalf("Expected %v got %v", goes.ConsumerStrategyPinned, strategy)
+	}
+}
+
+func TestCreatePersistentSubscription_WithUnknownConsumerStrategy(t *testing.T) {
+	received := make(chan testPackage, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		received <- pkg
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	settings := goes.NewPersistentSubscriptionSettings()
+	settings.NamedConsumerStrategy = "Random"
+	err := conn.CreatePersistentSubscription("testStream", "testGroup", *settings)
+	if strategyErr, ok := err.(*goes.ErrUnknownConsumerStrategy); !ok || strategyErr.Strategy != "Random" {
+		t.Fatalf("Expected an unknown consumer strategy error got %+v", err)
+	}
+	err = conn.UpdatePersistentSubscription("testStream", "testGroup", *settings)
+	if _, ok := err.(*goes.ErrUnknownConsumerStrategy); !ok {
+		t.Fatalf("Expected an unknown consumer strategy error got %+v", err)
+	}
+	_, err = goes.CreatePersistentSubscription(conn, "testStream", "testGroup", *settings)
+	if _, ok := err.(*goes.ErrUnknownConsumerStrategy); !ok {
+		t.Fatalf("Expected an unknown consumer strategy error got %+v", err)
+	}
+
+	select {
+	case pkg := <-received:
+		t.Fatalf("Expected no package to be sent got %+v", pkg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPersistentSubscription_WithRoundRobinDistributesEventsOverConsumers(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	groupName := uuid.NewV4().String()
+	settings := goes.NewPersistentSubscriptionSettings()
+	settings.StartFrom = 0
+	settings.NamedConsumerStrategy = goes.ConsumerStrategyRoundRobin
+	if err := conn.CreatePersistentSubscription(streamID, groupName, *settings); err != nil {
+		t.Fatalf("Unexpected failure creating %+v", err)
+	}
+
+	const eventCount = 20
+	var mutex sync.Mutex
+	counts := make([]int, 2)
+	var received sync.WaitGroup
+	received.Add(eventCount)
+	for consumer := range counts {
+		consumer := consumer
+		subscription, err := goes.ConnectToPersistentSubscription(conn, streamID, groupName, func(evnt *protobuf.StreamEventAppeared) {
+			mutex.Lock()
+			counts[consumer]++
+			mutex.Unlock()
+			received.Done()
+		}, func(*protobuf.SubscriptionDropped) {}, 1, true)
+		if err != nil {
+			t.Fatalf("Unexpected failure connecting %+v", err)
+		}
+		defer subscription.Unsubscribe()
+	}
+
+	events := make([]goes.EventData, eventCount)
+	for i := range events {
+		events[i] = createTestEventData()
+	}
+	if _, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, events); err != nil {
+		t.Fatalf("Unexpected failure writing %+v", err)
+	}
+	received.Wait()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if counts[0] == 0 || counts[1] == 0 {
+		t.Fatalf("Expected both consumers to receive events got %v", counts)
+	}
+}
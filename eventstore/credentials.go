@@ -0,0 +1,45 @@
+package goes
+
+// UserCredentials are the login and password an operation is authenticated with
+type UserCredentials struct {
+	Login    string
+	Password string
+}
+
+// OperationOption changes how a single operation is performed
+type OperationOption func(*operationOptions)
+
+type operationOptions struct {
+	credentials   *UserCredentials
+	onDropped     func(SubscriptionDropReason, error)
+	requireMaster *bool
+	maxBytes      int
+}
+
+// WithCredentials authenticates the operation with the credentials instead of the Login and Password of the configuration,
+// e.g. when a connection is shared by tenants with different access. The configured credentials are used when credentials is nil.
+func WithCredentials(credentials *UserCredentials) OperationOption {
+	return func(options *operationOptions) {
+		options.credentials = credentials
+	}
+}
+
+// credentials returns the credentials to authenticate an operation with
+func (connection *EventStoreConnection) credentials(options []OperationOption) UserCredentials {
+	return resolveCredentials(connection.Config, options)
+}
+
+// resolveCredentials returns the credentials set by the options, or the Login and Password of the configuration
+func resolveCredentials(config *Configuration, options []OperationOption) UserCredentials {
+	resolved := operationOptions{}
+	for _, option := range options {
+		option(&resolved)
+	}
+	if resolved.credentials != nil {
+		return *resolved.credentials
+	}
+	return UserCredentials{
+		Login:    config.Login,
+		Password: config.Password,
+	}
+}
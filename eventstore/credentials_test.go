@@ -0,0 +1,112 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/satori/go.uuid"
+)
+
+func TestWriteEvents_WithCredentials(t *testing.T) {
+	credentials := make(chan goes.UserCredentials, 2)
+	config := goes.NewConfiguration()
+	config.Login = "admin"
+	config.Password = "changeit"
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		for {
+			write, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			credentials <- goes.UserCredentials{Login: write.Login, Password: write.Password}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       writeEventsCompletedCommand,
+				CorrelationID: write.CorrelationID,
+				Data:          newTestWriteEventsCompleted(t),
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	events := []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}}
+	tenant := &goes.UserCredentials{Login: "tenant", Password: "secret"}
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, events, goes.WithCredentials(tenant)); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if actual := <-credentials; actual != *tenant {
+		t.Fatalf("Expected %v got %v", *tenant, actual)
+	}
+
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, events); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	expected := goes.UserCredentials{Login: "admin", Password: "changeit"}
+	if actual := <-credentials; actual != expected {
+		t.Fatalf("Expected %v got %v", expected, actual)
+	}
+}
+
+func TestSubscribeToStream_WithCredentials(t *testing.T) {
+	credentials := make(chan goes.UserCredentials, 2)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		subscribe, err := confirmTestSubscription(t, socket)
+		if err != nil {
+			return
+		}
+		credentials <- goes.UserCredentials{Login: subscribe.Login, Password: subscribe.Password}
+		unsubscribe, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		credentials <- goes.UserCredentials{Login: unsubscribe.Login, Password: unsubscribe.Password}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionDroppedCommand,
+			CorrelationID: unsubscribe.CorrelationID,
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	tenant := &goes.UserCredentials{Login: "tenant", Password: "secret"}
+	subscription, err := conn.SubscribeToStream("testStream", false, func(goes.ResolvedEvent) {}, goes.WithCredentials(tenant))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if err := subscription.Unsubscribe(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if actual := <-credentials; actual != *tenant {
+			t.Fatalf("Expected %v got %v", *tenant, actual)
+		}
+	}
+}
+
+func TestWriteEvents_WithWrongCredentials(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		write, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       notAuthenticatedCommand,
+			CorrelationID: write.CorrelationID,
+			Data:          []byte("Not Authenticated"),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	events := []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}}
+	wrong := &goes.UserCredentials{Login: "admin", Password: "wrong"}
+	start := time.Now()
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, events, goes.WithCredentials(wrong)); err != goes.ErrNotAuthenticated {
+		t.Fatalf("Expected %v got %v", goes.ErrNotAuthenticated, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected the write to fail promptly, it took %v", elapsed)
+	}
+}
@@ -0,0 +1,58 @@
+package goes
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// DeleteStream deletes the stream provided that it is at the expected version. A soft deleted stream can be written to again,
+// which recreates it, while reading from a hard deleted stream returns ErrStreamDeleted from then on.
+func (connection *EventStoreConnection) DeleteStream(stream string, expectedVersion int64, hardDelete bool, options ...OperationOption) error {
+	return connection.DeleteStreamWithContext(context.Background(), stream, expectedVersion, hardDelete, options...)
+}
+
+// DeleteStreamWithContext is like DeleteStream but gives up when ctx is cancelled
+func (connection *EventStoreConnection) DeleteStreamWithContext(ctx context.Context, stream string, expectedVersion int64, hardDelete bool, options ...OperationOption) (err error) {
+	ctx, span := connection.startSpan(ctx, deleteStream, stream)
+	defer func() {
+		endSpan(span, err)
+	}()
+	credentials := connection.credentials(options)
+	version, err := int32Argument("expectedVersion", expectedVersion)
+	if err != nil {
+		return err
+	}
+	deleteStreamData := &protobuf.DeleteStream{
+		EventStreamId:   proto.String(stream),
+		ExpectedVersion: proto.Int32(version),
+		RequireMaster:   proto.Bool(connection.requireMaster(options)),
+		HardDelete:      proto.Bool(hardDelete),
+	}
+	data, err := proto.Marshal(deleteStreamData)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return err
+	}
+
+	pkg, err := newPackage(deleteStream, data, uuid.NewV4().Bytes(), credentials.Login, credentials.Password)
+	if err != nil {
+		connection.logger().Errorf("failed to create new delete stream package")
+		return err
+	}
+
+	message := &protobuf.DeleteStreamCompleted{}
+	_, err = performRetriedOperation(ctx, connection, pkg, deleteStreamCompleted, func(result TCPPackage) error {
+		if err := proto.Unmarshal(result.Data, message); err != nil {
+			connection.logger().Errorf("unmarshaling error: %s", err)
+			return err
+		}
+		return operationResultError(message.GetResult())
+	})
+	if message.GetResult() == protobuf.OperationResult_WrongExpectedVersion {
+		return newWrongExpectedVersionError(ctx, connection, stream, expectedVersion, credentials)
+	}
+	return err
+}
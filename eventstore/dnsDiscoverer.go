@@ -0,0 +1,92 @@
+package goes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DNSDiscoverer discovers the nodes in a cluster whose addresses are published under a single host name. The host name is
+// resolved and every resolved address is used as a gossip seed to pick the most appropriate node to connect to.
+type DNSDiscoverer struct {
+	// ClusterDNS is the host name that resolves to the addresses of the nodes in the cluster
+	ClusterDNS string
+	// GossipPort is the http port the nodes gossip on
+	GossipPort int
+	// MaxDiscoverAttempts is the number of resolved addresses that are tried before giving up, each address is tried once when it is 0
+	MaxDiscoverAttempts int
+	// NodePreference is the kind of node that is picked when the cluster has several alive members
+	NodePreference NodePreference
+	// ResolveTTL is the number of milliseconds the resolved addresses are reused for before the host name is resolved again.
+	// Zero resolves the host name on every discovery.
+	ResolveTTL int
+
+	mutex      sync.Mutex
+	addresses  []string
+	resolvedAt time.Time
+}
+
+// NewDNSDiscoverer creates a discoverer for the cluster published under the host name with the default settings
+func NewDNSDiscoverer(clusterDNS string) *DNSDiscoverer {
+	return &DNSDiscoverer{
+		ClusterDNS:          clusterDNS,
+		GossipPort:          defaultGossipPort,
+		MaxDiscoverAttempts: 10,
+		ResolveTTL:          60000,
+	}
+}
+
+// Discover resolves the cluster's host name and gossips with the resolved addresses to pick the node to connect to
+func (discoverer *DNSDiscoverer) Discover() (MemberInfo, error) {
+	return discoverer.DiscoverContext(context.Background())
+}
+
+// DiscoverContext is like Discover but gives up when ctx is cancelled
+func (discoverer *DNSDiscoverer) DiscoverContext(ctx context.Context) (MemberInfo, error) {
+	addresses, err := discoverer.resolve(ctx)
+	if err != nil {
+		return MemberInfo{}, err
+	}
+	gossipSeeds := make([]string, len(addresses))
+	for i, address := range addresses {
+		gossipSeeds[i] = fmt.Sprintf("http://%s", net.JoinHostPort(address, strconv.Itoa(discoverer.GossipPort)))
+	}
+	gossipDiscoverer := &GossipSeedDiscoverer{
+		MaxDiscoverAttempts: discoverer.MaxDiscoverAttempts,
+		GossipSeeds:         gossipSeeds,
+		NodePreference:      discoverer.NodePreference,
+	}
+	member, err := gossipDiscoverer.DiscoverContext(ctx)
+	if err != nil {
+		// the nodes may have moved, resolve the host name again on the next discovery
+		discoverer.mutex.Lock()
+		discoverer.addresses = nil
+		discoverer.mutex.Unlock()
+		return MemberInfo{}, err
+	}
+	return member, nil
+}
+
+// resolve returns the addresses of the cluster's host name, reusing the previous resolution until the ResolveTTL expires
+func (discoverer *DNSDiscoverer) resolve(ctx context.Context) ([]string, error) {
+	discoverer.mutex.Lock()
+	defer discoverer.mutex.Unlock()
+	ttl := time.Duration(discoverer.ResolveTTL) * time.Millisecond
+	if len(discoverer.addresses) > 0 && time.Since(discoverer.resolvedAt) < ttl {
+		return discoverer.addresses, nil
+	}
+	if len(discoverer.ClusterDNS) == 0 {
+		return nil, errors.New("There is no cluster DNS to resolve")
+	}
+	addresses, err := net.DefaultResolver.LookupHost(ctx, discoverer.ClusterDNS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the cluster DNS %s: %s", discoverer.ClusterDNS, err.Error())
+	}
+	discoverer.addresses = addresses
+	discoverer.resolvedAt = time.Now()
+	return addresses, nil
+}
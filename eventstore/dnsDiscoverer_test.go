@@ -0,0 +1,36 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+func TestDNSDiscoverer_GossipsWithTheResolvedAddresses(t *testing.T) {
+	server := startTestGossipServer(`
+		{"state": "Follower", "isAlive": true, "externalTcpIp": "127.0.0.2", "externalTcpPort": 1113},
+		{"state": "Leader", "isAlive": true, "externalTcpIp": "127.0.0.1", "externalTcpPort": 1113}`)
+	defer server.Close()
+
+	discoverer := goes.NewDNSDiscoverer("localhost")
+	discoverer.GossipPort = server.Listener.Addr().(*net.TCPAddr).Port
+	discoverer.MaxDiscoverAttempts = 0
+	discoverer.NodePreference = goes.NodePreferenceFollower
+	for i := 0; i < 2; i++ {
+		member, err := discoverer.Discover()
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		if member.State != "Follower" {
+			t.Fatalf("Expected %v got %v", "Follower", member.State)
+		}
+	}
+}
+
+func TestDNSDiscoverer_WithoutClusterDNS(t *testing.T) {
+	discoverer := goes.NewDNSDiscoverer("")
+	if _, err := discoverer.Discover(); err == nil {
+		t.Fatalf("Expected discovering without a cluster DNS to fail")
+	}
+}
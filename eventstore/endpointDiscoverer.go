@@ -1,6 +1,73 @@
 package goes
 
+import (
+	"context"
+	"sync"
+	"time"
+)
+
 //EndpointDiscoverer func that is used to discover an endpoint given the gossip seeds
 type EndpointDiscoverer interface {
 	Discover() (MemberInfo, error)
 }
+
+// ContextEndpointDiscoverer is an EndpointDiscoverer whose discovery can be cancelled. When the configured discoverer
+// implements it the connection discovers with DiscoverContext, so that discovery gives up when connecting is cancelled or
+// times out. Discoverers that only implement Discover keep working but are not interrupted.
+type ContextEndpointDiscoverer interface {
+	EndpointDiscoverer
+	DiscoverContext(ctx context.Context) (MemberInfo, error)
+}
+
+// discoverContext discovers with the context when the discoverer supports it
+func discoverContext(ctx context.Context, discoverer EndpointDiscoverer) (MemberInfo, error) {
+	if contextDiscoverer, ok := discoverer.(ContextEndpointDiscoverer); ok {
+		return contextDiscoverer.DiscoverContext(ctx)
+	}
+	return discoverer.Discover()
+}
+
+// cachingDiscoverer reuses the last discovered node until the ttl expires or the node is invalidated because it could not be connected to
+type cachingDiscoverer struct {
+	discoverer EndpointDiscoverer
+	ttl        time.Duration
+
+	mutex        sync.Mutex
+	member       MemberInfo
+	discoveredAt time.Time
+	cached       bool
+}
+
+func newCachingDiscoverer(discoverer EndpointDiscoverer, ttl time.Duration) *cachingDiscoverer {
+	return &cachingDiscoverer{
+		discoverer: discoverer,
+		ttl:        ttl,
+	}
+}
+
+func (discoverer *cachingDiscoverer) Discover() (MemberInfo, error) {
+	return discoverer.DiscoverContext(context.Background())
+}
+
+func (discoverer *cachingDiscoverer) DiscoverContext(ctx context.Context) (MemberInfo, error) {
+	discoverer.mutex.Lock()
+	defer discoverer.mutex.Unlock()
+	if discoverer.cached && time.Since(discoverer.discoveredAt) < discoverer.ttl {
+		return discoverer.member, nil
+	}
+	member, err := discoverContext(ctx, discoverer.discoverer)
+	if err != nil {
+		discoverer.cached = false
+		return MemberInfo{}, err
+	}
+	discoverer.member = member
+	discoverer.discoveredAt = time.Now()
+	discoverer.cached = true
+	return member, nil
+}
+
+func (discoverer *cachingDiscoverer) invalidate() {
+	discoverer.mutex.Lock()
+	discoverer.cached = false
+	discoverer.mutex.Unlock()
+}
@@ -0,0 +1,249 @@
+package goes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+var (
+	// ErrAccessDenied is returned when the credentials used for an operation do not grant access to the stream
+	ErrAccessDenied = errors.New("access denied")
+	// ErrStreamDeleted is returned when the stream has been hard deleted, which is permanent
+	ErrStreamDeleted = errors.New("stream deleted")
+	// ErrNoStream is returned when reading from a stream that does not exist. A soft deleted stream does not exist until
+	// events are appended to it again.
+	ErrNoStream = errors.New("no stream")
+	// ErrEventNotFound is returned when the requested event does not exist in the stream
+	ErrEventNotFound = errors.New("event not found")
+	// ErrInvalidTransaction is returned when a write is made as part of a transaction that is not valid
+	ErrInvalidTransaction = errors.New("invalid transaction")
+	// ErrPrepareTimeout is returned when the server timed out preparing the write
+	ErrPrepareTimeout = errors.New("prepare timeout")
+	// ErrCommitTimeout is returned when the server timed out committing the write
+	ErrCommitTimeout = errors.New("commit timeout")
+	// ErrForwardTimeout is returned when the server timed out forwarding the write to the master
+	ErrForwardTimeout = errors.New("forward timeout")
+	// ErrRetryLimitReached is returned when an operation failed on every one of the configured retries
+	ErrRetryLimitReached = errors.New("retry limit reached")
+	// ErrConnectionLost is returned when the connection to the server was lost before an operation completed
+	ErrConnectionLost = errors.New("connection lost")
+	// ErrConnectionClosed is returned when an operation is attempted on a connection that has been closed
+	ErrConnectionClosed = errors.New("connection closed")
+	// ErrServerShutdown is the reason a subscription is dropped when the node it was subscribed on is shutting down
+	ErrServerShutdown = errors.New("server shutdown")
+	// ErrNoMaster is returned when an operation that requires the master was redirected to a master that can not be
+	// reached, or was redirected on every retry without reaching the master
+	ErrNoMaster = errors.New("no master")
+	// ErrNotAuthenticated is returned when the server rejected the credentials used for an operation
+	ErrNotAuthenticated = errors.New("not authenticated")
+	// ErrSubscriptionBufferOverflow is the reason a subscription is dropped when its handler cannot keep up with the events
+	// and the SubscriptionOverflowPolicy is OverflowPolicyDropSubscription
+	ErrSubscriptionBufferOverflow = errors.New("subscription buffer overflow")
+	// ErrOperationTimeout is returned when the server did not respond to an operation within the OperationTimeout
+	ErrOperationTimeout = errors.New("operation timeout")
+	// ErrWriteTimeout is returned when a package could not be written to the socket within the WriteTimeout
+	ErrWriteTimeout = errors.New("write timeout")
+	// ErrNotJSON is returned when decoding the data or the metadata of an event as json that was not written as json
+	ErrNotJSON = errors.New("not json")
+	// ErrProjectionNotFound is returned when managing a projection that does not exist
+	ErrProjectionNotFound = errors.New("projection not found")
+	// ErrUserNotFound is returned when managing a user that does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserAlreadyExists is returned when creating a user with the login name of an existing user
+	ErrUserAlreadyExists = errors.New("user already exists")
+)
+
+// ErrPackageTooLarge is reported when the server sends a package that is larger than the configured MaxPackageSize.
+// The package is discarded.
+type ErrPackageTooLarge struct {
+	PackageLength  int
+	MaxPackageSize int
+}
+
+func (err *ErrPackageTooLarge) Error() string {
+	return fmt.Sprintf("package length %d exceeds the maximum package size of %d bytes", err.PackageLength, err.MaxPackageSize)
+}
+
+// ErrUnknownEventType is returned when deserializing an event whose type has not been registered
+type ErrUnknownEventType struct {
+	EventType string
+}
+
+func (err *ErrUnknownEventType) Error() string {
+	return fmt.Sprintf("no type is registered for event type %s", err.EventType)
+}
+
+// ErrUnknownConsumerStrategy is returned when creating or updating a persistent subscription with a named consumer strategy
+// that is not one of the ConsumerStrategy constants
+type ErrUnknownConsumerStrategy struct {
+	Strategy string
+}
+
+func (err *ErrUnknownConsumerStrategy) Error() string {
+	return fmt.Sprintf("unknown named consumer strategy %s", err.Strategy)
+}
+
+// CurrentVersionUnknown is the CurrentVersion of an ErrWrongExpectedVersion when the version of the stream could not be
+// looked up after the write was rejected, and the version GetStreamVersion returns when the lookup failed
+const CurrentVersionUnknown = math.MinInt64
+
+// ErrWrongExpectedVersion is returned when a write is made against a stream that is not at the expected version.
+// Callers relying on optimistic concurrency can use the CurrentVersion to decide how to retry.
+type ErrWrongExpectedVersion struct {
+	Stream          string
+	ExpectedVersion int64
+	// CurrentVersion is the version of the stream when the write was rejected, ExpectedVersionNoStream when the stream does
+	// not exist or CurrentVersionUnknown when looking it up failed
+	CurrentVersion int64
+	// LookupErr is the error looking up the CurrentVersion failed with, if it is CurrentVersionUnknown
+	LookupErr error
+}
+
+func (err *ErrWrongExpectedVersion) Error() string {
+	if err.CurrentVersion == CurrentVersionUnknown {
+		return fmt.Sprintf("wrong expected version for stream %s: expected %d but the current version could not be looked up: %s", err.Stream, err.ExpectedVersion, err.LookupErr)
+	}
+	return fmt.Sprintf("wrong expected version for stream %s: expected %d but the current version is %d", err.Stream, err.ExpectedVersion, err.CurrentVersion)
+}
+
+// ErrBatchWriteFailed is returned by WriteEventsBatch when one of the chunks could not be written.
+// The chunks before it have been appended to the stream and are described by Written and Result.
+type ErrBatchWriteFailed struct {
+	Stream string
+	// Written is the number of events that were appended before the failing chunk
+	Written int
+	// Result describes the chunks that were appended or is nil when the first chunk failed
+	Result *WriteResult
+	// Err is the error the failing chunk was rejected with
+	Err error
+}
+
+func (err *ErrBatchWriteFailed) Error() string {
+	return fmt.Sprintf("batch write to stream %s failed after %d events: %s", err.Stream, err.Written, err.Err)
+}
+
+// Unwrap returns the error the failing chunk was rejected with
+func (err *ErrBatchWriteFailed) Unwrap() error {
+	return err.Err
+}
+
+// ErrRetriesExhausted is returned when an operation failed every time it was sent, after being sent again MaxOperationRetries
+// times. It is ErrRetryLimitReached for errors.Is and unwraps to the error of the last attempt.
+type ErrRetriesExhausted struct {
+	// Retries is the number of times the operation was sent again after the first attempt
+	Retries int
+	// Err is the error the last attempt failed with
+	Err error
+}
+
+func (err *ErrRetriesExhausted) Error() string {
+	return fmt.Sprintf("%s after %d retries: %s", ErrRetryLimitReached, err.Retries, err.Err)
+}
+
+// Is reports whether the target is ErrRetryLimitReached
+func (err *ErrRetriesExhausted) Is(target error) bool {
+	return target == ErrRetryLimitReached
+}
+
+// Unwrap returns the error the last attempt failed with
+func (err *ErrRetriesExhausted) Unwrap() error {
+	return err.Err
+}
+
+// ErrResubscribesExhausted is the error a subscription is dropped with when it was dropped for a transient reason every
+// time it subscribed again, after MaxResubscribes attempts. It unwraps to the error of the last drop.
+type ErrResubscribesExhausted struct {
+	// Resubscribes is the number of times the subscription subscribed again
+	Resubscribes int
+	// Err is the error the subscription was last dropped with
+	Err error
+}
+
+func (err *ErrResubscribesExhausted) Error() string {
+	return fmt.Sprintf("subscription dropped after %d resubscribes: %s", err.Resubscribes, err.Err)
+}
+
+// Unwrap returns the error the subscription was last dropped with
+func (err *ErrResubscribesExhausted) Unwrap() error {
+	return err.Err
+}
+
+// ErrInvalidConfiguration is returned by NewEventStoreConnection when a field of the configuration is out of range
+type ErrInvalidConfiguration struct {
+	Field  string
+	Value  interface{}
+	Reason string
+}
+
+func (err *ErrInvalidConfiguration) Error() string {
+	return fmt.Sprintf("invalid configuration: %s (%v) %s", err.Field, err.Value, err.Reason)
+}
+
+// ErrInvalidArgument is returned when an argument of an operation is out of the range the server accepts, e.g. an
+// event number or an expected version that does not fit in the 32 bits of the protocol
+type ErrInvalidArgument struct {
+	Argument string
+	Value    interface{}
+	Reason   string
+}
+
+func (err *ErrInvalidArgument) Error() string {
+	return fmt.Sprintf("invalid argument: %s (%v) %s", err.Argument, err.Value, err.Reason)
+}
+
+// int32Argument narrows an argument to the 32 bits of the protocol, rather than letting it wrap around to another event
+// number or version
+func int32Argument(argument string, value int64) (int32, error) {
+	if value < math.MinInt32 || value > math.MaxInt32 {
+		return 0, &ErrInvalidArgument{Argument: argument, Value: value, Reason: "does not fit in 32 bits"}
+	}
+	return int32(value), nil
+}
+
+// newWrongExpectedVersionError looks up the current version of the stream on the master as the write completion does not carry it
+func newWrongExpectedVersionError(ctx context.Context, conn *EventStoreConnection, stream string, expectedVersion int64, credentials UserCredentials) error {
+	currentVersion, err := streamVersion(ctx, conn, stream, true, credentials)
+	if err == ErrNoStream {
+		err = nil
+	}
+	return &ErrWrongExpectedVersion{
+		Stream:          stream,
+		ExpectedVersion: expectedVersion,
+		CurrentVersion:  currentVersion,
+		LookupErr:       err,
+	}
+}
+
+// operationResultError maps an unsuccessful operation result onto the matching error. A nil error is returned for a successful result.
+// WrongExpectedVersion is not mapped as its ErrWrongExpectedVersion describes the stream, which the caller looks up.
+func operationResultError(result protobuf.OperationResult) error {
+	switch result {
+	case protobuf.OperationResult_Success:
+		return nil
+	case protobuf.OperationResult_PrepareTimeout:
+		return ErrPrepareTimeout
+	case protobuf.OperationResult_CommitTimeout:
+		return ErrCommitTimeout
+	case protobuf.OperationResult_ForwardTimeout:
+		return ErrForwardTimeout
+	case protobuf.OperationResult_StreamDeleted:
+		return ErrStreamDeleted
+	case protobuf.OperationResult_InvalidTransaction:
+		return ErrInvalidTransaction
+	case protobuf.OperationResult_AccessDenied:
+		return ErrAccessDenied
+	}
+	return errors.New(result.String())
+}
+
+// IsRetryable reports whether an operation that failed with the error may succeed if it is sent again. The server timing
+// out on a write, with ErrPrepareTimeout, ErrCommitTimeout or ErrForwardTimeout, is retryable and such writes are sent
+// again up to MaxOperationRetries times. Any other result of an operation, e.g. ErrAccessDenied or ErrStreamDeleted, is
+// final.
+func IsRetryable(err error) bool {
+	return err == ErrPrepareTimeout || err == ErrCommitTimeout || err == ErrForwardTimeout
+}
@@ -1,6 +1,11 @@
 package goes
 
 import (
+	"encoding/json"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
 	"github.com/satori/go.uuid"
 )
 
@@ -9,6 +14,199 @@ type Event struct {
 	EventID   uuid.UUID
 	EventType string
 	IsJSON    bool
-	Data      []byte
-	Metadata  []byte
+	// IsJSONMetadata marks the metadata as json, independently of the data
+	IsJSONMetadata bool
+	Data           []byte
+	Metadata       []byte
+}
+
+// EventData describes an event to be written to a stream
+type EventData struct {
+	// EventID identifies the event. The server ignores an event whose id it has already written to the stream at the
+	// expected version, so reusing the ids when retrying a write makes the retry idempotent.
+	EventID   uuid.UUID
+	EventType string
+	// IsJSON marks the data as json, which lets projections and the server's json handling read it. The data is
+	// stored as binary otherwise.
+	IsJSON bool
+	// IsJSONMetadata marks the metadata as json, independently of the data
+	IsJSONMetadata bool
+	Data           []byte
+	Metadata       []byte
+}
+
+// contentType returns the content type flag of the protocol, 1 for json and 0 for binary
+func contentType(isJSON bool) int32 {
+	if isJSON {
+		return 1
+	}
+	return 0
+}
+
+func marshalEventData(evnts []EventData) []*protobuf.NewEvent {
+	var events []*protobuf.NewEvent
+	for _, evnt := range evnts {
+		events = append(events,
+			&protobuf.NewEvent{
+				EventId:             EncodeNetUUID(evnt.EventID.Bytes()),
+				EventType:           proto.String(evnt.EventType),
+				DataContentType:     proto.Int32(contentType(evnt.IsJSON)),
+				MetadataContentType: proto.Int32(contentType(evnt.IsJSONMetadata)),
+				Data:                evnt.Data,
+				Metadata:            evnt.Metadata,
+			},
+		)
+	}
+	return events
+}
+
+// RecordedEvent is an event that has been read from a stream
+type RecordedEvent struct {
+	StreamID    string
+	EventNumber int64
+	EventID     uuid.UUID
+	EventType   string
+	// IsJSON reports whether the data was written as json
+	IsJSON bool
+	// IsJSONMetadata reports whether the metadata was written as json
+	IsJSONMetadata bool
+	Data           []byte
+	Metadata       []byte
+	Created        time.Time
+}
+
+// UnmarshalJSONData unmarshals the json data of the event into the value pointed to by v. It returns ErrNotJSON when
+// the data was not written as json.
+func (evnt *RecordedEvent) UnmarshalJSONData(v interface{}) error {
+	if !evnt.IsJSON {
+		return ErrNotJSON
+	}
+	return json.Unmarshal(evnt.Data, v)
+}
+
+// UnmarshalJSONMetadata unmarshals the json metadata of the event into the value pointed to by v. It returns ErrNotJSON
+// when the metadata was not written as json.
+func (evnt *RecordedEvent) UnmarshalJSONMetadata(v interface{}) error {
+	if !evnt.IsJSONMetadata {
+		return ErrNotJSON
+	}
+	return json.Unmarshal(evnt.Metadata, v)
+}
+
+// CreatedTime returns when the event was written, in UTC. It is the zero time when the server did not tell.
+func (evnt *RecordedEvent) CreatedTime() time.Time {
+	if evnt.Created.IsZero() {
+		return evnt.Created
+	}
+	return evnt.Created.UTC()
+}
+
+// ResolvedEvent is an event as it was read from a stream or received by a subscription. When links are resolved and the
+// event is a link, Event is the event the link points to and Link is the link itself, otherwise Link is nil. Event is nil
+// when the event a link points to has been deleted.
+type ResolvedEvent struct {
+	Event *RecordedEvent
+	Link  *RecordedEvent
+	// Position is the position of the original event in the transaction log. It is set for events received by a
+	// subscription or read from all the events, and left zero for events read from a stream.
+	Position Position
+}
+
+// OriginalEvent returns the event as it is stored in the stream it was read from, which is the link for a resolved link.
+// Consumers of $all and of projected streams such as $ce- categories checkpoint on the original event.
+func (evnt ResolvedEvent) OriginalEvent() *RecordedEvent {
+	if evnt.Link != nil {
+		return evnt.Link
+	}
+	return evnt.Event
+}
+
+// OriginalStreamID returns the stream the original event is stored in
+func (evnt ResolvedEvent) OriginalStreamID() string {
+	return evnt.OriginalEvent().StreamID
+}
+
+// OriginalEventNumber returns the number of the original event in the stream it was read from
+func (evnt ResolvedEvent) OriginalEventNumber() int64 {
+	return evnt.OriginalEvent().EventNumber
+}
+
+// IsResolved reports whether the event is a link whose event was resolved
+func (evnt ResolvedEvent) IsResolved() bool {
+	return evnt.Link != nil && evnt.Event != nil
+}
+
+// Position is a position in the transaction log of all the events in the store
+type Position struct {
+	CommitPosition  int64
+	PreparePosition int64
+}
+
+// Less reports whether the position comes before the other position in the transaction log
+func (position Position) Less(other Position) bool {
+	if position.CommitPosition != other.CommitPosition {
+		return position.CommitPosition < other.CommitPosition
+	}
+	return position.PreparePosition < other.PreparePosition
+}
+
+// Equal reports whether both positions are the same position in the transaction log
+func (position Position) Equal(other Position) bool {
+	return position == other
+}
+
+// newResolvedEvent creates the event read from a stream, which has no position
+func newResolvedEvent(evnt *protobuf.EventRecord, link *protobuf.EventRecord) ResolvedEvent {
+	resolved := ResolvedEvent{}
+	if evnt != nil {
+		record := newRecordedEvent(evnt)
+		resolved.Event = &record
+	}
+	if link != nil {
+		record := newRecordedEvent(link)
+		resolved.Link = &record
+	}
+	return resolved
+}
+
+// newPositionedEvent creates the event received by a subscription or read from all the events
+func newPositionedEvent(resolved *protobuf.ResolvedEvent) ResolvedEvent {
+	evnt := newResolvedEvent(resolved.GetEvent(), resolved.GetLink())
+	evnt.Position = Position{
+		CommitPosition:  resolved.GetCommitPosition(),
+		PreparePosition: resolved.GetPreparePosition(),
+	}
+	return evnt
+}
+
+func newRecordedEvent(record *protobuf.EventRecord) RecordedEvent {
+	eventID, _ := uuid.FromBytes(DecodeNetUUID(record.GetEventId()))
+	evnt := RecordedEvent{
+		StreamID:       record.GetEventStreamId(),
+		EventNumber:    int64(record.GetEventNumber()),
+		EventID:        eventID,
+		EventType:      record.GetEventType(),
+		IsJSON:         record.GetDataContentType() == 1,
+		IsJSONMetadata: record.GetMetadataContentType() == 1,
+		Data:           record.GetData(),
+		Metadata:       record.GetMetadata(),
+	}
+	if record.CreatedEpoch != nil {
+		evnt.Created = time.Unix(0, record.GetCreatedEpoch()*int64(time.Millisecond))
+	} else if record.Created != nil {
+		evnt.Created = ticksToTime(record.GetCreated())
+	}
+	return evnt
+}
+
+const (
+	// unixEpochTicks is the number of .NET ticks, 100 nanoseconds each, from 0001-01-01 to 1970-01-01
+	unixEpochTicks = 621355968000000000
+	// ticksMask clears the bits a serialized .NET DateTime keeps its kind in
+	ticksMask = 0x3FFFFFFFFFFFFFFF
+)
+
+// ticksToTime converts the .NET ticks older servers report the creation time of an event in
+func ticksToTime(ticks int64) time.Time {
+	return time.Unix(0, ((ticks&ticksMask)-unixEpochTicks)*100)
 }
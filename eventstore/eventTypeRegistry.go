@@ -0,0 +1,67 @@
+package goes
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/satori/go.uuid"
+)
+
+// EventTypeRegistry maps event types to the Go types their json data is deserialized into
+type EventTypeRegistry struct {
+	mutex sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// NewEventTypeRegistry creates an empty registry
+func NewEventTypeRegistry() *EventTypeRegistry {
+	return &EventTypeRegistry{
+		types: make(map[string]reflect.Type),
+	}
+}
+
+// Register maps the event type to the Go type. A pointer type is registered as the type it points to.
+func (registry *EventTypeRegistry) Register(eventType string, typ reflect.Type) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.types[eventType] = typ
+}
+
+// Deserialize unmarshals the data of the event into a new value of the type registered for its event type and returns
+// a pointer to that value
+func (registry *EventTypeRegistry) Deserialize(evnt RecordedEvent) (interface{}, error) {
+	registry.mutex.RLock()
+	typ, ok := registry.types[evnt.EventType]
+	registry.mutex.RUnlock()
+	if !ok {
+		return nil, &ErrUnknownEventType{EventType: evnt.EventType}
+	}
+	v := reflect.New(typ).Interface()
+	if err := UnmarshalEvent(evnt, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// NewJSONEventData creates an event with a new id whose data is v marshalled as json
+func NewJSONEventData(eventType string, v interface{}) (EventData, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return EventData{}, err
+	}
+	return EventData{
+		EventID:   uuid.NewV4(),
+		EventType: eventType,
+		IsJSON:    true,
+		Data:      data,
+	}, nil
+}
+
+// UnmarshalEvent unmarshals the json data of the event into the value pointed to by v
+func UnmarshalEvent(evnt RecordedEvent, v interface{}) error {
+	return json.Unmarshal(evnt.Data, v)
+}
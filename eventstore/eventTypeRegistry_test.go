@@ -0,0 +1,58 @@
+package goes_test
+
+import (
+	"reflect"
+	"testing"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+type orderPlaced struct {
+	OrderID string
+	Amount  int
+}
+
+func TestEventTypeRegistry_Deserialize(t *testing.T) {
+	registry := goes.NewEventTypeRegistry()
+	registry.Register("OrderPlaced", reflect.TypeOf(orderPlaced{}))
+
+	expected := orderPlaced{OrderID: "order-1", Amount: 42}
+	evnt, err := goes.NewJSONEventData("OrderPlaced", expected)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if !evnt.IsJSON || evnt.EventType != "OrderPlaced" {
+		t.Fatalf("Expected a json OrderPlaced event got %+v", evnt)
+	}
+
+	result, err := registry.Deserialize(goes.RecordedEvent{EventType: evnt.EventType, Data: evnt.Data})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	order, ok := result.(*orderPlaced)
+	if !ok {
+		t.Fatalf("Expected %T got %T", &expected, result)
+	}
+	if *order != expected {
+		t.Fatalf("Expected %+v got %+v", expected, *order)
+	}
+}
+
+func TestEventTypeRegistry_DeserializeWithUnknownEventType(t *testing.T) {
+	registry := goes.NewEventTypeRegistry()
+	_, err := registry.Deserialize(goes.RecordedEvent{EventType: "OrderShipped", Data: []byte("{}")})
+	if _, ok := err.(*goes.ErrUnknownEventType); !ok {
+		t.Fatalf("Expected %T got %v", &goes.ErrUnknownEventType{}, err)
+	}
+}
+
+func TestUnmarshalEvent(t *testing.T) {
+	var order orderPlaced
+	err := goes.UnmarshalEvent(goes.RecordedEvent{Data: []byte(`{"OrderID":"order-1","Amount":42}`)}, &order)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if order.OrderID != "order-1" || order.Amount != 42 {
+		t.Fatalf("Expected %v got %+v", "order-1 for 42", order)
+	}
+}
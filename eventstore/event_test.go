@@ -0,0 +1,231 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+func TestPosition_Less(t *testing.T) {
+	for _, test := range []struct {
+		position goes.Position
+		other    goes.Position
+		expected bool
+	}{
+		{goes.Position{CommitPosition: 100, PreparePosition: 100}, goes.Position{CommitPosition: 200, PreparePosition: 200}, true},
+		{goes.Position{CommitPosition: 200, PreparePosition: 100}, goes.Position{CommitPosition: 200, PreparePosition: 150}, true},
+		{goes.Position{CommitPosition: 200, PreparePosition: 200}, goes.Position{CommitPosition: 200, PreparePosition: 200}, false},
+		{goes.Position{CommitPosition: 300, PreparePosition: 100}, goes.Position{CommitPosition: 200, PreparePosition: 200}, false},
+	} {
+		if actual := test.position.Less(test.other); actual != test.expected {
+			t.Fatalf("Expected %v < %v to be %v got %v", test.position, test.other, test.expected, actual)
+		}
+	}
+}
+
+func TestPosition_Equal(t *testing.T) {
+	position := goes.Position{CommitPosition: 200, PreparePosition: 100}
+	if !position.Equal(goes.Position{CommitPosition: 200, PreparePosition: 100}) {
+		t.Fatalf("Expected %v to equal itself", position)
+	}
+	if position.Equal(goes.Position{CommitPosition: 200, PreparePosition: 200}) {
+		t.Fatalf("Expected %v to differ from %v", position, goes.Position{CommitPosition: 200, PreparePosition: 200})
+	}
+}
+
+func TestResolvedEvent_OriginalEvent(t *testing.T) {
+	evnt := &goes.RecordedEvent{StreamID: "order-1", EventNumber: 3}
+	link := &goes.RecordedEvent{StreamID: "$ce-order", EventNumber: 0}
+
+	resolved := goes.ResolvedEvent{Event: evnt, Link: link}
+	if !resolved.IsResolved() || resolved.OriginalEvent() != link {
+		t.Fatalf("Expected the link to be the original event got %+v", resolved.OriginalEvent())
+	}
+	if resolved.OriginalStreamID() != "$ce-order" || resolved.OriginalEventNumber() != 0 {
+		t.Fatalf("Expected %v got %v@%v", "$ce-order@0", resolved.OriginalStreamID(), resolved.OriginalEventNumber())
+	}
+
+	unresolved := goes.ResolvedEvent{Event: evnt}
+	if unresolved.IsResolved() || unresolved.OriginalEvent() != evnt {
+		t.Fatalf("Expected the event to be the original event got %+v", unresolved.OriginalEvent())
+	}
+}
+
+func TestRecordedEvent_UnmarshalJSON(t *testing.T) {
+	evnt := &goes.RecordedEvent{IsJSON: true, Data: []byte(`{"order":1}`), Metadata: []byte{0x01}}
+
+	var data struct{ Order int }
+	if err := evnt.UnmarshalJSONData(&data); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if data.Order != 1 {
+		t.Fatalf("Expected %v got %v", 1, data.Order)
+	}
+	var metadata map[string]interface{}
+	if err := evnt.UnmarshalJSONMetadata(&metadata); err != goes.ErrNotJSON {
+		t.Fatalf("Expected %v got %v", goes.ErrNotJSON, err)
+	}
+	binary := &goes.RecordedEvent{Data: []byte(`{"order":1}`)}
+	if err := binary.UnmarshalJSONData(&data); err != goes.ErrNotJSON {
+		t.Fatalf("Expected %v got %v", goes.ErrNotJSON, err)
+	}
+}
+
+func TestRecordedEvent_CreatedTime(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	withEpoch := newTestEventRecord("orders", 0)
+	withEpoch.CreatedEpoch = proto.Int64(created.UnixNano() / int64(time.Millisecond))
+	// older servers only report the .NET ticks of a serialized DateTime, whose kind is kept in the top bits
+	withTicks := newTestEventRecord("orders", 1)
+	withTicks.Created = proto.Int64((created.UnixNano()/100 + 621355968000000000) | 1<<62)
+
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		read, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsForwardCompletedCommand,
+			CorrelationID: read.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+				Events: []*protobuf.ResolvedIndexedEvent{
+					{Event: withEpoch}, {Event: withTicks}, {Event: newTestEventRecord("orders", 2)},
+				},
+				Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+				NextEventNumber:    proto.Int32(3),
+				LastEventNumber:    proto.Int32(2),
+				IsEndOfStream:      proto.Bool(true),
+				LastCommitPosition: proto.Int64(0),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	slice, err := conn.ReadStreamEventsForward("orders", 0, 10, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 3 {
+		t.Fatalf("Expected %v got %v", 3, len(slice.Events))
+	}
+	for _, evnt := range slice.Events[:2] {
+		if !evnt.Event.CreatedTime().Equal(created) {
+			t.Fatalf("Expected %v got %v", created, evnt.Event.CreatedTime())
+		}
+	}
+	if !slice.Events[2].Event.CreatedTime().IsZero() {
+		t.Fatalf("Expected the zero time got %v", slice.Events[2].Event.CreatedTime())
+	}
+}
+
+func TestReadStreamEventsForward_WithResolvedLinks(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		read, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsForwardCompletedCommand,
+			CorrelationID: read.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+				Events: []*protobuf.ResolvedIndexedEvent{
+					{Event: newTestEventRecord("order-1", 3), Link: newTestEventRecord("$ce-order", 0)},
+				},
+				Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+				NextEventNumber:    proto.Int32(1),
+				LastEventNumber:    proto.Int32(0),
+				IsEndOfStream:      proto.Bool(true),
+				LastCommitPosition: proto.Int64(0),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	slice, err := conn.ReadStreamEventsForward("$ce-order", 0, 10, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 1 {
+		t.Fatalf("Expected %v got %v", 1, len(slice.Events))
+	}
+	evnt := slice.Events[0]
+	if evnt.Event.StreamID != "order-1" || evnt.Event.EventNumber != 3 {
+		t.Fatalf("Expected %v got %v@%v", "order-1@3", evnt.Event.StreamID, evnt.Event.EventNumber)
+	}
+	if evnt.OriginalStreamID() != "$ce-order" || evnt.OriginalEventNumber() != 0 {
+		t.Fatalf("Expected %v got %v@%v", "$ce-order@0", evnt.OriginalStreamID(), evnt.OriginalEventNumber())
+	}
+}
+
+func TestWriteEvents_RoundTripsTheContentTypes(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		write, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		request := &protobuf.WriteEvents{}
+		proto.Unmarshal(write.Data, request)
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       writeEventsCompletedCommand,
+			CorrelationID: write.CorrelationID,
+			Data:          newTestWriteEventsCompleted(t),
+		}))
+
+		read, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		completed := &protobuf.ReadStreamEventsCompleted{
+			Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+			NextEventNumber:    proto.Int32(int32(len(request.Events))),
+			LastEventNumber:    proto.Int32(int32(len(request.Events) - 1)),
+			IsEndOfStream:      proto.Bool(true),
+			LastCommitPosition: proto.Int64(0),
+		}
+		for i, evnt := range request.Events {
+			record := newTestEventRecord(request.GetEventStreamId(), int32(i))
+			record.DataContentType = evnt.DataContentType
+			record.MetadataContentType = evnt.MetadataContentType
+			completed.Events = append(completed.Events, &protobuf.ResolvedIndexedEvent{Event: record})
+		}
+		data, _ := proto.Marshal(completed)
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsForwardCompletedCommand,
+			CorrelationID: read.CorrelationID,
+			Data:          data,
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	events := []goes.EventData{
+		{EventID: uuid.NewV4(), EventType: "JSONEvent", IsJSON: true, IsJSONMetadata: true, Data: []byte("{}"), Metadata: []byte("{}")},
+		{EventID: uuid.NewV4(), EventType: "BinaryEvent", IsJSONMetadata: true, Data: []byte{0x01}, Metadata: []byte("{}")},
+		{EventID: uuid.NewV4(), EventType: "BinaryMetadataEvent", IsJSON: true, Data: []byte("{}"), Metadata: []byte{0x01}},
+	}
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, events); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	slice, err := conn.ReadStreamEventsForward("testStream", 0, 10, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != len(events) {
+		t.Fatalf("Expected %v got %v", len(events), len(slice.Events))
+	}
+	for i, evnt := range slice.Events {
+		if evnt.Event.IsJSON != events[i].IsJSON {
+			t.Fatalf("Expected %v got %v", events[i].IsJSON, evnt.Event.IsJSON)
+		}
+		if evnt.Event.IsJSONMetadata != events[i].IsJSONMetadata {
+			t.Fatalf("Expected %v got %v", events[i].IsJSONMetadata, evnt.Event.IsJSONMetadata)
+		}
+	}
+}
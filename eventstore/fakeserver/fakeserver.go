@@ -0,0 +1,361 @@
+// Package fakeserver is an in-memory Event Store that speaks enough of the tcp protocol to test the client, or code that
+// uses it, without running a real server. It listens on a loopback port, stores the written events in memory and serves
+// reads and subscriptions from them. The response to any command can be scripted with Handle, and dropped connections
+// and subscriptions can be simulated to exercise reconnects.
+package fakeserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Command is the command of a package
+type Command byte
+
+// The commands the fake server handles or sends
+const (
+	HeartbeatRequest                  Command = 0x01
+	HeartbeatResponse                 Command = 0x02
+	Ping                              Command = 0x03
+	Pong                              Command = 0x04
+	WriteEvents                       Command = 0x82
+	WriteEventsCompleted              Command = 0x83
+	ReadEvent                         Command = 0xB0
+	ReadEventCompleted                Command = 0xB1
+	ReadStreamEventsForward           Command = 0xB2
+	ReadStreamEventsForwardCompleted  Command = 0xB3
+	ReadStreamEventsBackward          Command = 0xB4
+	ReadStreamEventsBackwardCompleted Command = 0xB5
+	SubscribeToStream                 Command = 0xC0
+	SubscriptionConfirmation          Command = 0xC1
+	StreamEventAppeared               Command = 0xC2
+	UnsubscribeFromStream             Command = 0xC3
+	SubscriptionDropped               Command = 0xC4
+	BadRequest                        Command = 0xF0
+	NotHandled                        Command = 0xF1
+	NotAuthenticated                  Command = 0xF4
+	IdentifyClient                    Command = 0xF5
+	ClientIdentified                  Command = 0xF6
+)
+
+const (
+	// headerSize is the size of the command, the flags and the correlation id that start every package
+	headerSize = 1 + 1 + 16
+	// authenticatedFlag is set when the package carries a login and password
+	authenticatedFlag = 0x01
+	// maxPackageSize is the largest package that is read from a client
+	maxPackageSize = 64 * 1024 * 1024
+)
+
+// Package is a package sent by a client or to a client
+type Package struct {
+	Command Command
+	Flags   byte
+	// CorrelationID is the correlation id as it is encoded on the wire, a response carries the id of its request
+	CorrelationID []byte
+	Login         string
+	Password      string
+	Data          []byte
+}
+
+// HandlerFunc handles a package a client sent on a connection
+type HandlerFunc func(conn *Conn, pkg Package)
+
+// Server is the fake Event Store
+type Server struct {
+	listener net.Listener
+	// served is done once the accept loop and the connections have stopped
+	served sync.WaitGroup
+
+	mutex       sync.Mutex
+	handlers    map[Command]HandlerFunc
+	connections map[*Conn]struct{}
+	closed      bool
+	store
+}
+
+// New starts a fake server on a free loopback port
+func New() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		listener:    listener,
+		handlers:    map[Command]HandlerFunc{},
+		connections: map[*Conn]struct{}{},
+		store:       newStore(),
+	}
+	server.handlers[IdentifyClient] = respondWith(ClientIdentified)
+	server.handlers[Ping] = respondWith(Pong)
+	server.handlers[HeartbeatRequest] = respondWith(HeartbeatResponse)
+	server.handlers[HeartbeatResponse] = func(*Conn, Package) {}
+	server.handlers[WriteEvents] = server.writeEvents
+	server.handlers[ReadEvent] = server.readEvent
+	server.handlers[ReadStreamEventsForward] = server.readStreamEvents
+	server.handlers[ReadStreamEventsBackward] = server.readStreamEvents
+	server.handlers[SubscribeToStream] = server.subscribeToStream
+	server.handlers[UnsubscribeFromStream] = server.unsubscribeFromStream
+	server.served.Add(1)
+	go server.accept()
+	return server, nil
+}
+
+// Address returns the loopback address the server listens on
+func (server *Server) Address() string {
+	return server.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on
+func (server *Server) Port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Handle replaces the way the server responds to the command, e.g. to fail writes or to hold back a response. A nil
+// handler ignores the command. Commands without a handler are answered with BadRequest.
+func (server *Server) Handle(command Command, handler HandlerFunc) {
+	if handler == nil {
+		handler = func(*Conn, Package) {}
+	}
+	server.mutex.Lock()
+	server.handlers[command] = handler
+	server.mutex.Unlock()
+}
+
+// Handler returns the way the server responds to the command, e.g. for a handler passed to Handle to fall back on. It is
+// nil for a command that is answered with BadRequest.
+func (server *Server) Handler(command Command) HandlerFunc {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.handlers[command]
+}
+
+// Connections returns the number of clients that are connected
+func (server *Server) Connections() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return len(server.connections)
+}
+
+// DropConnections closes the connections of every client, which the clients see as a lost connection. The server keeps
+// accepting connections so that the clients can reconnect.
+func (server *Server) DropConnections() {
+	for _, conn := range server.connectionList() {
+		conn.Close()
+	}
+}
+
+// Close stops accepting connections, closes the connections of every client and waits for them to stop
+func (server *Server) Close() error {
+	err := server.listener.Close()
+	server.mutex.Lock()
+	server.closed = true
+	server.mutex.Unlock()
+	server.DropConnections()
+	server.served.Wait()
+	return err
+}
+
+func (server *Server) connectionList() []*Conn {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	connections := make([]*Conn, 0, len(server.connections))
+	for conn := range server.connections {
+		connections = append(connections, conn)
+	}
+	return connections
+}
+
+func (server *Server) accept() {
+	defer server.served.Done()
+	for {
+		socket, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		conn := &Conn{server: server, socket: socket}
+		server.mutex.Lock()
+		if server.closed {
+			server.mutex.Unlock()
+			socket.Close()
+			return
+		}
+		server.connections[conn] = struct{}{}
+		server.mutex.Unlock()
+		server.served.Add(1)
+		go conn.serve()
+	}
+}
+
+// Conn is the connection of a client to the server
+type Conn struct {
+	server *Server
+	socket net.Conn
+	// writeMutex keeps the packages that are sent from several goroutines whole
+	writeMutex sync.Mutex
+}
+
+// Send writes the package to the client
+func (conn *Conn) Send(pkg Package) error {
+	data, err := encodePackage(pkg)
+	if err != nil {
+		return err
+	}
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+	_, err = conn.socket.Write(data)
+	return err
+}
+
+// Respond sends the message to the client as the response with the command to the request. The message must set all
+// its required fields, as the client rejects it otherwise.
+func (conn *Conn) Respond(request Package, command Command, message proto.Message) error {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return conn.Send(Package{Command: command, CorrelationID: request.CorrelationID, Data: data})
+}
+
+// Close closes the connection, which the client sees as a lost connection
+func (conn *Conn) Close() error {
+	return conn.socket.Close()
+}
+
+func (conn *Conn) serve() {
+	defer conn.server.served.Done()
+	defer conn.disconnected()
+	for {
+		pkg, err := readPackage(conn.socket)
+		if err != nil {
+			return
+		}
+		conn.server.mutex.Lock()
+		handler, ok := conn.server.handlers[pkg.Command]
+		conn.server.mutex.Unlock()
+		if !ok {
+			conn.Send(Package{
+				Command:       BadRequest,
+				CorrelationID: pkg.CorrelationID,
+				Data:          []byte(fmt.Sprintf("command 0x%X is not supported by the fake server", byte(pkg.Command))),
+			})
+			continue
+		}
+		handler(conn, pkg)
+	}
+}
+
+func (conn *Conn) disconnected() {
+	conn.socket.Close()
+	server := conn.server
+	server.mutex.Lock()
+	delete(server.connections, conn)
+	server.removeSubscriptionsLocked(conn)
+	server.mutex.Unlock()
+}
+
+// respondWith answers a package with an empty package with the command
+func respondWith(command Command) HandlerFunc {
+	return func(conn *Conn, pkg Package) {
+		conn.Send(Package{Command: command, CorrelationID: pkg.CorrelationID})
+	}
+}
+
+func readPackage(reader io.Reader) (Package, error) {
+	var pkg Package
+	var length [4]byte
+	if _, err := io.ReadFull(reader, length[:]); err != nil {
+		return pkg, err
+	}
+	size := binary.LittleEndian.Uint32(length[:])
+	if size < headerSize || size > maxPackageSize {
+		return pkg, fmt.Errorf("invalid package length %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return pkg, err
+	}
+	pkg.Command = Command(data[0])
+	pkg.Flags = data[1]
+	pkg.CorrelationID = data[2:headerSize]
+	data = data[headerSize:]
+	if pkg.Flags&authenticatedFlag == authenticatedFlag {
+		var ok bool
+		if pkg.Login, data, ok = readString(data); !ok {
+			return pkg, errors.New("invalid login")
+		}
+		if pkg.Password, data, ok = readString(data); !ok {
+			return pkg, errors.New("invalid password")
+		}
+	}
+	pkg.Data = data
+	return pkg, nil
+}
+
+// readString reads a string that is prefixed by its length in a single byte
+func readString(data []byte) (string, []byte, bool) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return "", data, false
+	}
+	return string(data[1 : 1+data[0]]), data[1+data[0]:], true
+}
+
+func encodePackage(pkg Package) ([]byte, error) {
+	if len(pkg.CorrelationID) != 16 {
+		return nil, fmt.Errorf("correlation id is %d bytes, expected 16 bytes", len(pkg.CorrelationID))
+	}
+	size := headerSize + len(pkg.Data)
+	if pkg.Flags&authenticatedFlag == authenticatedFlag {
+		size += 1 + len(pkg.Login) + 1 + len(pkg.Password)
+	}
+	data := make([]byte, 4, 4+size)
+	binary.LittleEndian.PutUint32(data, uint32(size))
+	data = append(data, byte(pkg.Command), pkg.Flags)
+	data = append(data, pkg.CorrelationID...)
+	if pkg.Flags&authenticatedFlag == authenticatedFlag {
+		data = append(data, byte(len(pkg.Login)))
+		data = append(data, pkg.Login...)
+		data = append(data, byte(len(pkg.Password)))
+		data = append(data, pkg.Password...)
+	}
+	return append(data, pkg.Data...), nil
+}
+
+// String returns the name of the command
+func (command Command) String() string {
+	if name, ok := commandNames[command]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%X", byte(command))
+}
+
+var commandNames = map[Command]string{
+	HeartbeatRequest:                  "HeartbeatRequest",
+	HeartbeatResponse:                 "HeartbeatResponse",
+	Ping:                              "Ping",
+	Pong:                              "Pong",
+	WriteEvents:                       "WriteEvents",
+	WriteEventsCompleted:              "WriteEventsCompleted",
+	ReadEvent:                         "ReadEvent",
+	ReadEventCompleted:                "ReadEventCompleted",
+	ReadStreamEventsForward:           "ReadStreamEventsForward",
+	ReadStreamEventsForwardCompleted:  "ReadStreamEventsForwardCompleted",
+	ReadStreamEventsBackward:          "ReadStreamEventsBackward",
+	ReadStreamEventsBackwardCompleted: "ReadStreamEventsBackwardCompleted",
+	SubscribeToStream:                 "SubscribeToStream",
+	SubscriptionConfirmation:          "SubscriptionConfirmation",
+	StreamEventAppeared:               "StreamEventAppeared",
+	UnsubscribeFromStream:             "UnsubscribeFromStream",
+	SubscriptionDropped:               "SubscriptionDropped",
+	BadRequest:                        "BadRequest",
+	NotHandled:                        "NotHandled",
+	NotAuthenticated:                  "NotAuthenticated",
+	IdentifyClient:                    "IdentifyClient",
+	ClientIdentified:                  "ClientIdentified",
+}
@@ -0,0 +1,150 @@
+package fakeserver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+func startTestServer(t *testing.T) (*fakeserver.Server, *goes.EventStoreConnection) {
+	server, err := fakeserver.New()
+	if err != nil {
+		t.Fatalf("Unexpected failure starting the fake server: %s", err.Error())
+	}
+	config := goes.NewConfiguration()
+	config.Address = server.Address()
+	config.Port = server.Port()
+	config.ReconnectionDelay = 10
+	config.MaxReconnects = 5
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up the connection: %s", err.Error())
+	}
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Unexpected failure connecting: %s", err.Error())
+	}
+	return server, conn
+}
+
+func TestServer_WritesAndReadsEvents(t *testing.T) {
+	server, conn := startTestServer(t)
+	defer server.Close()
+	defer conn.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := conn.AppendJSON("orders", int64(i)-1, "OrderPlaced", map[string]int{"order": i}, nil); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+	if _, err := conn.AppendJSON("orders", 0, "OrderPlaced", nil, nil); err == nil {
+		t.Fatalf("Expected the write at the wrong expected version to fail")
+	}
+
+	slice, err := conn.ReadStreamEventsForward("orders", 1, 10, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 2 || !slice.IsEndOfStream {
+		t.Fatalf("Expected %v events and the end of the stream got %+v", 2, slice)
+	}
+	if slice.Events[0].Event.EventNumber != 1 || string(slice.Events[0].Event.Data) != `{"order":1}` {
+		t.Fatalf("Expected the event %v got %+v", 1, slice.Events[0].Event)
+	}
+	evnt, err := conn.ReadEvent("orders", -1, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if evnt.Event.EventNumber != 2 {
+		t.Fatalf("Expected %v got %v", 2, evnt.Event.EventNumber)
+	}
+	if _, err := conn.ReadEvent("customers", 0, false); err != goes.ErrNoStream {
+		t.Fatalf("Expected %v got %v", goes.ErrNoStream, err)
+	}
+	if len(server.Events("orders")) != 3 {
+		t.Fatalf("Expected %v got %v", 3, len(server.Events("orders")))
+	}
+}
+
+func TestServer_DeliversWrittenEventsToSubscribers(t *testing.T) {
+	server, conn := startTestServer(t)
+	defer server.Close()
+	defer conn.Close()
+
+	received := make(chan goes.ResolvedEvent, 10)
+	subscription, err := conn.SubscribeToStream("orders", false, func(evnt goes.ResolvedEvent) {
+		received <- evnt
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if _, err := conn.AppendJSON("customers", goes.ExpectedVersionAny, "CustomerRegistered", nil, nil); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if _, err := conn.AppendJSON("orders", goes.ExpectedVersionAny, "OrderPlaced", nil, nil); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	select {
+	case evnt := <-received:
+		if evnt.Event.StreamID != "orders" {
+			t.Fatalf("Expected %v got %v", "orders", evnt.Event.StreamID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the event to be delivered")
+	}
+	if err := subscription.Unsubscribe(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestServer_WithScriptedResponse(t *testing.T) {
+	server, conn := startTestServer(t)
+	defer server.Close()
+	defer conn.Close()
+
+	server.Handle(fakeserver.WriteEvents, func(c *fakeserver.Conn, pkg fakeserver.Package) {
+		c.Respond(pkg, fakeserver.WriteEventsCompleted, &protobuf.WriteEventsCompleted{
+			Result:           protobuf.OperationResult_AccessDenied.Enum(),
+			FirstEventNumber: proto.Int32(-1),
+			LastEventNumber:  proto.Int32(-1),
+		})
+	})
+	_, err := conn.AppendJSON("orders", goes.ExpectedVersionAny, "OrderPlaced", nil, nil)
+	if err != goes.ErrAccessDenied {
+		t.Fatalf("Expected %v got %v", goes.ErrAccessDenied, err)
+	}
+}
+
+func TestServer_DropConnections(t *testing.T) {
+	server, conn := startTestServer(t)
+	defer server.Close()
+	defer conn.Close()
+
+	received := make(chan goes.ResolvedEvent, 10)
+	if _, err := conn.SubscribeToStream("orders", false, func(evnt goes.ResolvedEvent) {
+		received <- evnt
+	}); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	server.DropConnections()
+
+	// the subscription is subscribed again once the connection was re-established
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Subscriptions() != 1 || server.Connections() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the connection to be re-established")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := conn.AppendJSON("orders", goes.ExpectedVersionAny, "OrderPlaced", nil, nil); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the event to be delivered after resubscribing")
+	}
+}
@@ -0,0 +1,294 @@
+package fakeserver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+// The expected versions a write can be made with, besides the number of the last event in the stream
+const (
+	expectedVersionAny          = -2
+	expectedVersionNoStream     = -1
+	expectedVersionStreamExists = -4
+)
+
+// allStream is the stream id a subscription to all the events is made with
+const allStream = ""
+
+// store holds the events written to the server and the subscriptions of the clients, it is guarded by the server's mutex
+type store struct {
+	streams map[string][]storedEvent
+	// subscriptions are keyed by the correlation id of the subscribe package
+	subscriptions map[string]*subscription
+	// position is the position of the last event in the transaction log
+	position int64
+}
+
+type storedEvent struct {
+	record   *protobuf.EventRecord
+	position int64
+}
+
+type subscription struct {
+	conn          *Conn
+	stream        string
+	correlationID []byte
+}
+
+func newStore() store {
+	return store{
+		streams:       map[string][]storedEvent{},
+		subscriptions: map[string]*subscription{},
+	}
+}
+
+// Events returns the events that were written to the stream
+func (server *Server) Events(stream string) []*protobuf.EventRecord {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	records := make([]*protobuf.EventRecord, 0, len(server.streams[stream]))
+	for _, evnt := range server.streams[stream] {
+		records = append(records, evnt.record)
+	}
+	return records
+}
+
+// Subscriptions returns the number of subscriptions the clients have made
+func (server *Server) Subscriptions() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return len(server.subscriptions)
+}
+
+// DropSubscriptions drops the subscriptions of every client with the reason, e.g. to test how subscribers recover
+func (server *Server) DropSubscriptions(reason protobuf.SubscriptionDropped_SubscriptionDropReason) {
+	server.mutex.Lock()
+	subscriptions := server.subscriptions
+	server.subscriptions = map[string]*subscription{}
+	server.mutex.Unlock()
+	for _, subscription := range subscriptions {
+		subscription.drop(reason)
+	}
+}
+
+func (subscription *subscription) drop(reason protobuf.SubscriptionDropped_SubscriptionDropReason) {
+	subscription.conn.Respond(Package{CorrelationID: subscription.correlationID}, SubscriptionDropped, &protobuf.SubscriptionDropped{
+		Reason: reason.Enum(),
+	})
+}
+
+func (server *Server) removeSubscriptionsLocked(conn *Conn) {
+	for correlationID, subscription := range server.subscriptions {
+		if subscription.conn == conn {
+			delete(server.subscriptions, correlationID)
+		}
+	}
+}
+
+func (server *Server) writeEvents(conn *Conn, pkg Package) {
+	request := &protobuf.WriteEvents{}
+	if err := proto.Unmarshal(pkg.Data, request); err != nil {
+		badRequest(conn, pkg, err)
+		return
+	}
+	stream := request.GetEventStreamId()
+
+	server.mutex.Lock()
+	// the events are passed to the subscribers while holding the mutex, so that they receive them in the order they were written
+	defer server.mutex.Unlock()
+	events := server.streams[stream]
+	current := int32(len(events)) - 1
+	expected := request.GetExpectedVersion()
+	if !(expected == expectedVersionAny ||
+		(expected == expectedVersionNoStream && current == -1) ||
+		(expected == expectedVersionStreamExists && current >= 0) ||
+		expected == current) {
+		conn.Respond(pkg, WriteEventsCompleted, &protobuf.WriteEventsCompleted{
+			Result:           protobuf.OperationResult_WrongExpectedVersion.Enum(),
+			Message:          proto.String(fmt.Sprintf("expected version %d but the current version is %d", expected, current)),
+			FirstEventNumber: proto.Int32(-1),
+			LastEventNumber:  proto.Int32(-1),
+		})
+		return
+	}
+
+	created := time.Now()
+	appended := make([]storedEvent, 0, len(request.GetEvents()))
+	for i, evnt := range request.GetEvents() {
+		server.position++
+		appended = append(appended, storedEvent{
+			record: &protobuf.EventRecord{
+				EventStreamId:       proto.String(stream),
+				EventNumber:         proto.Int32(current + 1 + int32(i)),
+				EventId:             evnt.GetEventId(),
+				EventType:           proto.String(evnt.GetEventType()),
+				DataContentType:     proto.Int32(evnt.GetDataContentType()),
+				MetadataContentType: proto.Int32(evnt.GetMetadataContentType()),
+				Data:                evnt.GetData(),
+				Metadata:            evnt.GetMetadata(),
+				CreatedEpoch:        proto.Int64(created.UnixNano() / int64(time.Millisecond)),
+			},
+			position: server.position,
+		})
+	}
+	server.streams[stream] = append(events, appended...)
+	conn.Respond(pkg, WriteEventsCompleted, &protobuf.WriteEventsCompleted{
+		Result:           protobuf.OperationResult_Success.Enum(),
+		FirstEventNumber: proto.Int32(current + 1),
+		LastEventNumber:  proto.Int32(current + int32(len(appended))),
+		PreparePosition:  proto.Int64(server.position),
+		CommitPosition:   proto.Int64(server.position),
+	})
+
+	for _, subscription := range server.subscriptions {
+		if subscription.stream != stream && subscription.stream != allStream {
+			continue
+		}
+		for _, evnt := range appended {
+			subscription.conn.Respond(Package{CorrelationID: subscription.correlationID}, StreamEventAppeared, &protobuf.StreamEventAppeared{
+				Event: &protobuf.ResolvedEvent{
+					Event:           evnt.record,
+					CommitPosition:  proto.Int64(evnt.position),
+					PreparePosition: proto.Int64(evnt.position),
+				},
+			})
+		}
+	}
+}
+
+func (server *Server) readEvent(conn *Conn, pkg Package) {
+	request := &protobuf.ReadEvent{}
+	if err := proto.Unmarshal(pkg.Data, request); err != nil {
+		badRequest(conn, pkg, err)
+		return
+	}
+	server.mutex.Lock()
+	events := server.streams[request.GetEventStreamId()]
+	server.mutex.Unlock()
+
+	eventNumber := request.GetEventNumber()
+	if eventNumber == -1 {
+		eventNumber = int32(len(events)) - 1
+	}
+	result := protobuf.ReadEventCompleted_Success
+	record := &protobuf.EventRecord{
+		EventStreamId:       proto.String(request.GetEventStreamId()),
+		EventNumber:         proto.Int32(eventNumber),
+		EventId:             make([]byte, 16),
+		EventType:           proto.String(""),
+		DataContentType:     proto.Int32(0),
+		MetadataContentType: proto.Int32(0),
+		Data:                []byte{},
+	}
+	switch {
+	case len(events) == 0:
+		result = protobuf.ReadEventCompleted_NoStream
+	case eventNumber < 0 || int(eventNumber) >= len(events):
+		result = protobuf.ReadEventCompleted_NotFound
+	default:
+		record = events[eventNumber].record
+	}
+	conn.Respond(pkg, ReadEventCompleted, &protobuf.ReadEventCompleted{
+		Result: result.Enum(),
+		Event:  &protobuf.ResolvedIndexedEvent{Event: record},
+	})
+}
+
+func (server *Server) readStreamEvents(conn *Conn, pkg Package) {
+	request := &protobuf.ReadStreamEvents{}
+	if err := proto.Unmarshal(pkg.Data, request); err != nil {
+		badRequest(conn, pkg, err)
+		return
+	}
+	server.mutex.Lock()
+	events := server.streams[request.GetEventStreamId()]
+	position := server.position
+	server.mutex.Unlock()
+
+	completed := ReadStreamEventsForwardCompleted
+	if pkg.Command == ReadStreamEventsBackward {
+		completed = ReadStreamEventsBackwardCompleted
+	}
+	last := int32(len(events)) - 1
+	response := &protobuf.ReadStreamEventsCompleted{
+		Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+		NextEventNumber:    proto.Int32(-1),
+		LastEventNumber:    proto.Int32(last),
+		IsEndOfStream:      proto.Bool(true),
+		LastCommitPosition: proto.Int64(position),
+	}
+	if len(events) == 0 {
+		response.Result = protobuf.ReadStreamEventsCompleted_NoStream.Enum()
+		conn.Respond(pkg, completed, response)
+		return
+	}
+
+	from := request.GetFromEventNumber()
+	count := request.GetMaxCount()
+	if pkg.Command == ReadStreamEventsForward {
+		if from < 0 {
+			from = 0
+		}
+		for number := from; number <= last && number < from+count; number++ {
+			response.Events = append(response.Events, &protobuf.ResolvedIndexedEvent{Event: events[number].record})
+		}
+		response.NextEventNumber = proto.Int32(last + 1)
+		if from+count <= last {
+			response.NextEventNumber = proto.Int32(from + count)
+			response.IsEndOfStream = proto.Bool(false)
+		}
+	} else {
+		if from == -1 || from > last {
+			from = last
+		}
+		for number := from; number >= 0 && number > from-count; number-- {
+			response.Events = append(response.Events, &protobuf.ResolvedIndexedEvent{Event: events[number].record})
+		}
+		if from-count >= 0 {
+			response.NextEventNumber = proto.Int32(from - count)
+			response.IsEndOfStream = proto.Bool(false)
+		}
+	}
+	conn.Respond(pkg, completed, response)
+}
+
+func (server *Server) subscribeToStream(conn *Conn, pkg Package) {
+	request := &protobuf.SubscribeToStream{}
+	if err := proto.Unmarshal(pkg.Data, request); err != nil {
+		badRequest(conn, pkg, err)
+		return
+	}
+	stream := request.GetEventStreamId()
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.subscriptions[string(pkg.CorrelationID)] = &subscription{
+		conn:          conn,
+		stream:        stream,
+		correlationID: pkg.CorrelationID,
+	}
+	confirmation := &protobuf.SubscriptionConfirmation{
+		LastCommitPosition: proto.Int64(server.position),
+	}
+	if stream != allStream {
+		confirmation.LastEventNumber = proto.Int32(int32(len(server.streams[stream])) - 1)
+	}
+	// the confirmation is sent while holding the mutex so that no event written in the meantime precedes it
+	conn.Respond(pkg, SubscriptionConfirmation, confirmation)
+}
+
+func (server *Server) unsubscribeFromStream(conn *Conn, pkg Package) {
+	server.mutex.Lock()
+	subscription, ok := server.subscriptions[string(pkg.CorrelationID)]
+	delete(server.subscriptions, string(pkg.CorrelationID))
+	server.mutex.Unlock()
+	if ok {
+		subscription.drop(protobuf.SubscriptionDropped_Unsubscribed)
+	}
+}
+
+func badRequest(conn *Conn, pkg Package, err error) {
+	conn.Send(Package{Command: BadRequest, CorrelationID: pkg.CorrelationID, Data: []byte(err.Error())})
+}
@@ -1,10 +1,13 @@
 package goes_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	goes "github.com/pgermishuys/goes/eventstore"
 )
@@ -74,7 +77,7 @@ func TestGossipEndPointDiscoverer_WithAliveMaster(t *testing.T) {
 		t.Fatalf("Expected State to be Master but was %s", member.State)
 	}
 	if member.IsAlive != true {
-		t.Fatalf("Expected IsAlive to be true but was %s", member.IsAlive)
+		t.Fatalf("Expected IsAlive to be true but was %v", member.IsAlive)
 	}
 }
 
@@ -132,6 +135,174 @@ func TestGossipEndPointDiscoverer_WithTheFirstGossipRequestFailing(t *testing.T)
 		t.Fatalf("Expected State to be Master but was %s", member.State)
 	}
 	if member.IsAlive != true {
-		t.Fatalf("Expected IsAlive to be true but was %s", member.IsAlive)
+		t.Fatalf("Expected IsAlive to be true but was %v", member.IsAlive)
+	}
+}
+
+func TestGossipSeedDiscoverer_FailsOverWhenASeedIsUnavailable(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	available := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"members": [{"state": "Master", "isAlive": true, "externalTcpIp": "127.0.0.1", "externalTcpPort": 1114}]}`)
+	}))
+	defer available.Close()
+
+	seeds := []string{unavailable.URL, available.URL}
+	discoverer := goes.GossipSeedDiscoverer{
+		GossipSeeds: seeds,
+	}
+	member, err := discoverer.Discover()
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if member.ExternalTCPIP != "127.0.0.1" || member.ExternalTCPPort != 1114 {
+		t.Fatalf("Expected %v got %v:%v", "127.0.0.1:1114", member.ExternalTCPIP, member.ExternalTCPPort)
+	}
+	if seeds[0] != unavailable.URL || seeds[1] != available.URL {
+		t.Fatalf("Expected the gossip seeds not to be reordered got %v", seeds)
+	}
+}
+
+func TestGossipSeedDiscoverer_WithNoAliveMembers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"members": [{"state": "Master", "isAlive": false, "externalTcpIp": "127.0.0.1", "externalTcpPort": 1114}]}`)
+	}))
+	defer server.Close()
+
+	discoverer := goes.GossipSeedDiscoverer{
+		MaxDiscoverAttempts: 3,
+		GossipSeeds:         []string{server.URL},
+	}
+	_, err := discoverer.Discover()
+	if err == nil {
+		t.Fatalf("Expected discovering a cluster without alive members to fail")
+	}
+	if count := strings.Count(err.Error(), server.URL); count != 3 {
+		t.Fatalf("Expected the failure of each attempt to be reported got %v", err)
+	}
+}
+
+func startTestGossipServer(members string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"members": [%s]}`, members)
+	}))
+}
+
+func TestGossipSeedDiscoverer_WithNodePreference(t *testing.T) {
+	server := startTestGossipServer(`
+		{"state": "Manager", "isAlive": true, "externalTcpIp": "127.0.0.5", "externalTcpPort": 1113},
+		{"state": "Follower", "isAlive": false, "externalTcpIp": "127.0.0.4", "externalTcpPort": 1113},
+		{"state": "ReadOnlyReplica", "isAlive": true, "externalTcpIp": "127.0.0.3", "externalTcpPort": 1113},
+		{"state": "Follower", "isAlive": true, "externalTcpIp": "127.0.0.2", "externalTcpPort": 1113},
+		{"state": "Leader", "isAlive": true, "externalTcpIp": "127.0.0.1", "externalTcpPort": 1113}`)
+	defer server.Close()
+
+	for preference, expected := range map[goes.NodePreference]string{
+		goes.NodePreferenceLeader:          "Leader",
+		goes.NodePreferenceFollower:        "Follower",
+		goes.NodePreferenceReadOnlyReplica: "ReadOnlyReplica",
+	} {
+		discoverer := goes.GossipSeedDiscoverer{
+			GossipSeeds:    []string{server.URL},
+			NodePreference: preference,
+		}
+		member, err := discoverer.Discover()
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		if member.State != expected || !member.IsAlive {
+			t.Fatalf("Expected %v got %+v", expected, member)
+		}
+	}
+
+	discoverer := goes.GossipSeedDiscoverer{
+		GossipSeeds:    []string{server.URL},
+		NodePreference: goes.NodePreferenceRandom,
+	}
+	for i := 0; i < 10; i++ {
+		member, err := discoverer.Discover()
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		if !member.IsAlive || member.State == "Manager" {
+			t.Fatalf("Expected an alive member that accepts client connections got %+v", member)
+		}
+	}
+}
+
+func TestGossipSeedDiscoverer_WithNoPreferredNodeAlive(t *testing.T) {
+	server := startTestGossipServer(`
+		{"state": "Slave", "isAlive": false, "externalTcpIp": "127.0.0.2", "externalTcpPort": 1113},
+		{"state": "Master", "isAlive": true, "externalTcpIp": "127.0.0.1", "externalTcpPort": 1113}`)
+	defer server.Close()
+
+	discoverer := goes.GossipSeedDiscoverer{
+		GossipSeeds:    []string{server.URL},
+		NodePreference: goes.NodePreferenceFollower,
+	}
+	member, err := discoverer.Discover()
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if member.State != "Master" {
+		t.Fatalf("Expected %v got %v", "Master", member.State)
+	}
+}
+
+// startTestSlowGossipServer starts a gossip server that does not respond until it is closed
+func startTestSlowGossipServer() (*httptest.Server, chan struct{}) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	return server, release
+}
+
+func TestGossipSeedDiscoverer_DiscoverContextWhenContextIsCancelled(t *testing.T) {
+	server, release := startTestSlowGossipServer()
+	defer server.Close()
+	defer close(release)
+
+	discoverer := &goes.GossipSeedDiscoverer{
+		MaxDiscoverAttempts: 3,
+		GossipSeeds:         []string{server.URL},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := discoverer.DiscoverContext(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("Expected discovery to give up when the context expired, took %v", elapsed)
+	}
+}
+
+func TestConnectWithContext_WhenDiscoveryIsCancelled(t *testing.T) {
+	server, release := startTestSlowGossipServer()
+	defer server.Close()
+	defer close(release)
+
+	config := goes.NewConfiguration()
+	config.MaxReconnects = 1
+	config.EndpointDiscoverer = &goes.GossipSeedDiscoverer{GossipSeeds: []string{server.URL}}
+	conn, err := goes.NewEventStoreConnection(config)
+	if err != nil {
+		t.Fatalf("Unexpected failure setting up test connection: %s", err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = conn.ConnectWithContext(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v got %v", context.DeadlineExceeded, err)
 	}
 }
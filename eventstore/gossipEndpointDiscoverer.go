@@ -1,87 +1,176 @@
 package goes
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
-//GossipEndpointDiscoverer used for discovering and picking the most appropriate node in a cluster
-type GossipEndpointDiscoverer struct {
+// gossipClient is used to gossip with the seeds, a seed that does not respond within the timeout is treated as unreachable
+var gossipClient = &http.Client{Timeout: 5 * time.Second}
+
+// GossipSeedDiscoverer discovers the nodes in a cluster by gossiping over HTTP with a list of seeds and picks the most
+// appropriate node to connect to. The seeds are the http endpoints of the nodes, e.g. http://127.0.0.1:2113, and are tried
+// in a random order until one of them responds with at least one alive member.
+type GossipSeedDiscoverer struct {
+	// MaxDiscoverAttempts is the number of seeds that are tried before giving up, the seeds are tried once each when it is 0
 	MaxDiscoverAttempts int
 	GossipSeeds         []string
+	// NodePreference is the kind of node that is picked when the cluster has several alive members, the leader by default
+	NodePreference NodePreference
+}
+
+// NodePreference determines which alive member of a cluster is connected to
+type NodePreference int
+
+const (
+	// NodePreferenceLeader connects to the leader, which is needed for reads that must see every write
+	NodePreferenceLeader NodePreference = iota
+	// NodePreferenceFollower connects to a random follower to offload reads from the leader
+	NodePreferenceFollower
+	// NodePreferenceReadOnlyReplica connects to a random read-only replica, e.g. for bulk reads
+	NodePreferenceReadOnlyReplica
+	// NodePreferenceRandom connects to a random member that accepts client connections
+	NodePreferenceRandom
+)
+
+// nodeStates are the member states by the kind of node, the older Master and Slave states are reported by servers before 20.6
+var nodeStates = map[string]NodePreference{
+	"Master":          NodePreferenceLeader,
+	"Leader":          NodePreferenceLeader,
+	"Slave":           NodePreferenceFollower,
+	"Follower":        NodePreferenceFollower,
+	"ReadOnlyReplica": NodePreferenceReadOnlyReplica,
+}
+
+// nodePreferenceOrder is the order in which the kinds of nodes are picked for a preference, when no node of the preferred
+// kind is alive the next kind is picked
+var nodePreferenceOrder = map[NodePreference][]NodePreference{
+	NodePreferenceLeader:          {NodePreferenceLeader, NodePreferenceFollower, NodePreferenceReadOnlyReplica},
+	NodePreferenceFollower:        {NodePreferenceFollower, NodePreferenceLeader, NodePreferenceReadOnlyReplica},
+	NodePreferenceReadOnlyReplica: {NodePreferenceReadOnlyReplica, NodePreferenceFollower, NodePreferenceLeader},
 }
 
+// GossipEndpointDiscoverer used for discovering and picking the most appropriate node in a cluster
+//
+// Deprecated: use GossipSeedDiscoverer
+type GossipEndpointDiscoverer = GossipSeedDiscoverer
+
 // Discover will discover nodes via performing a gossip over HTTP and then picking the best candidate to connect to
-func (discoverer *GossipEndpointDiscoverer) Discover() (MemberInfo, error) {
+func (discoverer *GossipSeedDiscoverer) Discover() (MemberInfo, error) {
+	return discoverer.DiscoverContext(context.Background())
+}
+
+// DiscoverContext is like Discover but gives up when ctx is cancelled
+func (discoverer *GossipSeedDiscoverer) DiscoverContext(ctx context.Context) (MemberInfo, error) {
 	if len(discoverer.GossipSeeds) == 0 {
 		return MemberInfo{}, errors.New("There are no gossip seeds")
 	}
-	discoverer.GossipSeeds = shuffleGossipSeeds(discoverer.GossipSeeds)
-	gossipIndex := 0
-	for attempt := 1; attempt <= discoverer.MaxDiscoverAttempts; attempt++ {
-		if gossipIndex >= len(discoverer.GossipSeeds) {
-			gossipIndex = 0
-		}
-		gossipSeed := discoverer.GossipSeeds[gossipIndex]
-		gossipIndex++
+	maxAttempts := discoverer.MaxDiscoverAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = len(discoverer.GossipSeeds)
+	}
+	gossipSeeds := shuffleGossipSeeds(discoverer.GossipSeeds)
+	// failures are the errors of the seeds that were tried, which are all reported when no seed responds
+	failures := make([]string, 0, maxAttempts)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		gossipSeed := gossipSeeds[attempt%len(gossipSeeds)]
 		log.Printf("[info] attempting to gossip via %+v", gossipSeed)
-		member, err := discoverEndPoint(gossipSeed)
+		member, err := discoverEndPoint(ctx, gossipSeed, discoverer.NodePreference)
+		if ctx.Err() != nil {
+			return MemberInfo{}, ctx.Err()
+		}
 		if err != nil {
-			if attempt == discoverer.MaxDiscoverAttempts {
-				return MemberInfo{}, errors.New("Failed to discover any cluster node members via gossip. Maximum number of attempts reached")
-			}
+			failures = append(failures, fmt.Sprintf("%s: %s", gossipSeed, err.Error()))
 			continue
 		}
 		return member, nil
 	}
-	return MemberInfo{}, nil
+	return MemberInfo{}, fmt.Errorf("Failed to discover any cluster node members via gossip. Maximum number of attempts reached: %s", strings.Join(failures, "; "))
 }
 
-func discoverEndPoint(gossipSeed string) (MemberInfo, error) {
-	gossipResponse, err := gossip(gossipSeed)
+func discoverEndPoint(ctx context.Context, gossipSeed string, preference NodePreference) (MemberInfo, error) {
+	gossipResponse, err := gossip(ctx, gossipSeed)
 	if err != nil {
 		return MemberInfo{}, err
 	}
-	candidate, _ := getBestCandidate(gossipResponse)
-	return candidate, nil
+	return getBestCandidate(gossipResponse, preference)
 }
 
+// shuffleGossipSeeds returns a copy of the seeds in a random order
 func shuffleGossipSeeds(src []string) []string {
-	for i := range src {
-		j := rand.Intn(i + 1)
-		src[i], src[j] = src[j], src[i]
+	dst := make([]string, len(src))
+	for i, j := range rand.Perm(len(src)) {
+		dst[i] = src[j]
 	}
-	return src
+	return dst
 }
 
-func getBestCandidate(response GossipResponse) (MemberInfo, error) {
+// getBestCandidate picks a random alive member of the most preferred kind. Members in other states, such as managers, are only
+// picked when no member of a known kind is alive.
+func getBestCandidate(response GossipResponse, preference NodePreference) (MemberInfo, error) {
 	if len(response.Members) == 0 {
 		return MemberInfo{}, errors.New("There are no members to determine the best candidate from")
 	}
-	for _, member := range response.Members {
-		if member.State == "Master" && member.IsAlive {
-			return member, nil
+	var candidates []MemberInfo
+	for _, i := range rand.Perm(len(response.Members)) {
+		if response.Members[i].IsAlive {
+			candidates = append(candidates, response.Members[i])
 		}
 	}
-	for _, member := range response.Members {
-		if member.IsAlive {
-			return member, nil
+	if len(candidates) == 0 {
+		return MemberInfo{}, errors.New("There are no alive members to determine the best candidate from")
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return nodeRank(candidates[i], preference) < nodeRank(candidates[j], preference)
+	})
+	return candidates[0], nil
+}
+
+// nodeRank is the position of the member's kind in the order of the preference, lower is better
+func nodeRank(member MemberInfo, preference NodePreference) int {
+	kind, ok := nodeStates[member.State]
+	if !ok {
+		return len(nodeStates)
+	}
+	order, ok := nodePreferenceOrder[preference]
+	if !ok {
+		// every known kind is as good as any other for a random node
+		return 0
+	}
+	for rank, preferred := range order {
+		if preferred == kind {
+			return rank
 		}
 	}
-	return MemberInfo{}, nil
+	return len(order)
 }
 
-func gossip(gossipSeed string) (GossipResponse, error) {
-	response, err := http.Get(gossipSeed + "/gossip")
-	if err != nil || response.StatusCode != http.StatusOK {
+func gossip(ctx context.Context, gossipSeed string) (GossipResponse, error) {
+	request, err := http.NewRequest(http.MethodGet, gossipSeed+"/gossip", nil)
+	if err != nil {
+		return GossipResponse{}, err
+	}
+	response, err := gossipClient.Do(request.WithContext(ctx))
+	if err != nil {
 		return GossipResponse{}, err
 	}
 	defer response.Body.Close()
-	body, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK {
+		return GossipResponse{}, fmt.Errorf("unexpected status %s", response.Status)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return GossipResponse{}, err
+	}
 	var gossipResponse GossipResponse
 	err = json.Unmarshal(body, &gossipResponse)
 	if err != nil {
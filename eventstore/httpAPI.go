@@ -0,0 +1,85 @@
+package goes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// DefaultHTTPPort is the port a node serves its HTTP API on unless it is configured otherwise
+const DefaultHTTPPort = 2113
+
+// httpAPI sends requests to the HTTP API of the node at the Address of a configuration
+type httpAPI struct {
+	config  *Configuration
+	baseURL string
+	client  *http.Client
+}
+
+func newHTTPAPI(config *Configuration, httpPort int) *httpAPI {
+	scheme := "http"
+	transport := &http.Transport{}
+	if config.UseTLS {
+		scheme = "https"
+		transport.TLSClientConfig = config.TLSConfig
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+	}
+	return &httpAPI{
+		config:  config,
+		baseURL: fmt.Sprintf("%s://%s", scheme, joinHostPort(config.Address, httpPort)),
+		client:  &http.Client{Transport: transport},
+	}
+}
+
+// send performs the request and unmarshals the response into result unless it is nil. A status in statusErrors is returned
+// as the matching error.
+func (api *httpAPI) send(ctx context.Context, method string, path string, body []byte, result interface{}, options []OperationOption, statusErrors map[int]error) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequest(method, api.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+	credentials := resolveCredentials(api.config, options)
+	if credentials.Login != "" {
+		request.SetBasicAuth(credentials.Login, credentials.Password)
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	response, err := api.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if err, ok := statusErrors[response.StatusCode]; ok {
+		return err
+	}
+	switch {
+	case response.StatusCode == http.StatusUnauthorized:
+		return ErrNotAuthenticated
+	case response.StatusCode == http.StatusForbidden:
+		return ErrAccessDenied
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		return fmt.Errorf("unexpected status %s: %s", response.Status, string(data))
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
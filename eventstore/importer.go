@@ -0,0 +1,135 @@
+package goes
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// defaultImportBatchSize is the number of events written per operation when ImportSettings.BatchSize is not set
+	defaultImportBatchSize = 500
+	// defaultImportConcurrency is the number of streams written at the same time when neither
+	// ImportSettings.Concurrency nor MaxInflight is set
+	defaultImportConcurrency = 100
+)
+
+// ImportSettings tune how an Importer writes the events
+type ImportSettings struct {
+	// BatchSize is the number of events of a stream that are written per operation, 500 when it is not set
+	BatchSize int
+	// Concurrency is the number of streams that are written at the same time. It defaults to MaxInflight, or to 100
+	// when MaxInflight is not set, and is never more than MaxInflight.
+	Concurrency int
+	// OnProgress is called every time the events of a stream have been written or have failed to be written. It is
+	// called for one stream at a time.
+	OnProgress func(ImportProgress)
+}
+
+// ImportProgress counts the streams and events an Importer has finished with
+type ImportProgress struct {
+	// Streams is the number of streams whose import has finished, including the streams that failed
+	Streams int
+	// FailedStreams is the number of streams that could not be imported completely
+	FailedStreams int
+	// Events is the number of events that were written
+	Events int
+}
+
+// Importer writes the events of many streams at the same time, e.g. to seed a store or to migrate from another store.
+// Every stream is written with WriteEventsBatch, so a stream that fails does not keep the other streams from being
+// imported, its failure is returned by Wait.
+type Importer struct {
+	connection *EventStoreConnection
+	settings   ImportSettings
+	options    []OperationOption
+	// slots holds a slot for every stream that is being written
+	slots  chan struct{}
+	writes sync.WaitGroup
+
+	mutex    sync.Mutex
+	progress ImportProgress
+	failures []*ErrBatchWriteFailed
+}
+
+// NewImporter creates an importer that writes with the options, such as WithCredentials
+func (connection *EventStoreConnection) NewImporter(settings ImportSettings, options ...OperationOption) *Importer {
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = defaultImportBatchSize
+	}
+	maxInflight := connection.Config.MaxInflight
+	if settings.Concurrency <= 0 {
+		settings.Concurrency = maxInflight
+	}
+	if settings.Concurrency <= 0 {
+		settings.Concurrency = defaultImportConcurrency
+	}
+	if maxInflight > 0 && settings.Concurrency > maxInflight {
+		settings.Concurrency = maxInflight
+	}
+	return &Importer{
+		connection: connection,
+		settings:   settings,
+		options:    options,
+		slots:      make(chan struct{}, settings.Concurrency),
+	}
+}
+
+// Import starts writing the events to the stream at the expected version and returns once the write has started. It
+// blocks while Concurrency streams are being written. A stream should only be imported once, unless its events are
+// imported with ExpectedVersionAny, as the writes of the same stream are not ordered.
+func (importer *Importer) Import(stream string, expectedVersion int64, events []EventData) error {
+	return importer.ImportWithContext(context.Background(), stream, expectedVersion, events)
+}
+
+// ImportWithContext is like Import but gives up waiting to start writing when ctx is cancelled, cancelling ctx
+// afterwards fails the write of the stream
+func (importer *Importer) ImportWithContext(ctx context.Context, stream string, expectedVersion int64, events []EventData) error {
+	select {
+	case importer.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	importer.writes.Add(1)
+	go func() {
+		defer importer.writes.Done()
+		defer func() { <-importer.slots }()
+		var err error
+		if len(events) > 0 {
+			_, err = importer.connection.WriteEventsBatchWithContext(ctx, stream, expectedVersion, events, importer.settings.BatchSize, importer.options...)
+		}
+		importer.finished(len(events), err)
+	}()
+	return nil
+}
+
+// Wait waits until the streams that were imported have been written and returns the failures of the streams that
+// could not be imported completely
+func (importer *Importer) Wait() []*ErrBatchWriteFailed {
+	importer.writes.Wait()
+	importer.mutex.Lock()
+	defer importer.mutex.Unlock()
+	return append([]*ErrBatchWriteFailed(nil), importer.failures...)
+}
+
+// Progress returns the streams and events the importer has finished with so far
+func (importer *Importer) Progress() ImportProgress {
+	importer.mutex.Lock()
+	defer importer.mutex.Unlock()
+	return importer.progress
+}
+
+func (importer *Importer) finished(events int, err error) {
+	importer.mutex.Lock()
+	defer importer.mutex.Unlock()
+	importer.progress.Streams++
+	if err == nil {
+		importer.progress.Events += events
+	} else if failure, ok := err.(*ErrBatchWriteFailed); ok {
+		importer.progress.FailedStreams++
+		importer.progress.Events += failure.Written
+		importer.failures = append(importer.failures, failure)
+	}
+	if importer.settings.OnProgress != nil {
+		importer.settings.OnProgress(importer.progress)
+	}
+}
@@ -0,0 +1,104 @@
+package goes_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// startTestImportServer accepts every write, except the writes to the streams whose name starts with denied
+func startTestImportServer(tb testing.TB) (*goes.EventStoreConnection, *fakeserver.Server) {
+	config := goes.NewConfiguration()
+	config.Logger = &testLogger{}
+	conn, server := startTestFakeServer(tb, config)
+	write := server.Handler(fakeserver.WriteEvents)
+	server.Handle(fakeserver.WriteEvents, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		request := &protobuf.WriteEvents{}
+		if err := proto.Unmarshal(pkg.Data, request); err != nil || !strings.HasPrefix(request.GetEventStreamId(), "denied") {
+			write(serverConn, pkg)
+			return
+		}
+		serverConn.Respond(pkg, fakeserver.WriteEventsCompleted, &protobuf.WriteEventsCompleted{
+			Result:           protobuf.OperationResult_AccessDenied.Enum(),
+			FirstEventNumber: proto.Int32(-1),
+			LastEventNumber:  proto.Int32(-1),
+		})
+	})
+	return conn, server
+}
+
+func newTestImportEvents(count int) []goes.EventData {
+	events := make([]goes.EventData, count)
+	for i := range events {
+		events[i] = goes.EventData{EventID: uuid.NewV4(), EventType: "TestEvent", IsJSON: true, Data: []byte("{}")}
+	}
+	return events
+}
+
+func TestImporter(t *testing.T) {
+	conn, server := startTestImportServer(t)
+	defer server.Close()
+	defer conn.Close()
+
+	var mutex sync.Mutex
+	var progress []goes.ImportProgress
+	importer := conn.NewImporter(goes.ImportSettings{
+		BatchSize:   3,
+		Concurrency: 2,
+		OnProgress: func(p goes.ImportProgress) {
+			mutex.Lock()
+			progress = append(progress, p)
+			mutex.Unlock()
+		},
+	})
+	for _, stream := range []string{"orders-1", "denied-1", "orders-2", "orders-3"} {
+		if err := importer.Import(stream, goes.ExpectedVersionNoStream, newTestImportEvents(10)); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+	failures := importer.Wait()
+
+	if len(failures) != 1 || failures[0].Stream != "denied-1" || failures[0].Err != goes.ErrAccessDenied {
+		t.Fatalf("Expected the import of %v to fail with %v got %+v", "denied-1", goes.ErrAccessDenied, failures)
+	}
+	expected := goes.ImportProgress{Streams: 4, FailedStreams: 1, Events: 30}
+	if importer.Progress() != expected {
+		t.Fatalf("Expected %+v got %+v", expected, importer.Progress())
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(progress) != 4 || progress[3] != expected {
+		t.Fatalf("Expected the progress to be reported for %v streams got %+v", 4, progress)
+	}
+}
+
+// BenchmarkImporter measures importing a million events spread over a thousand streams
+func BenchmarkImporter(b *testing.B) {
+	const (
+		streams         = 1000
+		eventsPerStream = 1000
+	)
+	conn, server := startTestImportServer(b)
+	defer server.Close()
+	defer conn.Close()
+	events := newTestImportEvents(eventsPerStream)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		importer := conn.NewImporter(goes.ImportSettings{})
+		for stream := 0; stream < streams; stream++ {
+			if err := importer.Import(uuid.NewV4().String(), goes.ExpectedVersionAny, events); err != nil {
+				b.Fatalf("Unexpected failure %+v", err)
+			}
+		}
+		if failures := importer.Wait(); len(failures) > 0 {
+			b.Fatalf("Unexpected failure %+v", failures[0])
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package goes
+
+import "log"
+
+// Logger is used by the connection to report on what it is doing. Set Configuration.Logger to route the output to your own logger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// stdLogger writes to the standard library logger and is used when no Logger has been configured
+type stdLogger struct{}
+
+func (stdLogger) Debugf(format string, args ...interface{}) {
+	log.Printf("[debug] "+format, args...)
+}
+
+func (stdLogger) Infof(format string, args ...interface{}) {
+	log.Printf("[info] "+format, args...)
+}
+
+func (stdLogger) Errorf(format string, args ...interface{}) {
+	log.Printf("[error] "+format, args...)
+}
@@ -0,0 +1,32 @@
+package goes
+
+// WithMaxBytes stops a read of a stream from returning more events once the data and metadata of the events it returns add
+// up to maxBytes. The event that reaches the budget is still returned, so a read returns at least one event, and the
+// NextEventNumber of the slice is the event after it so that paging continues where the read stopped. The read is made in
+// pages that ask the server for only as many events as are expected to fit in what is left of the budget, judged by the
+// largest event read so far, and no page is read once the budget is used up. Zero leaves the read unbounded.
+func WithMaxBytes(maxBytes int) OperationOption {
+	return func(options *operationOptions) {
+		options.maxBytes = maxBytes
+	}
+}
+
+// maxBytes returns the number of bytes a read may return, zero when it is unbounded
+func maxBytes(options []OperationOption) int {
+	resolved := operationOptions{}
+	for _, option := range options {
+		option(&resolved)
+	}
+	return resolved.maxBytes
+}
+
+// size returns the number of bytes of the data and metadata of the event and of the link
+func (evnt ResolvedEvent) size() int {
+	size := 0
+	for _, record := range []*RecordedEvent{evnt.Event, evnt.Link} {
+		if record != nil {
+			size += len(record.Data) + len(record.Metadata)
+		}
+	}
+	return size
+}
@@ -0,0 +1,65 @@
+package goes
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics records how the connection and its operations are doing, e.g. to export them to Prometheus.
+// Set Configuration.Metrics to record them, nothing is recorded and no time is measured when it is nil.
+// The methods are called from several goroutines at once and must not block.
+type Metrics interface {
+	// OperationSent is called when the package of an operation has been sent to the server
+	OperationSent(command Command)
+	// OperationCompleted is called when an operation received its response, such as writeEventsCompleted or notHandled,
+	// or failed with err before any response arrived, e.g. with ErrOperationTimeout. The duration is the time from sending
+	// the operation until it completed.
+	OperationCompleted(command Command, response Command, err error, duration time.Duration)
+	// InflightChanged is called with the number of operations that are waiting for a response whenever it changes
+	InflightChanged(inflight int)
+	// ConnectionStateChanged is called with the new state after every change of the connection state
+	ConnectionStateChanged(state ConnectionState)
+	// Reconnected is called after an attempt to re-establish a lost connection, err is nil when it succeeded
+	Reconnected(err error)
+	// HeartbeatReceived is called for every heartbeat request received from the server
+	HeartbeatReceived()
+	// HeartbeatTimedOut is called when no data was received from the server within the HeartbeatTimeout
+	HeartbeatTimedOut()
+}
+
+// operationStarted records an operation that is about to be sent and returns when it was started.
+// The zero time is returned when no Metrics are configured.
+func (connection *EventStoreConnection) operationStarted() time.Time {
+	inflight := atomic.AddInt64(&connection.inflightCount, 1)
+	metrics := connection.Config.Metrics
+	if metrics == nil {
+		return time.Time{}
+	}
+	metrics.InflightChanged(int(inflight))
+	return time.Now()
+}
+
+// operationCompleted records the outcome of an operation that was started at started
+func (connection *EventStoreConnection) operationCompleted(command Command, started time.Time, result TCPPackage, err error) {
+	inflight := atomic.AddInt64(&connection.inflightCount, -1)
+	metrics := connection.Config.Metrics
+	if metrics == nil {
+		return
+	}
+	metrics.InflightChanged(int(inflight))
+	var response Command
+	if err == nil {
+		response = result.Command
+	}
+	metrics.OperationCompleted(command, response, err, time.Since(started))
+}
+
+// reconnected records an attempt to re-establish a lost connection, err is nil when it succeeded
+func (connection *EventStoreConnection) reconnected(err error) {
+	if err == nil {
+		atomic.AddInt64(&connection.stats.reconnects, 1)
+	}
+	if connection.Config.Metrics != nil {
+		connection.Config.Metrics.Reconnected(err)
+	}
+}
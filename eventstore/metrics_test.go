@@ -0,0 +1,122 @@
+package goes_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+const heartbeatRequestCommand byte = 0x01
+
+type testOperation struct {
+	command  goes.Command
+	response goes.Command
+	err      error
+}
+
+// testMetrics keeps everything that was recorded
+type testMetrics struct {
+	mutex      sync.Mutex
+	sent       []goes.Command
+	completed  []testOperation
+	inflight   []int
+	states     []goes.ConnectionState
+	heartbeats int
+}
+
+func (metrics *testMetrics) OperationSent(command goes.Command) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.sent = append(metrics.sent, command)
+}
+
+func (metrics *testMetrics) OperationCompleted(command goes.Command, response goes.Command, err error, duration time.Duration) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.completed = append(metrics.completed, testOperation{command: command, response: response, err: err})
+}
+
+func (metrics *testMetrics) InflightChanged(inflight int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.inflight = append(metrics.inflight, inflight)
+}
+
+func (metrics *testMetrics) ConnectionStateChanged(state goes.ConnectionState) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.states = append(metrics.states, state)
+}
+
+func (metrics *testMetrics) Reconnected(err error) {}
+
+func (metrics *testMetrics) HeartbeatReceived() {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.heartbeats++
+}
+
+func (metrics *testMetrics) HeartbeatTimedOut() {}
+
+func TestMetrics_RecordsOperationsAndHeartbeats(t *testing.T) {
+	metrics := &testMetrics{}
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.Metrics = metrics
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		for {
+			pkg, err := readRawTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command != pingCommand {
+				continue
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       heartbeatRequestCommand,
+				CorrelationID: pkg.CorrelationID,
+			}))
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       pongCommand,
+				CorrelationID: pkg.CorrelationID,
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	if len(metrics.completed) != 1 {
+		t.Fatalf("Expected %v got %v", 1, len(metrics.completed))
+	}
+	operation := metrics.completed[0]
+	if operation.command != goes.Command(pingCommand) || operation.response != goes.Command(pongCommand) || operation.err != nil {
+		t.Fatalf("Expected a ping answered by a pong got %+v", operation)
+	}
+	if len(metrics.inflight) != 2 || metrics.inflight[0] != 1 || metrics.inflight[1] != 0 {
+		t.Fatalf("Expected %v got %v", []int{1, 0}, metrics.inflight)
+	}
+	if metrics.heartbeats != 1 {
+		t.Fatalf("Expected %v got %v", 1, metrics.heartbeats)
+	}
+	if len(metrics.states) == 0 || metrics.states[len(metrics.states)-1] != goes.ConnectionStateConnected {
+		t.Fatalf("Expected %v got %v", goes.ConnectionStateConnected, metrics.states)
+	}
+	pings := 0
+	for _, command := range metrics.sent {
+		if command == goes.Command(pingCommand) {
+			pings++
+		}
+	}
+	if pings != 1 {
+		t.Fatalf("Expected %v got %v", 1, pings)
+	}
+}
@@ -0,0 +1,54 @@
+package goes
+
+import (
+	"fmt"
+
+	"github.com/satori/go.uuid"
+)
+
+// queueLocked holds the package back when QueueWhileDisconnected is set and the connection is being re-established, or
+// packages queued before it have not been sent yet so that the packages are sent in the order they were submitted.
+// It reports whether the package was queued and fails with ErrConnectionClosed when the queue is full.
+// The connection's mutex must be held.
+func (connection *EventStoreConnection) queueLocked(pkg TCPPackage) (bool, error) {
+	if !connection.Config.QueueWhileDisconnected {
+		return false, nil
+	}
+	if connection.state != ConnectionStateReconnecting && len(connection.queue) == 0 {
+		return false, nil
+	}
+	if len(connection.queue) >= connection.Config.MaxQueueSize {
+		return false, ErrConnectionClosed
+	}
+	connection.queue = append(connection.queue, pkg)
+	return true, nil
+}
+
+// flushQueue sends the packages that were queued while the connection was being re-established in the order they were
+// queued. Packages whose operation gave up in the meantime are skipped.
+func (connection *EventStoreConnection) flushQueue() {
+	for {
+		connection.mutex.Lock()
+		if len(connection.queue) == 0 || connection.state != ConnectionStateConnected {
+			connection.mutex.Unlock()
+			return
+		}
+		pkg := connection.queue[0]
+		connection.queue[0] = TCPPackage{}
+		connection.queue = connection.queue[1:]
+		correlationID, _ := uuid.FromBytes(pkg.CorrelationID)
+		_, waiting := connection.requests[correlationID]
+		connection.mutex.Unlock()
+		if !waiting {
+			continue
+		}
+		err := pkg.write(connection)
+		if err != nil {
+			connection.reportError(fmt.Errorf("failed to send queued %s: %s", pkg.Command.String(), err.Error()))
+			continue
+		}
+		if connection.Config.Metrics != nil {
+			connection.Config.Metrics.OperationSent(pkg.Command)
+		}
+	}
+}
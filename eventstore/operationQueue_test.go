@@ -0,0 +1,165 @@
+package goes_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// newTestQueueConfiguration queues operations while disconnected and holds the reconnect until release is closed, or fails
+// it when reconnecting is false
+func newTestQueueConfiguration(release chan struct{}, reconnecting bool) *goes.Configuration {
+	var dials int32
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.ConnectTimeout = 0
+	config.QueueWhileDisconnected = true
+	config.Dialer = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		if atomic.AddInt32(&dials, 1) > 1 {
+			<-release
+			if !reconnecting {
+				return nil, goes.ErrConnectionLost
+			}
+		}
+		dialer := &net.Dialer{}
+		return dialer.DialContext(ctx, network, address)
+	}
+	return config
+}
+
+// dropTestConnection closes the first connection once the client identified itself
+func dropTestConnection(socket net.Conn) {
+	readRawTestPackage(socket)
+	socket.Close()
+}
+
+// waitForTestInflight waits until the number of operations waiting for a response reaches inflight
+func waitForTestInflight(t *testing.T, conn *goes.EventStoreConnection, inflight int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.Stats().Inflight != inflight {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v operations in flight got %v", inflight, conn.Stats().Inflight)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func writeTestEvent(conn *goes.EventStoreConnection, stream string) error {
+	_, err := conn.WriteEvents(stream, goes.ExpectedVersionAny, []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}})
+	return err
+}
+
+func TestQueueWhileDisconnected_SendsTheOperationsInOrderOnceReconnected(t *testing.T) {
+	const writes = 3
+	release := make(chan struct{})
+	streams := make(chan string, writes)
+	conn, listener := startTestServerWithHandlers(t, newTestQueueConfiguration(release, true), dropTestConnection,
+		func(socket net.Conn) {
+			for {
+				pkg, err := readTestPackage(socket)
+				if err != nil {
+					return
+				}
+				request := &protobuf.WriteEvents{}
+				proto.Unmarshal(pkg.Data, request)
+				streams <- request.GetEventStreamId()
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       writeEventsCompletedCommand,
+					CorrelationID: pkg.CorrelationID,
+					Data:          newTestWriteEventsCompleted(t),
+				}))
+			}
+		})
+	defer listener.Close()
+	defer conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.State() != goes.ConnectionStateReconnecting {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v got %v", goes.ConnectionStateReconnecting, conn.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	results := make(chan error, writes)
+	expected := make([]string, writes)
+	for i := range expected {
+		expected[i] = uuid.NewV4().String()
+		go func(stream string) {
+			results <- writeTestEvent(conn, stream)
+		}(expected[i])
+		waitForTestInflight(t, conn, i+1)
+	}
+	close(release)
+
+	for i := 0; i < writes; i++ {
+		if err := <-results; err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		if stream := <-streams; stream != expected[i] {
+			t.Fatalf("Expected %v got %v", expected[i], stream)
+		}
+	}
+}
+
+func TestQueueWhileDisconnected_WhenTheQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	config := newTestQueueConfiguration(release, true)
+	config.MaxQueueSize = 1
+	conn, listener := startTestServerWithHandlers(t, config, dropTestConnection, func(socket net.Conn) {
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+	defer close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.State() != goes.ConnectionStateReconnecting {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v got %v", goes.ConnectionStateReconnecting, conn.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	go writeTestEvent(conn, "queued")
+	waitForTestInflight(t, conn, 1)
+
+	if err := writeTestEvent(conn, "overflowing"); err != goes.ErrConnectionClosed {
+		t.Fatalf("Expected %v got %v", goes.ErrConnectionClosed, err)
+	}
+}
+
+func TestQueueWhileDisconnected_WhenTheConnectionCanNotBeRestored(t *testing.T) {
+	release := make(chan struct{})
+	conn, listener := startTestServerWithHandlers(t, newTestQueueConfiguration(release, false), dropTestConnection)
+	defer listener.Close()
+	defer conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.State() != goes.ConnectionStateReconnecting {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v got %v", goes.ConnectionStateReconnecting, conn.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	result := make(chan error, 1)
+	go func() {
+		result <- writeTestEvent(conn, "queued")
+	}()
+	waitForTestInflight(t, conn, 1)
+	close(release)
+
+	select {
+	case err := <-result:
+		if err != goes.ErrConnectionClosed {
+			t.Fatalf("Expected %v got %v", goes.ErrConnectionClosed, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the queued write to fail")
+	}
+}
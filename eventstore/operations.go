@@ -1,99 +1,245 @@
 package goes
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pgermishuys/goes/protobuf"
 	"github.com/satori/go.uuid"
 )
 
+// marshalToProtobufEvents marshals the events of the legacy API like the events of WriteEvents, the conversion fails to compile
+// when the fields of Event and EventData no longer match
 func marshalToProtobufEvents(evnts []Event) []*protobuf.NewEvent {
-	var events []*protobuf.NewEvent
+	events := make([]EventData, 0, len(evnts))
 	for _, evnt := range evnts {
-		dataContentType := int32(0)
-		if evnt.IsJSON == true {
-			dataContentType = 1
+		events = append(events, EventData(evnt))
+	}
+	return marshalEventData(events)
+}
+
+// retryDelay is the time given to a node that is not ready or too busy before an operation is sent again
+const retryDelay = 100 * time.Millisecond
+
+// performOperation sends the package and waits for the expected result. Operations that were not handled by the node, or
+// whose connection was lost before the response arrived, are sent again up to MaxOperationRetries times, after reconnecting
+// to the master when the node is not the master or once the lost connection has been re-established. An operation fails
+// with ErrNoMaster when the master it was redirected to can not be reached, and with an ErrRetriesExhausted that
+// unwraps to ErrNoMaster when it was still redirected on its last retry.
+func performOperation(ctx context.Context, conn *EventStoreConnection, pkg TCPPackage, expectedResult Command) (TCPPackage, error) {
+	return performRetriedOperation(ctx, conn, pkg, expectedResult, nil)
+}
+
+// performRetriedOperation is like performOperation, but it also passes every expected result to resultError. The
+// operation is sent again while resultError returns an error that IsRetryable, e.g. when the server timed out writing
+// the events. Any other error is returned as is. Every reason to send the operation again draws on the same
+// MaxOperationRetries, so an operation is sent at most MaxOperationRetries+1 times.
+func performRetriedOperation(ctx context.Context, conn *EventStoreConnection, pkg TCPPackage, expectedResult Command, resultError func(result TCPPackage) error) (TCPPackage, error) {
+	if conn.Config.Tracer != nil {
+		correlationID, _ := uuid.FromBytes(pkg.CorrelationID)
+		spanFromContext(ctx).SetAttribute(SpanAttributeCorrelationID, correlationID.String())
+	}
+	for retry := 0; ; retry++ {
+		resultChan := make(chan TCPPackage, 1)
+		result, err := sendAndWait(ctx, conn, pkg, resultChan)
+		correlationID, _ := uuid.FromBytes(pkg.CorrelationID)
+		conn.removeRequest(correlationID)
+		if err == ErrConnectionLost {
+			if retry >= conn.Config.MaxOperationRetries {
+				return result, &ErrRetriesExhausted{Retries: retry, Err: err}
+			}
+			conn.logger().Debugf("connection lost while waiting for %s, retrying", pkg.Command.String())
+			err = awaitReconnected(ctx, conn)
+			if err != nil {
+				return result, err
+			}
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if result.Command == notHandled {
+			if retry >= conn.Config.MaxOperationRetries {
+				return result, &ErrRetriesExhausted{Retries: retry, Err: notHandledError(result)}
+			}
+			err = handleNotHandled(ctx, conn, result)
+			if err != nil {
+				return result, err
+			}
+			continue
+		}
+		err = responseError(result, expectedResult)
+		if err != nil || resultError == nil {
+			return result, err
+		}
+		err = resultError(result)
+		if !IsRetryable(err) {
+			return result, err
+		}
+		if retry >= conn.Config.MaxOperationRetries {
+			return result, &ErrRetriesExhausted{Retries: retry, Err: err}
+		}
+		conn.logger().Debugf("%s failed: %s, retrying", pkg.Command.String(), err.Error())
+	}
+}
+
+// responseError returns the error carried by a response that is not the expected result, or nil when it is
+func responseError(result TCPPackage, expectedResult Command) error {
+	switch result.Command {
+	case expectedResult:
+		return nil
+	case notAuthenticated:
+		return ErrNotAuthenticated
+	case badRequest:
+		return fmt.Errorf("%s: %s", result.Command.String(), string(result.Data))
+	}
+	return errors.New(result.Command.String())
+}
+
+// handleNotHandled prepares for an operation that was not handled by the node to be sent again
+func handleNotHandled(ctx context.Context, conn *EventStoreConnection, result TCPPackage) error {
+	message := &protobuf.NotHandled{}
+	err := proto.Unmarshal(result.Data, message)
+	if err != nil {
+		return err
+	}
+	if message.GetReason() == protobuf.NotHandled_NotMaster {
+		master := &protobuf.NotHandled_MasterInfo{}
+		err = proto.Unmarshal(message.GetAdditionalInfo(), master)
+		if err != nil {
+			return err
 		}
-		events = append(events,
-			&protobuf.NewEvent{
-				EventId:             EncodeNetUUID(evnt.EventID.Bytes()),
-				EventType:           proto.String(evnt.EventType),
-				DataContentType:     proto.Int32(dataContentType),
-				MetadataContentType: proto.Int32(0),
-				Data:                evnt.Data,
-				Metadata:            evnt.Metadata,
-			},
-		)
-	}
-	return events
+		err = reconnectToMaster(ctx, conn, master)
+		if err != nil && ctx.Err() == nil {
+			conn.logger().Errorf("failed to reach the master at %s:%v: %s", master.GetExternalTcpAddress(), master.GetExternalTcpPort(), err.Error())
+			return ErrNoMaster
+		}
+		return err
+	}
+	conn.logger().Debugf("operation not handled: %s, retrying", message.GetReason().String())
+	select {
+	case <-time.After(retryDelay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func performOperation(conn *EventStoreConnection, pkg TCPPackage, expectedResult Command) (TCPPackage, error) {
-	resultChan := make(chan TCPPackage)
-	sendPackage(pkg, conn, resultChan)
-	result := <-resultChan
-	if result.Command != expectedResult {
-		return result, errors.New(result.Command.String())
+// notHandledError returns the error an operation that was not handled on its last retry fails with
+func notHandledError(result TCPPackage) error {
+	message := &protobuf.NotHandled{}
+	if proto.Unmarshal(result.Data, message) == nil && message.GetReason() == protobuf.NotHandled_NotMaster {
+		return ErrNoMaster
 	}
-	return result, nil
+	return errors.New(result.Command.String())
 }
 
-func shouldRetryOperation(operationResult *protobuf.OperationResult) (bool, error) {
-	if *operationResult == protobuf.OperationResult_AccessDenied ||
-		*operationResult == protobuf.OperationResult_WrongExpectedVersion {
-		return false, errors.New(operationResult.String())
+// awaitReconnected waits until the lost connection has been re-established. It fails with ErrConnectionClosed when the
+// connection is closed instead, e.g. when it could not be re-established within MaxReconnects.
+func awaitReconnected(ctx context.Context, conn *EventStoreConnection) error {
+	return conn.waitForConnection(ctx, true)
+}
+
+// sendAndWait sends the package and waits for the first response on the result channel. The request is
+// deregistered if the package could not be sent, the context is cancelled or the OperationTimeout expires before a
+// response arrives. A late response is then dropped by the socket reader as the request is no longer known.
+// It fails with ErrConnectionClosed when the connection is closed while waiting. The request counts towards MaxInflight until it returns.
+func sendAndWait(ctx context.Context, conn *EventStoreConnection, pkg TCPPackage, resultChan chan TCPPackage) (result TCPPackage, err error) {
+	if err := conn.acquireInflight(ctx); err != nil {
+		return TCPPackage{}, err
+	}
+	defer conn.releaseInflight()
+	started := conn.operationStarted()
+	defer func() {
+		conn.operationCompleted(pkg.Command, started, result, err)
+	}()
+	correlationID, _ := uuid.FromBytes(pkg.CorrelationID)
+	closed := conn.closed()
+	err = sendPackage(pkg, conn, resultChan)
+	if err != nil {
+		conn.removeRequest(correlationID)
+		return TCPPackage{}, err
+	}
+	var timeout <-chan time.Time
+	if conn.Config.OperationTimeout > 0 {
+		timer := time.NewTimer(time.Duration(conn.Config.OperationTimeout) * time.Millisecond)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case result, ok := <-resultChan:
+		if !ok {
+			return TCPPackage{}, ErrConnectionLost
+		}
+		return result, nil
+	case <-ctx.Done():
+		conn.removeRequest(correlationID)
+		return TCPPackage{}, ctx.Err()
+	case <-timeout:
+		conn.removeRequest(correlationID)
+		return TCPPackage{}, ErrOperationTimeout
+	case <-closed:
+		return TCPPackage{}, ErrConnectionClosed
 	}
-	if *operationResult == protobuf.OperationResult_CommitTimeout ||
-		*operationResult == protobuf.OperationResult_PrepareTimeout ||
-		*operationResult == protobuf.OperationResult_ForwardTimeout {
-		return true, nil
+}
+
+// legacyResultError returns the error the result of a legacy write carries, the timeouts are retried by
+// performRetriedOperation
+func legacyResultError(operationResult protobuf.OperationResult) error {
+	if operationResult == protobuf.OperationResult_WrongExpectedVersion {
+		return errors.New(operationResult.String())
 	}
-	return false, nil
+	return operationResultError(operationResult)
 }
 
 // AppendToStream appends an event to the stream
 func AppendToStream(conn *EventStoreConnection, streamID string, expectedVersion int32, evnts []Event) (protobuf.WriteEventsCompleted, error) {
+	return AppendToStreamWithContext(context.Background(), conn, streamID, expectedVersion, evnts)
+}
+
+// AppendToStreamWithContext is like AppendToStream but gives up when ctx is cancelled
+func AppendToStreamWithContext(ctx context.Context, conn *EventStoreConnection, streamID string, expectedVersion int32, evnts []Event) (protobuf.WriteEventsCompleted, error) {
 	events := marshalToProtobufEvents(evnts)
 	writeEventsData := &protobuf.WriteEvents{
 		EventStreamId:   proto.String(streamID),
 		ExpectedVersion: proto.Int32(expectedVersion),
 		Events:          events,
-		RequireMaster:   proto.Bool(true),
+		RequireMaster:   proto.Bool(conn.Config.RequireMaster),
 	}
 
 	data, err := proto.Marshal(writeEventsData)
 	if err != nil {
-		log.Printf("[error] marshaling error: %s", err)
+		conn.logger().Errorf("marshaling error: %s", err)
 		return protobuf.WriteEventsCompleted{}, err
 	}
 
 	pkg, err := newPackage(writeEvents, data, uuid.NewV4().Bytes(), conn.Config.Login, conn.Config.Password)
 	if err != nil {
-		log.Printf("[error] failed to create new write events package")
+		conn.logger().Errorf("failed to create new write events package")
 		return protobuf.WriteEventsCompleted{}, err
 	}
 
-	for i := 0; i < conn.Config.MaxOperationRetries; i++ {
-		resultPackage, err := performOperation(conn, pkg, writeEventsCompleted)
-		if err != nil {
-			return protobuf.WriteEventsCompleted{}, err
+	message := &protobuf.WriteEventsCompleted{}
+	_, err = performRetriedOperation(ctx, conn, pkg, writeEventsCompleted, func(result TCPPackage) error {
+		if err := proto.Unmarshal(result.Data, message); err != nil {
+			conn.logger().Errorf("unmarshaling error: %s", err)
+			return err
 		}
-		message := &protobuf.WriteEventsCompleted{}
-		proto.Unmarshal(resultPackage.Data, message)
-
-		shouldRetry, err := shouldRetryOperation(message.Result)
-		if err != nil || !shouldRetry {
-			return *message, err
-		}
-	}
-
-	return protobuf.WriteEventsCompleted{}, errors.New("Retry limit reached")
+		return legacyResultError(message.GetResult())
+	})
+	return *message, err
 }
 
 // ReadSingleEvent reads a single event from a stream
 func ReadSingleEvent(conn *EventStoreConnection, streamID string, eventNumber int32, resolveLinkTos bool, requireMaster bool) (protobuf.ReadEventCompleted, error) {
+	return ReadSingleEventWithContext(context.Background(), conn, streamID, eventNumber, resolveLinkTos, requireMaster)
+}
+
+// ReadSingleEventWithContext is like ReadSingleEvent but gives up when ctx is cancelled
+func ReadSingleEventWithContext(ctx context.Context, conn *EventStoreConnection, streamID string, eventNumber int32, resolveLinkTos bool, requireMaster bool) (protobuf.ReadEventCompleted, error) {
 	readEventsData := &protobuf.ReadEvent{
 		EventStreamId:  proto.String(streamID),
 		EventNumber:    proto.Int32(eventNumber),
@@ -102,20 +248,25 @@ func ReadSingleEvent(conn *EventStoreConnection, streamID string, eventNumber in
 	}
 	data, err := proto.Marshal(readEventsData)
 	if err != nil {
-		log.Fatal("marshaling error: ", err)
+		conn.logger().Errorf("marshaling error: %s", err)
+		return protobuf.ReadEventCompleted{}, err
 	}
 
 	pkg, err := newPackage(readEvent, data, uuid.NewV4().Bytes(), conn.Config.Login, conn.Config.Password)
 	if err != nil {
-		log.Printf("[error] failed to create new read event package")
+		conn.logger().Errorf("failed to create new read event package")
 	}
 
-	resultPackage, err := performOperation(conn, pkg, readEventCompleted)
+	resultPackage, err := performOperation(ctx, conn, pkg, readEventCompleted)
 	if err != nil {
 		return protobuf.ReadEventCompleted{}, err
 	}
 	message := &protobuf.ReadEventCompleted{}
-	proto.Unmarshal(resultPackage.Data, message)
+	err = proto.Unmarshal(resultPackage.Data, message)
+	if err != nil {
+		conn.logger().Errorf("unmarshaling error: %s", err)
+		return protobuf.ReadEventCompleted{}, err
+	}
 
 	if *message.Result == protobuf.ReadEventCompleted_AccessDenied ||
 		*message.Result == protobuf.ReadEventCompleted_Error {
@@ -131,6 +282,11 @@ func ReadSingleEvent(conn *EventStoreConnection, streamID string, eventNumber in
 
 // DeleteStream will delete the stream
 func DeleteStream(conn *EventStoreConnection, streamID string, expectedVersion int32, requireMaster bool, hardDelete bool) (protobuf.DeleteStreamCompleted, error) {
+	return DeleteStreamWithContext(context.Background(), conn, streamID, expectedVersion, requireMaster, hardDelete)
+}
+
+// DeleteStreamWithContext is like DeleteStream but gives up when ctx is cancelled
+func DeleteStreamWithContext(ctx context.Context, conn *EventStoreConnection, streamID string, expectedVersion int32, requireMaster bool, hardDelete bool) (protobuf.DeleteStreamCompleted, error) {
 	deleteStreamData := &protobuf.DeleteStream{
 		EventStreamId:   proto.String(streamID),
 		ExpectedVersion: proto.Int32(expectedVersion),
@@ -139,34 +295,34 @@ func DeleteStream(conn *EventStoreConnection, streamID string, expectedVersion i
 	}
 	data, err := proto.Marshal(deleteStreamData)
 	if err != nil {
-		log.Fatal("marshaling error: ", err)
+		conn.logger().Errorf("marshaling error: %s", err)
+		return protobuf.DeleteStreamCompleted{}, err
 	}
 
-	log.Printf("[info] Deleting Stream: %+v\n", deleteStreamData)
+	conn.logger().Debugf("Deleting Stream: %+v", deleteStreamData)
 	pkg, err := newPackage(deleteStream, data, uuid.NewV4().Bytes(), conn.Config.Login, conn.Config.Password)
 	if err != nil {
-		log.Printf("[error] failed to create new delete stream package")
+		conn.logger().Errorf("failed to create new delete stream package")
 	}
 
-	for i := 0; i < conn.Config.MaxOperationRetries; i++ {
-		resultPackage, err := performOperation(conn, pkg, deleteStreamCompleted)
-		if err != nil {
-			return protobuf.DeleteStreamCompleted{}, err
-		}
-		message := &protobuf.DeleteStreamCompleted{}
-		proto.Unmarshal(resultPackage.Data, message)
-
-		shouldRetry, err := shouldRetryOperation(message.Result)
-		if err != nil || !shouldRetry {
-			return *message, err
+	message := &protobuf.DeleteStreamCompleted{}
+	_, err = performRetriedOperation(ctx, conn, pkg, deleteStreamCompleted, func(result TCPPackage) error {
+		if err := proto.Unmarshal(result.Data, message); err != nil {
+			conn.logger().Errorf("unmarshaling error: %s", err)
+			return err
 		}
-	}
-
-	return protobuf.DeleteStreamCompleted{}, errors.New("Retry limit reached")
+		return legacyResultError(message.GetResult())
+	})
+	return *message, err
 }
 
 // ReadStreamEventsForward will read n number of events from the stream forward. The read includes the stream at the from position.
 func ReadStreamEventsForward(conn *EventStoreConnection, streamID string, from int32, maxCount int32, resolveLinkTos bool, requireMaster bool) (protobuf.ReadStreamEventsCompleted, error) {
+	return ReadStreamEventsForwardWithContext(context.Background(), conn, streamID, from, maxCount, resolveLinkTos, requireMaster)
+}
+
+// ReadStreamEventsForwardWithContext is like ReadStreamEventsForward but gives up when ctx is cancelled
+func ReadStreamEventsForwardWithContext(ctx context.Context, conn *EventStoreConnection, streamID string, from int32, maxCount int32, resolveLinkTos bool, requireMaster bool) (protobuf.ReadStreamEventsCompleted, error) {
 	readStreamEventsForwardData := &protobuf.ReadStreamEvents{
 		EventStreamId:   proto.String(streamID),
 		FromEventNumber: proto.Int32(from),
@@ -176,21 +332,26 @@ func ReadStreamEventsForward(conn *EventStoreConnection, streamID string, from i
 	}
 	data, err := proto.Marshal(readStreamEventsForwardData)
 	if err != nil {
-		log.Fatal("marshaling error: ", err)
+		conn.logger().Errorf("marshaling error: %s", err)
+		return protobuf.ReadStreamEventsCompleted{}, err
 	}
 
-	log.Printf("[info] Read Stream Forward: %+v\n", readStreamEventsForwardData)
+	conn.logger().Debugf("Read Stream Forward: %+v", readStreamEventsForwardData)
 	pkg, err := newPackage(readStreamEventsForward, data, uuid.NewV4().Bytes(), conn.Config.Login, conn.Config.Password)
 	if err != nil {
-		log.Println("[error] failed to create new read events forward stream package")
+		conn.logger().Errorf("failed to create new read events forward stream package")
 	}
 
-	resultPackage, err := performOperation(conn, pkg, readStreamEventsForwardCompleted)
+	resultPackage, err := performOperation(ctx, conn, pkg, readStreamEventsForwardCompleted)
 	if err != nil {
 		return protobuf.ReadStreamEventsCompleted{}, err
 	}
 	message := &protobuf.ReadStreamEventsCompleted{}
-	proto.Unmarshal(resultPackage.Data, message)
+	err = proto.Unmarshal(resultPackage.Data, message)
+	if err != nil {
+		conn.logger().Errorf("unmarshaling error: %s", err)
+		return protobuf.ReadStreamEventsCompleted{}, err
+	}
 
 	if *message.Result == protobuf.ReadStreamEventsCompleted_AccessDenied ||
 		*message.Result == protobuf.ReadStreamEventsCompleted_Error {
@@ -211,6 +372,11 @@ func ReadStreamEventsForward(conn *EventStoreConnection, streamID string, from i
 
 // ReadStreamEventsBackward will read n number of events from the stream backward.
 func ReadStreamEventsBackward(conn *EventStoreConnection, streamID string, from int32, maxCount int32, resolveLinkTos bool, requireMaster bool) (protobuf.ReadStreamEventsCompleted, error) {
+	return ReadStreamEventsBackwardWithContext(context.Background(), conn, streamID, from, maxCount, resolveLinkTos, requireMaster)
+}
+
+// ReadStreamEventsBackwardWithContext is like ReadStreamEventsBackward but gives up when ctx is cancelled
+func ReadStreamEventsBackwardWithContext(ctx context.Context, conn *EventStoreConnection, streamID string, from int32, maxCount int32, resolveLinkTos bool, requireMaster bool) (protobuf.ReadStreamEventsCompleted, error) {
 	readStreamEventsBackwardData := &protobuf.ReadStreamEvents{
 		EventStreamId:   proto.String(streamID),
 		FromEventNumber: proto.Int32(from),
@@ -220,21 +386,26 @@ func ReadStreamEventsBackward(conn *EventStoreConnection, streamID string, from
 	}
 	data, err := proto.Marshal(readStreamEventsBackwardData)
 	if err != nil {
-		log.Fatal("marshaling error: ", err)
+		conn.logger().Errorf("marshaling error: %s", err)
+		return protobuf.ReadStreamEventsCompleted{}, err
 	}
 
-	log.Printf("[info] Read Stream Backward: %+v\n", readStreamEventsBackwardData)
+	conn.logger().Debugf("Read Stream Backward: %+v", readStreamEventsBackwardData)
 	pkg, err := newPackage(readStreamEventsBackward, data, uuid.NewV4().Bytes(), conn.Config.Login, conn.Config.Password)
 	if err != nil {
-		log.Printf("[error] failed to create new read events backward stream package")
+		conn.logger().Errorf("failed to create new read events backward stream package")
 	}
 
-	resultPackage, err := performOperation(conn, pkg, readStreamEventsBackwardCompleted)
+	resultPackage, err := performOperation(ctx, conn, pkg, readStreamEventsBackwardCompleted)
 	if err != nil {
 		return protobuf.ReadStreamEventsCompleted{}, err
 	}
 	message := &protobuf.ReadStreamEventsCompleted{}
-	proto.Unmarshal(resultPackage.Data, message)
+	err = proto.Unmarshal(resultPackage.Data, message)
+	if err != nil {
+		conn.logger().Errorf("unmarshaling error: %s", err)
+		return protobuf.ReadStreamEventsCompleted{}, err
+	}
 
 	if *message.Result == protobuf.ReadStreamEventsCompleted_AccessDenied ||
 		*message.Result == protobuf.ReadStreamEventsCompleted_Error {
@@ -258,36 +429,76 @@ type dropped func(*protobuf.SubscriptionDropped)
 
 //SubscribeToStream registers a subscription with the stream
 func SubscribeToStream(conn *EventStoreConnection, streamID string, resolveLinkTos bool, eventAppeared eventAppeared, dropped dropped) (*Subscription, error) {
+	return SubscribeToStreamWithContext(context.Background(), conn, streamID, resolveLinkTos, eventAppeared, dropped)
+}
+
+// SubscribeToStreamWithContext is like SubscribeToStream but gives up when ctx is cancelled
+func SubscribeToStreamWithContext(ctx context.Context, conn *EventStoreConnection, streamID string, resolveLinkTos bool, eventAppeared eventAppeared, dropped dropped) (*Subscription, error) {
+	return subscribe(ctx, conn, streamID, resolveLinkTos, eventAppeared, dropped, nil, nil, conn.credentials(nil))
+}
+
+// subscribe subscribes to the stream, confirmed is called each time the subscription is confirmed again after a reconnect
+func subscribe(ctx context.Context, conn *EventStoreConnection, streamID string, resolveLinkTos bool, eventAppeared eventAppeared, dropped dropped, confirmed func(), onDropped func(SubscriptionDropReason, error), credentials UserCredentials) (*Subscription, error) {
 	subscriptionData := &protobuf.SubscribeToStream{
 		EventStreamId:  proto.String(streamID),
 		ResolveLinkTos: proto.Bool(resolveLinkTos),
 	}
-	data, err := proto.Marshal(subscriptionData)
+	subscription := newSubscription(conn, uuid.NewV4(), make(chan TCPPackage, conn.subscriptionBufferSize()), eventAppeared, dropped)
+	subscription.confirmed = confirmed
+	subscription.onDropped = onDropped
+	subscription.credentials = credentials
+	err := startSubscription(ctx, subscription, subscribeToStream, streamID, subscriptionData)
 	if err != nil {
-		log.Fatal("marshaling error: ", err)
+		return nil, err
 	}
+	return subscription, nil
+}
 
-	log.Printf("[info] Subscription Data: %+v\n", subscriptionData)
-	correlationID := uuid.NewV4()
-	pkg, err := newPackage(subscribeToStream, data, correlationID.Bytes(), conn.Config.Login, conn.Config.Password)
+// startSubscription sends the subscribe command and starts delivering to the subscription once the server confirmed it
+func startSubscription(ctx context.Context, subscription *Subscription, command Command, stream string, request proto.Message) (err error) {
+	conn := subscription.Connection
+	subscription.stream = stream
+	ctx, span := conn.startSpan(ctx, command, stream)
+	span.SetAttribute(SpanAttributeCorrelationID, subscription.CorrelationID.String())
+	defer func() {
+		endSpan(span, err)
+	}()
+	data, err := proto.Marshal(request)
 	if err != nil {
-		log.Printf("[error] failed to subscribe to stream package")
+		conn.logger().Errorf("marshaling error: %s", err)
+		return err
 	}
-	if !conn.connected {
-		return nil, errors.New("the connection is closed")
+
+	conn.logger().Debugf("Subscription Data: %+v", request)
+	pkg, err := newPackage(command, data, subscription.CorrelationID.Bytes(), subscription.credentials.Login, subscription.credentials.Password)
+	if err != nil {
+		conn.logger().Errorf("failed to subscribe to stream package")
+	}
+	if !conn.isConnected() {
+		return ErrConnectionClosed
+	}
+	result, err := sendAndWait(ctx, conn, pkg, subscription.Channel)
+	if err != nil {
+		return err
+	}
+	if err := responseError(result, subscriptionConfirmation); err != nil {
+		conn.removeRequest(subscription.CorrelationID)
+		return err
 	}
-	resultChan := make(chan TCPPackage)
-	sendPackage(pkg, conn, resultChan)
-	result := <-resultChan
 	subscriptionConfirmation := &protobuf.SubscriptionConfirmation{}
-	proto.Unmarshal(result.Data, subscriptionConfirmation)
-	log.Printf("[info] SubscribeToStream: %+v\n", subscriptionConfirmation)
-	subscription, err := NewSubscription(conn, correlationID, resultChan, eventAppeared, dropped)
+	err = proto.Unmarshal(result.Data, subscriptionConfirmation)
 	if err != nil {
-		log.Printf("[error] Failed to create new subscription: %+v\n", err)
+		conn.logger().Errorf("unmarshaling error: %s", err)
+		return err
 	}
-	conn.subscriptions[correlationID] = subscription
-	return subscription, nil
+	conn.logger().Debugf("SubscribeToStream: %+v", subscriptionConfirmation)
+	subscription.subscribeCommand = command
+	subscription.subscribeData = data
+	if !conn.registerSubscription(subscription) {
+		return ErrConnectionLost
+	}
+	go subscription.Start()
+	return nil
 }
 
 // PersistentSubscriptionSettings describes the settings for the persistent subscription
@@ -305,7 +516,8 @@ type PersistentSubscriptionSettings struct {
 	CheckpointMaxCount         int
 	CheckpointMinCount         int
 	SubscriberMaxCount         int
-	NamedConsumerStrategy      string
+	// NamedConsumerStrategy is one of the ConsumerStrategy constants
+	NamedConsumerStrategy string
 }
 
 // NewPersistentSubscriptionSettings creates new subscription settings
@@ -323,12 +535,20 @@ func NewPersistentSubscriptionSettings() *PersistentSubscriptionSettings {
 		CheckpointMinCount:         10,
 		CheckpointMaxCount:         1000,
 		SubscriberMaxCount:         0,
-		NamedConsumerStrategy:      "RoundRobin",
+		NamedConsumerStrategy:      ConsumerStrategyRoundRobin,
 	}
 }
 
 // CreatePersistentSubscription creates a new persistent subscription
 func CreatePersistentSubscription(conn *EventStoreConnection, streamID string, groupName string, settings PersistentSubscriptionSettings) (protobuf.CreatePersistentSubscriptionCompleted, error) {
+	return CreatePersistentSubscriptionWithContext(context.Background(), conn, streamID, groupName, settings)
+}
+
+// CreatePersistentSubscriptionWithContext is like CreatePersistentSubscription but gives up when ctx is cancelled
+func CreatePersistentSubscriptionWithContext(ctx context.Context, conn *EventStoreConnection, streamID string, groupName string, settings PersistentSubscriptionSettings) (protobuf.CreatePersistentSubscriptionCompleted, error) {
+	if err := validateConsumerStrategy(settings.NamedConsumerStrategy); err != nil {
+		return protobuf.CreatePersistentSubscriptionCompleted{}, err
+	}
 	subscriptionData := &protobuf.CreatePersistentSubscription{
 		SubscriptionGroupName:      proto.String(groupName),
 		EventStreamId:              proto.String(streamID),
@@ -350,22 +570,26 @@ func CreatePersistentSubscription(conn *EventStoreConnection, streamID string, g
 
 	data, err := proto.Marshal(subscriptionData)
 	if err != nil {
-		log.Printf("[error] marshaling error: %s", err)
+		conn.logger().Errorf("marshaling error: %s", err)
 		return protobuf.CreatePersistentSubscriptionCompleted{}, err
 	}
 
 	pkg, err := newPackage(createPersistentSubscription, data, uuid.NewV4().Bytes(), conn.Config.Login, conn.Config.Password)
 	if err != nil {
-		log.Printf("[error] failed to create new create persistent subscription package")
+		conn.logger().Errorf("failed to create new create persistent subscription package")
 		return protobuf.CreatePersistentSubscriptionCompleted{}, err
 	}
 
-	resultPackage, err := performOperation(conn, pkg, createPersistentSubscriptionCompleted)
+	resultPackage, err := performOperation(ctx, conn, pkg, createPersistentSubscriptionCompleted)
 	if err != nil {
 		return protobuf.CreatePersistentSubscriptionCompleted{}, err
 	}
 	message := &protobuf.CreatePersistentSubscriptionCompleted{}
-	proto.Unmarshal(resultPackage.Data, message)
+	err = proto.Unmarshal(resultPackage.Data, message)
+	if err != nil {
+		conn.logger().Errorf("unmarshaling error: %s", err)
+		return protobuf.CreatePersistentSubscriptionCompleted{}, err
+	}
 
 	if *message.Result == protobuf.CreatePersistentSubscriptionCompleted_AccessDenied ||
 		*message.Result == protobuf.CreatePersistentSubscriptionCompleted_Fail ||
@@ -377,7 +601,21 @@ func CreatePersistentSubscription(conn *EventStoreConnection, streamID string, g
 }
 
 // ConnectToPersistentSubscription connects to a persistent subscription
-func ConnectToPersistentSubscription(conn *EventStoreConnection, stream string, groupName string, eventAppeared eventAppeared, dropped dropped, bufferSize int, autoAck bool) (*Subscription, error) {
+func ConnectToPersistentSubscription(conn *EventStoreConnection, stream string, groupName string, eventAppeared eventAppeared, dropped dropped, bufferSize int, autoAck bool) (*PersistentSubscription, error) {
+	return ConnectToPersistentSubscriptionWithContext(context.Background(), conn, stream, groupName, eventAppeared, dropped, bufferSize, autoAck)
+}
+
+// ConnectToPersistentSubscriptionWithContext is like ConnectToPersistentSubscription but gives up when ctx is cancelled
+func ConnectToPersistentSubscriptionWithContext(ctx context.Context, conn *EventStoreConnection, stream string, groupName string, eventAppeared eventAppeared, dropped dropped, bufferSize int, autoAck bool) (*PersistentSubscription, error) {
+	return connectPersistentSubscription(ctx, conn, stream, groupName, bufferSize, conn.credentials(nil), func(subscription *Subscription) {
+		subscription.EventAppeared = eventAppeared
+		subscription.Dropped = dropped
+		subscription.autoAck = autoAck
+	})
+}
+
+// connectPersistentSubscription connects to the group, configure sets up the subscription before events are delivered to it
+func connectPersistentSubscription(ctx context.Context, conn *EventStoreConnection, stream string, groupName string, bufferSize int, credentials UserCredentials, configure func(*Subscription)) (*PersistentSubscription, error) {
 	subscriptionData := &protobuf.ConnectToPersistentSubscription{
 		SubscriptionId:          proto.String(groupName),
 		EventStreamId:           proto.String(stream),
@@ -386,31 +624,51 @@ func ConnectToPersistentSubscription(conn *EventStoreConnection, stream string,
 
 	data, err := proto.Marshal(subscriptionData)
 	if err != nil {
-		log.Printf("[error] marshalling error: %s", err)
+		conn.logger().Errorf("marshalling error: %s", err)
 		return nil, err
 	}
 
 	correlationID := uuid.NewV4()
-	pkg, err := newPackage(connectToPersistentSubscription, data, correlationID.Bytes(), conn.Config.Login, conn.Config.Password)
+	pkg, err := newPackage(connectToPersistentSubscription, data, correlationID.Bytes(), credentials.Login, credentials.Password)
 	if err != nil {
-		log.Printf("[error] failed to create new connect to persistent subscription package")
+		conn.logger().Errorf("failed to create new connect to persistent subscription package")
 		return nil, err
 	}
 
-	if !conn.connected {
-		return nil, errors.New("the connection is closed")
+	if !conn.isConnected() {
+		return nil, ErrConnectionClosed
 	}
 
-	resultChan := make(chan TCPPackage)
-	sendPackage(pkg, conn, resultChan)
-	result := <-resultChan
+	resultChan := make(chan TCPPackage, conn.subscriptionBufferSize())
+	result, err := sendAndWait(ctx, conn, pkg, resultChan)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(result, persistentSubscriptionConfirmation); err != nil {
+		conn.removeRequest(correlationID)
+		return nil, err
+	}
 	subscriptionConfirmation := &protobuf.PersistentSubscriptionConfirmation{}
-	proto.Unmarshal(result.Data, subscriptionConfirmation)
-	log.Printf("[info] ConnectToPersistentSubscription: %+v\n", subscriptionConfirmation)
-	subscription, err := NewSubscription(conn, correlationID, resultChan, eventAppeared, dropped)
+	err = proto.Unmarshal(result.Data, subscriptionConfirmation)
 	if err != nil {
-		log.Printf("[error] failed to connect to persistent subscription %v\n", err)
+		conn.logger().Errorf("unmarshaling error: %s", err)
 		return nil, err
 	}
-	return subscription, nil
+	conn.logger().Debugf("ConnectToPersistentSubscription: %+v", subscriptionConfirmation)
+	subscription := newSubscription(conn, correlationID, resultChan, nil, nil)
+	subscription.subscribeCommand = connectToPersistentSubscription
+	subscription.subscribeData = data
+	subscription.subscriptionID = subscriptionConfirmation.GetSubscriptionId()
+	subscription.credentials = credentials
+	configure(subscription)
+	subscription.stream = stream
+	subscription.subscriptionType = SubscriptionTypePersistent
+	if !conn.registerSubscription(subscription) {
+		return nil, ErrConnectionLost
+	}
+	go subscription.Start()
+	return &PersistentSubscription{
+		Subscription:   subscription,
+		SubscriptionID: subscriptionConfirmation.GetSubscriptionId(),
+	}, nil
 }
@@ -3,7 +3,9 @@ package goes_test
 import (
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
 	"github.com/pgermishuys/goes/protobuf"
 	"github.com/satori/go.uuid"
 )
@@ -125,3 +127,24 @@ func TestAppendToSystemStream_WithIncorrectCredentials(t *testing.T) {
 		t.Fatalf("Expected %s got %s", expectedError, err.Error())
 	}
 }
+
+func TestAppendToStream_MarshalsTheEventsLikeWriteEvents(t *testing.T) {
+	evnt := createTestEvent()
+	evnt.IsJSONMetadata = true
+	requests := make(chan *protobuf.WriteEvents, 2)
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	defer server.Close()
+	defer conn.Close()
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(requests))
+
+	if _, err := goes.AppendToStream(conn, "testStream", -2, []goes.Event{evnt}); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{goes.EventData(evnt)}); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	legacy, request := <-requests, <-requests
+	if !proto.Equal(legacy, request) {
+		t.Fatalf("Expected %+v got %+v", request, legacy)
+	}
+}
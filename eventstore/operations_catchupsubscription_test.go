@@ -1,6 +1,228 @@
 package goes_test
 
-import "testing"
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	subscribeToStreamCommand                byte = 0xC0
+	subscriptionConfirmationCommand         byte = 0xC1
+	streamEventAppearedCommand              byte = 0xC2
+	unsubscribeFromStreamCommand            byte = 0xC3
+	subscriptionDroppedCommand              byte = 0xC4
+	readStreamEventsForwardCompletedCommand byte = 0xB3
+)
+
+func newTestEventRecord(stream string, eventNumber int32) *protobuf.EventRecord {
+	return &protobuf.EventRecord{
+		EventStreamId:       proto.String(stream),
+		EventNumber:         proto.Int32(eventNumber),
+		EventId:             goes.EncodeNetUUID(uuid.NewV4().Bytes()),
+		EventType:           proto.String("TestEvent"),
+		DataContentType:     proto.Int32(0),
+		MetadataContentType: proto.Int32(0),
+		Data:                []byte("{}"),
+	}
+}
+
+func marshalTestMessage(t *testing.T, message proto.Message) []byte {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("Unexpected failure marshalling %T: %s", message, err.Error())
+	}
+	return data
+}
+
+// respondToUnsubscribe waits for an unsubscribe package and confirms that the subscription was dropped
+func respondToUnsubscribe(t *testing.T, socket net.Conn) {
+	unsubscribe, err := readTestPackage(socket)
+	if err != nil || unsubscribe.Command != unsubscribeFromStreamCommand {
+		return
+	}
+	socket.Write(encodeTestPackage(testPackage{
+		Command:       subscriptionDroppedCommand,
+		CorrelationID: unsubscribe.CorrelationID,
+		Data: marshalTestMessage(t, &protobuf.SubscriptionDropped{
+			Reason: protobuf.SubscriptionDropped_Unsubscribed.Enum(),
+		}),
+	}))
+}
 
 func TestCatchupSubscription(t *testing.T) {
+	stream := "testStream"
+	history := marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+		Events: []*protobuf.ResolvedIndexedEvent{
+			{Event: newTestEventRecord(stream, 0)},
+			{Event: newTestEventRecord(stream, 1)},
+			{Event: newTestEventRecord(stream, 2)},
+		},
+		Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+		NextEventNumber:    proto.Int32(3),
+		LastEventNumber:    proto.Int32(2),
+		IsEndOfStream:      proto.Bool(true),
+		LastCommitPosition: proto.Int64(0),
+	})
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		subscribe, err := readTestPackage(socket)
+		if err != nil || subscribe.Command != subscribeToStreamCommand {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionConfirmationCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.SubscriptionConfirmation{
+				LastCommitPosition: proto.Int64(0),
+				LastEventNumber:    proto.Int32(1),
+			}),
+		}))
+		read, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		// event 2 is both received live and read from the history, event 3 is only received live
+		for _, eventNumber := range []int32{2, 3} {
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       streamEventAppearedCommand,
+				CorrelationID: subscribe.CorrelationID,
+				Data: marshalTestMessage(t, &protobuf.StreamEventAppeared{
+					Event: &protobuf.ResolvedEvent{
+						Event:           newTestEventRecord(stream, eventNumber),
+						CommitPosition:  proto.Int64(0),
+						PreparePosition: proto.Int64(0),
+					},
+				}),
+			}))
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsForwardCompletedCommand,
+			CorrelationID: read.CorrelationID,
+			Data:          history,
+		}))
+		respondToUnsubscribe(t, socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan int64, 10)
+	subscription, err := conn.SubscribeToStreamFrom(stream, goes.StreamCheckpointStart, false, func(evnt goes.ResolvedEvent) error {
+		received <- evnt.Event.EventNumber
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer subscription.Stop()
+
+	for expected := int64(0); expected <= 3; expected++ {
+		select {
+		case actual := <-received:
+			if actual != expected {
+				t.Fatalf("Expected %v got %v", expected, actual)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected event %v to be delivered", expected)
+		}
+	}
+	select {
+	case actual := <-received:
+		t.Fatalf("Expected no more events got %v", actual)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCatchupSubscription_FromTheEndOfTheStream(t *testing.T) {
+	stream := "testStream"
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		subscribe, err := readTestPackage(socket)
+		if err != nil || subscribe.Command != subscribeToStreamCommand {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionConfirmationCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.SubscriptionConfirmation{
+				LastCommitPosition: proto.Int64(0),
+				LastEventNumber:    proto.Int32(3),
+			}),
+		}))
+		// event 4 is written while the end of the stream is resolved
+		end, err := readTestPackage(socket)
+		if err != nil || end.Command != readStreamEventsBackwardCommand {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsBackwardCompletedCommand,
+			CorrelationID: end.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+				Events:             []*protobuf.ResolvedIndexedEvent{{Event: newTestEventRecord(stream, 4)}},
+				Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+				NextEventNumber:    proto.Int32(3),
+				LastEventNumber:    proto.Int32(4),
+				IsEndOfStream:      proto.Bool(false),
+				LastCommitPosition: proto.Int64(0),
+			}),
+		}))
+		read, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		for _, eventNumber := range []int32{4, 5} {
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       streamEventAppearedCommand,
+				CorrelationID: subscribe.CorrelationID,
+				Data: marshalTestMessage(t, &protobuf.StreamEventAppeared{
+					Event: &protobuf.ResolvedEvent{
+						Event:           newTestEventRecord(stream, eventNumber),
+						CommitPosition:  proto.Int64(0),
+						PreparePosition: proto.Int64(0),
+					},
+				}),
+			}))
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsForwardCompletedCommand,
+			CorrelationID: read.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+				Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+				NextEventNumber:    proto.Int32(5),
+				LastEventNumber:    proto.Int32(4),
+				IsEndOfStream:      proto.Bool(true),
+				LastCommitPosition: proto.Int64(0),
+			}),
+		}))
+		respondToUnsubscribe(t, socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan int64, 10)
+	subscription, err := conn.SubscribeToStreamFrom(stream, goes.StreamCheckpointEnd, false, func(evnt goes.ResolvedEvent) error {
+		received <- evnt.Event.EventNumber
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer subscription.Stop()
+
+	select {
+	case actual := <-received:
+		if actual != 5 {
+			t.Fatalf("Expected %v got %v", 5, actual)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected event %v to be delivered", 5)
+	}
+	select {
+	case actual := <-received:
+		t.Fatalf("Expected no more events got %v", actual)
+	case <-time.After(100 * time.Millisecond):
+	}
 }
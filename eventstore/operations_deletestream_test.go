@@ -1,8 +1,10 @@
 package goes_test
 
 import (
+	"net"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pgermishuys/goes/eventstore"
 	"github.com/pgermishuys/goes/protobuf"
 	"github.com/satori/go.uuid"
@@ -145,3 +147,202 @@ func TestDeleteStream_WithInvalidCredentials(t *testing.T) {
 		t.Fatalf("Expected %s got %s", expectedError, err.Error())
 	}
 }
+
+func TestDeleteStreamMethod_WithSoftDeleteAllowsRecreation(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, []goes.EventData{createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	err = conn.DeleteStream(streamID, 0, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	_, err = conn.WriteEvents(streamID, goes.ExpectedVersionAny, []goes.EventData{createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure recreating the stream %+v", err)
+	}
+}
+
+func TestDeleteStreamMethod_WithHardDelete(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, []goes.EventData{createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	err = conn.DeleteStream(streamID, 0, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	_, err = conn.ReadEvent(streamID, 0, true)
+	if err != goes.ErrStreamDeleted {
+		t.Fatalf("Expected %v got %v", goes.ErrStreamDeleted, err)
+	}
+}
+
+func TestDeleteStreamMethod_WithWrongExpectedVersion(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, []goes.EventData{createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	err = conn.DeleteStream(streamID, 1, true)
+	wrongExpectedVersion, ok := err.(*goes.ErrWrongExpectedVersion)
+	if !ok {
+		t.Fatalf("Expected a wrong expected version error got %v", err)
+	}
+	if wrongExpectedVersion.CurrentVersion != 0 {
+		t.Fatalf("Expected %d got %d", 0, wrongExpectedVersion.CurrentVersion)
+	}
+}
+
+func TestDeleteStreamMethod_ReadsAfterSoftAndHardDelete(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	softDeleted := uuid.NewV4().String()
+	hardDeleted := uuid.NewV4().String()
+	for _, stream := range []string{softDeleted, hardDeleted} {
+		_, err := conn.WriteEvents(stream, goes.ExpectedVersionNoStream, []goes.EventData{createTestEventData()})
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		err = conn.DeleteStream(stream, 0, stream == hardDeleted)
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+
+	// a soft deleted stream appears not to exist until it is written to again
+	if _, err := conn.ReadEvent(softDeleted, 0, false); err != goes.ErrNoStream {
+		t.Fatalf("Expected %v got %v", goes.ErrNoStream, err)
+	}
+	if _, err := conn.ReadStreamEventsForward(softDeleted, goes.StreamPositionStart, 10, false); err != goes.ErrNoStream {
+		t.Fatalf("Expected %v got %v", goes.ErrNoStream, err)
+	}
+	result, err := conn.WriteEvents(softDeleted, goes.ExpectedVersionAny, []goes.EventData{createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure recreating the stream %+v", err)
+	}
+	if _, err := conn.ReadEvent(softDeleted, result.LastEventNumber, false); err != nil {
+		t.Fatalf("Unexpected failure reading the recreated stream %+v", err)
+	}
+	slice, err := conn.ReadStreamEventsForward(softDeleted, goes.StreamPositionStart, 10, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure reading the recreated stream %+v", err)
+	}
+	if len(slice.Events) != 1 {
+		t.Fatalf("Expected %v got %v", 1, len(slice.Events))
+	}
+
+	// a hard deleted stream can not be recreated
+	if _, err := conn.WriteEvents(hardDeleted, goes.ExpectedVersionAny, []goes.EventData{createTestEventData()}); err != goes.ErrStreamDeleted {
+		t.Fatalf("Expected %v got %v", goes.ErrStreamDeleted, err)
+	}
+	if _, err := conn.ReadEvent(hardDeleted, 0, false); err != goes.ErrStreamDeleted {
+		t.Fatalf("Expected %v got %v", goes.ErrStreamDeleted, err)
+	}
+	if _, err := conn.ReadStreamEventsForward(hardDeleted, goes.StreamPositionStart, 10, false); err != goes.ErrStreamDeleted {
+		t.Fatalf("Expected %v got %v", goes.ErrStreamDeleted, err)
+	}
+}
+
+func TestReadEvent_DistinguishesMissingAndDeletedStreams(t *testing.T) {
+	results := map[protobuf.ReadEventCompleted_ReadEventResult]error{
+		protobuf.ReadEventCompleted_NoStream:      goes.ErrNoStream,
+		protobuf.ReadEventCompleted_StreamDeleted: goes.ErrStreamDeleted,
+	}
+	for result, expected := range results {
+		data := marshalTestMessage(t, &protobuf.ReadEventCompleted{
+			Result: result.Enum(),
+			Event:  &protobuf.ResolvedIndexedEvent{Event: newTestEventRecord("testStream", 0)},
+		})
+		conn, listener := startTestServer(t, func(socket net.Conn) {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       readEventCompletedCommand,
+				CorrelationID: pkg.CorrelationID,
+				Data:          data,
+			}))
+		})
+		_, err := conn.ReadEvent("testStream", 0, false)
+		conn.Close()
+		listener.Close()
+		if err != expected {
+			t.Fatalf("Expected %v got %v", expected, err)
+		}
+	}
+}
+
+func TestReadStreamEventsForward_DistinguishesMissingAndDeletedStreams(t *testing.T) {
+	results := map[protobuf.ReadStreamEventsCompleted_ReadStreamResult]error{
+		protobuf.ReadStreamEventsCompleted_NoStream:      goes.ErrNoStream,
+		protobuf.ReadStreamEventsCompleted_StreamDeleted: goes.ErrStreamDeleted,
+	}
+	for result, expected := range results {
+		data := marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+			Result:             result.Enum(),
+			NextEventNumber:    proto.Int32(-1),
+			LastEventNumber:    proto.Int32(-1),
+			IsEndOfStream:      proto.Bool(true),
+			LastCommitPosition: proto.Int64(0),
+		})
+		conn, listener := startTestServer(t, func(socket net.Conn) {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       readStreamEventsForwardCompletedCommand,
+				CorrelationID: pkg.CorrelationID,
+				Data:          data,
+			}))
+		})
+		_, err := conn.ReadStreamEventsForward("testStream", goes.StreamPositionStart, 10, false)
+		conn.Close()
+		listener.Close()
+		if err != expected {
+			t.Fatalf("Expected %v got %v", expected, err)
+		}
+	}
+}
+
+func TestTruncateStream_HidesTheEventsBeforeTheEventNumber(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	events := []goes.EventData{createTestEventData(), createTestEventData(), createTestEventData()}
+	if _, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, events); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if err := conn.TruncateStream(streamID, 2); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	if _, err := conn.ReadEvent(streamID, 1, false); err != goes.ErrEventNotFound {
+		t.Fatalf("Expected %v got %v", goes.ErrEventNotFound, err)
+	}
+	slice, err := conn.ReadStreamEventsForward(streamID, goes.StreamPositionStart, 10, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 1 || slice.Events[0].Event.EventNumber != 2 {
+		t.Fatalf("Expected only event %v got %+v", 2, slice.Events)
+	}
+}
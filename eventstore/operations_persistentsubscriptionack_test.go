@@ -0,0 +1,117 @@
+package goes_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	connectToPersistentSubscriptionCommand    fakeserver.Command = 0xC5
+	persistentSubscriptionConfirmationCommand fakeserver.Command = 0xC6
+	persistentSubscriptionAckEventsCommand    fakeserver.Command = 0xCC
+	persistentSubscriptionNakEventsCommand    fakeserver.Command = 0xCD
+)
+
+// startTestPersistentSubscriptionServer confirms the persistent subscriptions on a fake server and sends the connect,
+// ack and nack packages of the client to received
+func startTestPersistentSubscriptionServer(t *testing.T, received chan fakeserver.Package) (*goes.EventStoreConnection, *fakeserver.Server) {
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	server.Handle(connectToPersistentSubscriptionCommand, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		serverConn.Respond(pkg, persistentSubscriptionConfirmationCommand, &protobuf.PersistentSubscriptionConfirmation{
+			LastCommitPosition: proto.Int64(0),
+			SubscriptionId:     proto.String("testStream::testGroup"),
+		})
+		received <- pkg
+	})
+	receive := func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		received <- pkg
+	}
+	server.Handle(persistentSubscriptionAckEventsCommand, receive)
+	server.Handle(persistentSubscriptionNakEventsCommand, receive)
+	return conn, server
+}
+
+func receiveTestPackage(t *testing.T, received chan fakeserver.Package) fakeserver.Package {
+	select {
+	case pkg := <-received:
+		return pkg
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a package to be received")
+	}
+	return fakeserver.Package{}
+}
+
+func TestPersistentSubscriptionAck(t *testing.T) {
+	received := make(chan fakeserver.Package, 2)
+	conn, server := startTestPersistentSubscriptionServer(t, received)
+	defer server.Close()
+	defer conn.Close()
+
+	subscription, err := goes.ConnectToPersistentSubscription(conn, "testStream", "testGroup", func(*protobuf.StreamEventAppeared) {}, func(*protobuf.SubscriptionDropped) {}, 10, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	connect := receiveTestPackage(t, received)
+
+	eventID := uuid.NewV4()
+	err = subscription.Ack([]uuid.UUID{eventID})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	ack := receiveTestPackage(t, received)
+	if ack.Command != persistentSubscriptionAckEventsCommand {
+		t.Fatalf("Expected %v got %v", persistentSubscriptionAckEventsCommand, ack.Command)
+	}
+	if !bytes.Equal(ack.CorrelationID, connect.CorrelationID) {
+		t.Fatalf("Expected %v got %v", connect.CorrelationID, ack.CorrelationID)
+	}
+	ackEvents := &protobuf.PersistentSubscriptionAckEvents{}
+	if err := proto.Unmarshal(ack.Data, ackEvents); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if ackEvents.GetSubscriptionId() != subscription.SubscriptionID {
+		t.Fatalf("Expected %v got %v", subscription.SubscriptionID, ackEvents.GetSubscriptionId())
+	}
+	if len(ackEvents.GetProcessedEventIds()) != 1 || !bytes.Equal(ackEvents.GetProcessedEventIds()[0], goes.EncodeNetUUID(eventID.Bytes())) {
+		t.Fatalf("Expected %v got %v", eventID, ackEvents.GetProcessedEventIds())
+	}
+}
+
+func TestPersistentSubscriptionNack(t *testing.T) {
+	received := make(chan fakeserver.Package, 2)
+	conn, server := startTestPersistentSubscriptionServer(t, received)
+	defer server.Close()
+	defer conn.Close()
+
+	subscription, err := goes.ConnectToPersistentSubscription(conn, "testStream", "testGroup", func(*protobuf.StreamEventAppeared) {}, func(*protobuf.SubscriptionDropped) {}, 10, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	connect := receiveTestPackage(t, received)
+
+	err = subscription.Nack([]uuid.UUID{uuid.NewV4()}, goes.NackActionPark)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	nack := receiveTestPackage(t, received)
+	if nack.Command != persistentSubscriptionNakEventsCommand {
+		t.Fatalf("Expected %v got %v", persistentSubscriptionNakEventsCommand, nack.Command)
+	}
+	if !bytes.Equal(nack.CorrelationID, connect.CorrelationID) {
+		t.Fatalf("Expected %v got %v", connect.CorrelationID, nack.CorrelationID)
+	}
+	nakEvents := &protobuf.PersistentSubscriptionNakEvents{}
+	if err := proto.Unmarshal(nack.Data, nakEvents); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if nakEvents.GetAction() != protobuf.PersistentSubscriptionNakEvents_Park {
+		t.Fatalf("Expected %v got %v", protobuf.PersistentSubscriptionNakEvents_Park, nakEvents.GetAction())
+	}
+}
@@ -0,0 +1,52 @@
+package goes_test
+
+import (
+	"testing"
+
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/satori/go.uuid"
+)
+
+func TestPersistentSubscriptionLifecycle_CreateUpdateDelete(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	groupName := uuid.NewV4().String()
+	settings := goes.NewPersistentSubscriptionSettings()
+
+	err := conn.CreatePersistentSubscription(streamID, groupName, *settings)
+	if err != nil {
+		t.Fatalf("Unexpected failure creating %+v", err)
+	}
+	err = conn.CreatePersistentSubscription(streamID, groupName, *settings)
+	if err != goes.ErrPersistentSubscriptionAlreadyExists {
+		t.Fatalf("Expected %v got %v", goes.ErrPersistentSubscriptionAlreadyExists, err)
+	}
+
+	settings.MaxRetryCount = 5
+	err = conn.UpdatePersistentSubscription(streamID, groupName, *settings)
+	if err != nil {
+		t.Fatalf("Unexpected failure updating %+v", err)
+	}
+
+	err = conn.DeletePersistentSubscription(streamID, groupName)
+	if err != nil {
+		t.Fatalf("Unexpected failure deleting %+v", err)
+	}
+	err = conn.DeletePersistentSubscription(streamID, groupName)
+	if err != goes.ErrPersistentSubscriptionDoesNotExist {
+		t.Fatalf("Expected %v got %v", goes.ErrPersistentSubscriptionDoesNotExist, err)
+	}
+}
+
+func TestPersistentSubscriptionLifecycle_UpdateWhenSubscriptionDoesNotExist(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	settings := goes.NewPersistentSubscriptionSettings()
+	err := conn.UpdatePersistentSubscription(uuid.NewV4().String(), uuid.NewV4().String(), *settings)
+	if err != goes.ErrPersistentSubscriptionDoesNotExist {
+		t.Fatalf("Expected %v got %v", goes.ErrPersistentSubscriptionDoesNotExist, err)
+	}
+}
@@ -0,0 +1,91 @@
+package goes_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+func TestReadEvent_WithEventsInStream(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	evnt := createTestEventData()
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionAny, []goes.EventData{evnt})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	result, err := conn.ReadEvent(streamID, 0, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if result.Event.EventID != evnt.EventID {
+		t.Fatalf("Expected %v got %v", evnt.EventID, result.Event.EventID)
+	}
+	if result.Event.StreamID != streamID {
+		t.Fatalf("Expected %s got %s", streamID, result.Event.StreamID)
+	}
+	if result.Event.EventType != evnt.EventType {
+		t.Fatalf("Expected %s got %s", evnt.EventType, result.Event.EventType)
+	}
+	if result.Event.Created.IsZero() {
+		t.Fatalf("Expected the created time to be set")
+	}
+}
+
+func TestReadEvent_WithNoStream(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	_, err := conn.ReadEvent(uuid.NewV4().String(), 0, true)
+	if err != goes.ErrNoStream {
+		t.Fatalf("Expected %v got %v", goes.ErrNoStream, err)
+	}
+}
+
+func TestReadEvent_WithEventNumberPastTheEndOfTheStream(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionAny, []goes.EventData{createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	_, err = conn.ReadEvent(streamID, 5, true)
+	if err != goes.ErrEventNotFound {
+		t.Fatalf("Expected %v got %v", goes.ErrEventNotFound, err)
+	}
+}
+
+func TestReadEvent_WithAnErrorWithoutAMessage(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readEventCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.ReadEventCompleted{
+				Result: protobuf.ReadEventCompleted_Error.Enum(),
+				Event:  &protobuf.ResolvedIndexedEvent{Event: newTestEventRecord("testStream", 0)},
+			}),
+		}))
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := conn.ReadEvent("testStream", 0, false)
+	if err == nil || !strings.Contains(err.Error(), protobuf.ReadEventCompleted_Error.String()) {
+		t.Fatalf("Expected an error with the result %v got %v", protobuf.ReadEventCompleted_Error, err)
+	}
+}
@@ -0,0 +1,61 @@
+package goes_test
+
+import (
+	"testing"
+
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/satori/go.uuid"
+)
+
+func TestReadStreamEventsBackwardSlice_ReadingTheLastEvent(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	events := []goes.EventData{
+		createTestEventData(),
+		createTestEventData(),
+	}
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, events)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	slice, err := conn.ReadStreamEventsBackward(streamID, goes.StreamPositionEnd, 1, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 1 {
+		t.Fatalf("Expected %d got %d", 1, len(slice.Events))
+	}
+	if slice.Events[0].Event.EventID != events[1].EventID {
+		t.Fatalf("Expected %v got %v", events[1].EventID, slice.Events[0].Event.EventID)
+	}
+	if slice.Events[0].Event.EventNumber != 1 {
+		t.Fatalf("Expected %d got %d", 1, slice.Events[0].Event.EventNumber)
+	}
+	if slice.IsEndOfStream {
+		t.Fatalf("Expected more events to be available before the last event")
+	}
+
+	slice, err = conn.ReadStreamEventsBackward(streamID, slice.NextEventNumber, 1, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if slice.Events[0].Event.EventNumber != 0 {
+		t.Fatalf("Expected %d got %d", 0, slice.Events[0].Event.EventNumber)
+	}
+	if !slice.IsEndOfStream {
+		t.Fatalf("Expected the first event to be the end of the stream")
+	}
+}
+
+func TestReadStreamEventsBackwardSlice_WithNoStream(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	_, err := conn.ReadStreamEventsBackward(uuid.NewV4().String(), goes.StreamPositionEnd, 1, true)
+	if err != goes.ErrNoStream {
+		t.Fatalf("Expected %v got %v", goes.ErrNoStream, err)
+	}
+}
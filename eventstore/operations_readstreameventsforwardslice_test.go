@@ -0,0 +1,65 @@
+package goes_test
+
+import (
+	"testing"
+
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/satori/go.uuid"
+)
+
+func TestReadStreamEventsForwardSlice_PagingThroughStream(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	events := []goes.EventData{
+		createTestEventData(),
+		createTestEventData(),
+		createTestEventData(),
+	}
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, events)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	slice, err := conn.ReadStreamEventsForward(streamID, 0, 2, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 2 {
+		t.Fatalf("Expected %d got %d", 2, len(slice.Events))
+	}
+	if slice.IsEndOfStream {
+		t.Fatalf("Expected the first page not to be the end of the stream")
+	}
+	if slice.NextEventNumber != 2 {
+		t.Fatalf("Expected %d got %d", 2, slice.NextEventNumber)
+	}
+
+	slice, err = conn.ReadStreamEventsForward(streamID, slice.NextEventNumber, 2, true)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 1 {
+		t.Fatalf("Expected %d got %d", 1, len(slice.Events))
+	}
+	if !slice.IsEndOfStream {
+		t.Fatalf("Expected the last page to be the end of the stream")
+	}
+	if slice.LastEventNumber != 2 {
+		t.Fatalf("Expected %d got %d", 2, slice.LastEventNumber)
+	}
+	if slice.Events[0].Event.EventID != events[2].EventID {
+		t.Fatalf("Expected %v got %v", events[2].EventID, slice.Events[0].Event.EventID)
+	}
+}
+
+func TestReadStreamEventsForwardSlice_WithNoStream(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	_, err := conn.ReadStreamEventsForward(uuid.NewV4().String(), 0, 1, true)
+	if err != goes.ErrNoStream {
+		t.Fatalf("Expected %v got %v", goes.ErrNoStream, err)
+	}
+}
@@ -0,0 +1,61 @@
+package goes_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/satori/go.uuid"
+)
+
+func TestReadStreamIterator_ReadsEveryEventAcrossBatches(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	var events []goes.EventData
+	for i := 0; i < 5; i++ {
+		events = append(events, createTestEventData())
+	}
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, events)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	results, errs := conn.ReadStreamIterator(streamID, 2, true)
+	var read []goes.ResolvedEvent
+	for evnt := range results {
+		read = append(read, evnt)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(read) != len(events) {
+		t.Fatalf("Expected %d got %d", len(events), len(read))
+	}
+	for i, evnt := range read {
+		if evnt.Event.EventID != events[i].EventID {
+			t.Fatalf("Expected %v got %v", events[i].EventID, evnt.Event.EventID)
+		}
+	}
+}
+
+func TestReadStreamIterator_WhenContextIsCancelled(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+
+	streamID := uuid.NewV4().String()
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, []goes.EventData{createTestEventData(), createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, errs := conn.ReadStreamIteratorWithContext(ctx, streamID, 1, true)
+	<-results
+	cancel()
+
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("Expected %v got %v", context.Canceled, err)
+	}
+}
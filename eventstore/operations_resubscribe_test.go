@@ -0,0 +1,183 @@
+package goes_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+func confirmTestSubscription(t *testing.T, socket net.Conn) (testPackage, error) {
+	subscribe, err := readTestPackage(socket)
+	if err != nil {
+		return subscribe, err
+	}
+	socket.Write(encodeTestPackage(testPackage{
+		Command:       subscriptionConfirmationCommand,
+		CorrelationID: subscribe.CorrelationID,
+		Data: marshalTestMessage(t, &protobuf.SubscriptionConfirmation{
+			LastCommitPosition: proto.Int64(0),
+		}),
+	}))
+	return subscribe, nil
+}
+
+func writeTestEventAppeared(t *testing.T, socket net.Conn, correlationID []byte, stream string, eventNumber int32) {
+	socket.Write(encodeTestPackage(testPackage{
+		Command:       streamEventAppearedCommand,
+		CorrelationID: correlationID,
+		Data:          newTestEventAppeared(t, stream, eventNumber),
+	}))
+}
+
+func newTestEventAppeared(t *testing.T, stream string, eventNumber int32) []byte {
+	return marshalTestMessage(t, &protobuf.StreamEventAppeared{
+		Event: &protobuf.ResolvedEvent{
+			Event:           newTestEventRecord(stream, eventNumber),
+			CommitPosition:  proto.Int64(0),
+			PreparePosition: proto.Int64(0),
+		},
+	})
+}
+
+func writeTestStreamEventsCompleted(t *testing.T, socket net.Conn, correlationID []byte, stream string, eventNumbers ...int32) {
+	var events []*protobuf.ResolvedIndexedEvent
+	for _, eventNumber := range eventNumbers {
+		events = append(events, &protobuf.ResolvedIndexedEvent{Event: newTestEventRecord(stream, eventNumber)})
+	}
+	last := eventNumbers[len(eventNumbers)-1]
+	socket.Write(encodeTestPackage(testPackage{
+		Command:       readStreamEventsForwardCompletedCommand,
+		CorrelationID: correlationID,
+		Data: marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+			Events:             events,
+			Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+			NextEventNumber:    proto.Int32(last + 1),
+			LastEventNumber:    proto.Int32(last),
+			IsEndOfStream:      proto.Bool(true),
+			LastCommitPosition: proto.Int64(0),
+		}),
+	}))
+}
+
+func expectTestEventNumbers(t *testing.T, received chan int64, expected ...int64) {
+	for _, expectedEventNumber := range expected {
+		select {
+		case actual := <-received:
+			if actual != expectedEventNumber {
+				t.Fatalf("Expected %v got %v", expectedEventNumber, actual)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected event %v to be delivered", expectedEventNumber)
+		}
+	}
+	select {
+	case actual := <-received:
+		t.Fatalf("Expected no more events got %v", actual)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSubscribeToStream_ResubscribesAfterReconnect(t *testing.T) {
+	stream := "testStream"
+	correlationIDs := make(chan []byte, 2)
+	lose := make(chan struct{})
+	config := goes.NewConfiguration()
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			subscribe, err := confirmTestSubscription(t, socket)
+			if err != nil {
+				return
+			}
+			correlationIDs <- subscribe.CorrelationID
+			writeTestEventAppeared(t, socket, subscribe.CorrelationID, stream, 0)
+			<-lose
+			socket.Close()
+		},
+		func(socket net.Conn) {
+			subscribe, err := confirmTestSubscription(t, socket)
+			if err != nil || subscribe.Command != subscribeToStreamCommand {
+				return
+			}
+			correlationIDs <- subscribe.CorrelationID
+			writeTestEventAppeared(t, socket, subscribe.CorrelationID, stream, 1)
+			respondToUnsubscribe(t, socket)
+		})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan int64, 10)
+	subscription, err := conn.SubscribeToStream(stream, false, func(evnt goes.ResolvedEvent) {
+		received <- evnt.Event.EventNumber
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer subscription.Unsubscribe()
+
+	expectTestEventNumbers(t, received, 0)
+	close(lose)
+	expectTestEventNumbers(t, received, 1)
+	if bytes.Equal(<-correlationIDs, <-correlationIDs) {
+		t.Fatalf("Expected the subscription to be resubscribed with a new correlation id")
+	}
+}
+
+func TestCatchupSubscription_ResumesFromCheckpointAfterReconnect(t *testing.T) {
+	stream := "testStream"
+	resumedFrom := make(chan int32, 1)
+	config := goes.NewConfiguration()
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			subscribe, err := confirmTestSubscription(t, socket)
+			if err != nil {
+				return
+			}
+			read, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			writeTestStreamEventsCompleted(t, socket, read.CorrelationID, stream, 0, 1)
+			writeTestEventAppeared(t, socket, subscribe.CorrelationID, stream, 2)
+			socket.Close()
+		},
+		func(socket net.Conn) {
+			// events 3 and 4 were written while the connection was lost
+			subscribe, err := confirmTestSubscription(t, socket)
+			if err != nil {
+				return
+			}
+			read, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			readData := &protobuf.ReadStreamEvents{}
+			proto.Unmarshal(read.Data, readData)
+			resumedFrom <- readData.GetFromEventNumber()
+			writeTestStreamEventsCompleted(t, socket, read.CorrelationID, stream, 3, 4)
+			writeTestEventAppeared(t, socket, subscribe.CorrelationID, stream, 4)
+			writeTestEventAppeared(t, socket, subscribe.CorrelationID, stream, 5)
+			respondToUnsubscribe(t, socket)
+		})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan int64, 10)
+	subscription, err := conn.SubscribeToStreamFrom(stream, goes.StreamCheckpointStart, false, func(evnt goes.ResolvedEvent) error {
+		received <- evnt.Event.EventNumber
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer subscription.Stop()
+
+	expectTestEventNumbers(t, received, 0, 1, 2, 3, 4, 5)
+	if from := <-resumedFrom; from != 3 {
+		t.Fatalf("Expected %v got %v", 3, from)
+	}
+}
@@ -0,0 +1,67 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+func TestSubscribeToAll(t *testing.T) {
+	subscribedStream := make(chan string, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		subscribe, err := readTestPackage(socket)
+		if err != nil || subscribe.Command != subscribeToStreamCommand {
+			return
+		}
+		subscribeData := &protobuf.SubscribeToStream{}
+		proto.Unmarshal(subscribe.Data, subscribeData)
+		subscribedStream <- subscribeData.GetEventStreamId()
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionConfirmationCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.SubscriptionConfirmation{
+				LastCommitPosition: proto.Int64(100),
+			}),
+		}))
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       streamEventAppearedCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.StreamEventAppeared{
+				Event: &protobuf.ResolvedEvent{
+					Event:           newTestEventRecord("testStream", 0),
+					CommitPosition:  proto.Int64(200),
+					PreparePosition: proto.Int64(150),
+				},
+			}),
+		}))
+		respondToUnsubscribe(t, socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan goes.ResolvedEvent, 1)
+	subscription, err := conn.SubscribeToAll(false, func(evnt goes.ResolvedEvent) {
+		received <- evnt
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer subscription.Unsubscribe()
+
+	if stream := <-subscribedStream; stream != "" {
+		t.Fatalf("Expected the $all stream to be subscribed to got %v", stream)
+	}
+	select {
+	case evnt := <-received:
+		expected := goes.Position{CommitPosition: 200, PreparePosition: 150}
+		if evnt.Position != expected {
+			t.Fatalf("Expected %+v got %+v", expected, evnt.Position)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected an event to be delivered")
+	}
+}
@@ -0,0 +1,113 @@
+package goes_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+const (
+	filteredSubscribeToStreamCommand byte = 0xD2
+	checkpointReachedCommand         byte = 0xD3
+)
+
+func TestSubscribeToAllFiltered(t *testing.T) {
+	filters := make(chan *protobuf.FilteredSubscribeToStream, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		subscribe, err := readTestPackage(socket)
+		if err != nil || subscribe.Command != filteredSubscribeToStreamCommand {
+			return
+		}
+		request := &protobuf.FilteredSubscribeToStream{}
+		proto.Unmarshal(subscribe.Data, request)
+		filters <- request
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionConfirmationCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.SubscriptionConfirmation{
+				LastCommitPosition: proto.Int64(100),
+			}),
+		}))
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       streamEventAppearedCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.StreamEventAppeared{
+				Event: &protobuf.ResolvedEvent{
+					Event:           newTestEventRecord("order-1", 0),
+					CommitPosition:  proto.Int64(200),
+					PreparePosition: proto.Int64(200),
+				},
+			}),
+		}))
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       checkpointReachedCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.CheckpointReached{
+				CommitPosition:  proto.Int64(300),
+				PreparePosition: proto.Int64(300),
+			}),
+		}))
+		respondToUnsubscribe(t, socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	events := make(chan goes.ResolvedEvent, 1)
+	checkpoints := make(chan goes.Position, 1)
+	filter := goes.Filter{Target: goes.FilterTargetStreamID, Mode: goes.FilterModePrefix, Values: []string{"order-", "invoice-"}}
+	subscription, err := conn.SubscribeToAllFiltered(filter, 32, false, func(evnt goes.ResolvedEvent) {
+		events <- evnt
+	}, func(position goes.Position) {
+		checkpoints <- position
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer subscription.Unsubscribe()
+
+	request := <-filters
+	if request.GetFilter().GetContext() != protobuf.Filter_StreamId || request.GetFilter().GetType() != protobuf.Filter_Prefix {
+		t.Fatalf("Expected a stream id prefix filter got %+v", request.GetFilter())
+	}
+	if !reflect.DeepEqual(request.GetFilter().GetData(), filter.Values) || request.GetCheckpointInterval() != 32 {
+		t.Fatalf("Expected %v got %+v", filter.Values, request)
+	}
+	select {
+	case evnt := <-events:
+		if evnt.Event.StreamID != "order-1" || evnt.Position.CommitPosition != 200 {
+			t.Fatalf("Expected %v got %+v", "order-1 at 200", evnt)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the event to be delivered")
+	}
+	select {
+	case position := <-checkpoints:
+		if position.CommitPosition != 300 || position.PreparePosition != 300 {
+			t.Fatalf("Expected %v got %+v", 300, position)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the checkpoint to be delivered")
+	}
+}
+
+func TestSubscribeToAllFiltered_WithInvalidFilter(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	for _, filter := range []goes.Filter{
+		{Mode: goes.FilterModePrefix},
+		{Mode: goes.FilterModeRegex, Values: []string{"^order-", "^invoice-"}},
+	} {
+		if _, err := conn.SubscribeToAllFiltered(filter, 1, false, func(goes.ResolvedEvent) {}, nil); err == nil {
+			t.Fatalf("Expected %+v to be rejected", filter)
+		}
+	}
+}
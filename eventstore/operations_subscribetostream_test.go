@@ -0,0 +1,144 @@
+package goes_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+func TestSubscribeToStream_ThenUnsubscribe(t *testing.T) {
+	stream := "testStream"
+	unsubscribed := make(chan bool, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		subscribe, err := readTestPackage(socket)
+		if err != nil || subscribe.Command != subscribeToStreamCommand {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionConfirmationCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.SubscriptionConfirmation{
+				LastCommitPosition: proto.Int64(0),
+				LastEventNumber:    proto.Int32(0),
+			}),
+		}))
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       streamEventAppearedCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.StreamEventAppeared{
+				Event: &protobuf.ResolvedEvent{
+					Event:           newTestEventRecord(stream, 1),
+					CommitPosition:  proto.Int64(0),
+					PreparePosition: proto.Int64(0),
+				},
+			}),
+		}))
+		unsubscribe, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		unsubscribed <- unsubscribe.Command == unsubscribeFromStreamCommand && bytes.Equal(unsubscribe.CorrelationID, subscribe.CorrelationID)
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionDroppedCommand,
+			CorrelationID: unsubscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.SubscriptionDropped{
+				Reason: protobuf.SubscriptionDropped_Unsubscribed.Enum(),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan goes.ResolvedEvent, 1)
+	subscription, err := conn.SubscribeToStream(stream, false, func(evnt goes.ResolvedEvent) {
+		received <- evnt
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	select {
+	case evnt := <-received:
+		if evnt.Event.EventNumber != 1 {
+			t.Fatalf("Expected %v got %v", 1, evnt.Event.EventNumber)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected an event to be delivered")
+	}
+
+	err = subscription.Unsubscribe()
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if !<-unsubscribed {
+		t.Fatalf("Expected an unsubscribe package with the subscription's correlation id")
+	}
+}
+
+func TestUnsubscribe_WhenCalledMoreThanOnce(t *testing.T) {
+	unsubscribes := make(chan struct{}, 3)
+	release := make(chan struct{})
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		if _, err := confirmTestSubscription(t, socket); err != nil {
+			return
+		}
+		for {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command != unsubscribeFromStreamCommand {
+				continue
+			}
+			unsubscribes <- struct{}{}
+			<-release
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       subscriptionDroppedCommand,
+				CorrelationID: pkg.CorrelationID,
+				Data: marshalTestMessage(t, &protobuf.SubscriptionDropped{
+					Reason: protobuf.SubscriptionDropped_Unsubscribed.Enum(),
+				}),
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	subscription, err := conn.SubscribeToStream("testStream", false, func(goes.ResolvedEvent) {})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	results := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			results <- subscription.Unsubscribe()
+		}()
+	}
+	select {
+	case <-unsubscribes:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected an unsubscribe package")
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for i := 0; i < 3; i++ {
+		if err := <-results; err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+	if err := subscription.Unsubscribe(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	select {
+	case <-unsubscribes:
+		t.Fatalf("Expected a single unsubscribe package")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if len(conn.Subscriptions()) != 0 {
+		t.Fatalf("Expected %v got %v", 0, len(conn.Subscriptions()))
+	}
+}
@@ -0,0 +1,622 @@
+package goes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+func createTestEventData() goes.EventData {
+	return goes.EventData{
+		EventID:   uuid.NewV4(),
+		EventType: "TestEvent",
+		IsJSON:    true,
+		Data:      []byte("{}"),
+		Metadata:  []byte("{}"),
+	}
+}
+
+func TestWriteEvents_SingleEvent(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+	events := []goes.EventData{
+		createTestEventData(),
+	}
+
+	result, err := conn.WriteEvents(uuid.NewV4().String(), -2, events)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if result.FirstEventNumber != 0 {
+		t.Fatalf("Expected %d got %d", 0, result.FirstEventNumber)
+	}
+	if result.LastEventNumber != 0 {
+		t.Fatalf("Expected %d got %d", 0, result.LastEventNumber)
+	}
+}
+
+func TestWriteEvents_MultipleEvents(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+	events := []goes.EventData{
+		createTestEventData(),
+		createTestEventData(),
+	}
+
+	result, err := conn.WriteEvents(uuid.NewV4().String(), -2, events)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if result.LastEventNumber != 1 {
+		t.Fatalf("Expected %d got %d", 1, result.LastEventNumber)
+	}
+	if result.Position.CommitPosition < result.Position.PreparePosition {
+		t.Fatalf("Expected commit position %d to be at or after prepare position %d", result.Position.CommitPosition, result.Position.PreparePosition)
+	}
+}
+
+func TestWriteEvents_WithInvalidExpectedVersion(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+	events := []goes.EventData{
+		createTestEventData(),
+	}
+
+	streamID := uuid.NewV4().String()
+	result, err := conn.WriteEvents(streamID, 0, events)
+	wrongExpectedVersion, ok := err.(*goes.ErrWrongExpectedVersion)
+	if !ok {
+		t.Fatalf("Expected a wrong expected version error got %v", err)
+	}
+	if result != nil {
+		t.Fatalf("Expected no result got %+v", result)
+	}
+	if wrongExpectedVersion.Stream != streamID {
+		t.Fatalf("Expected %s got %s", streamID, wrongExpectedVersion.Stream)
+	}
+	if wrongExpectedVersion.CurrentVersion != goes.ExpectedVersionNoStream {
+		t.Fatalf("Expected %d got %d", goes.ExpectedVersionNoStream, wrongExpectedVersion.CurrentVersion)
+	}
+}
+
+func TestWriteEvents_WithExpectedVersionOfExistingStream(t *testing.T) {
+	conn := createTestConnection(t)
+	defer conn.Close()
+	streamID := uuid.NewV4().String()
+
+	_, err := conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, []goes.EventData{createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	_, err = conn.WriteEvents(streamID, goes.ExpectedVersionNoStream, []goes.EventData{createTestEventData()})
+	wrongExpectedVersion, ok := err.(*goes.ErrWrongExpectedVersion)
+	if !ok {
+		t.Fatalf("Expected a wrong expected version error got %v", err)
+	}
+	if wrongExpectedVersion.CurrentVersion != 0 {
+		t.Fatalf("Expected %d got %d", 0, wrongExpectedVersion.CurrentVersion)
+	}
+
+	result, err := conn.WriteEvents(streamID, 0, []goes.EventData{createTestEventData()})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if result.LastEventNumber != 1 {
+		t.Fatalf("Expected %d got %d", 1, result.LastEventNumber)
+	}
+}
+
+func TestWriteEvents_RetryWithTheSameEventIDsIsIdempotent(t *testing.T) {
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	defer server.Close()
+	defer conn.Close()
+	// the first write times out and is retried by the client
+	requests := make(chan *protobuf.WriteEvents, 2)
+	write := server.Handler(fakeserver.WriteEvents)
+	server.Handle(fakeserver.WriteEvents, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		request := &protobuf.WriteEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		requests <- request
+		if len(requests) == 1 {
+			serverConn.Respond(pkg, fakeserver.WriteEventsCompleted, &protobuf.WriteEventsCompleted{
+				Result:           protobuf.OperationResult_CommitTimeout.Enum(),
+				FirstEventNumber: proto.Int32(-1),
+				LastEventNumber:  proto.Int32(-1),
+			})
+			return
+		}
+		write(serverConn, pkg)
+	})
+
+	events := []goes.EventData{createTestEventData(), createTestEventData()}
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionNoStream, events); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	first, retry := <-requests, <-requests
+	if retry.GetExpectedVersion() != first.GetExpectedVersion() {
+		t.Fatalf("Expected %v got %v", first.GetExpectedVersion(), retry.GetExpectedVersion())
+	}
+	if len(retry.GetEvents()) != len(events) {
+		t.Fatalf("Expected %d events got %d", len(events), len(retry.GetEvents()))
+	}
+	for i, evnt := range retry.GetEvents() {
+		if !bytes.Equal(evnt.GetEventId(), first.GetEvents()[i].GetEventId()) || !bytes.Equal(evnt.GetEventId(), goes.EncodeNetUUID(events[i].EventID.Bytes())) {
+			t.Fatalf("Expected %v got %v", first.GetEvents()[i].GetEventId(), evnt.GetEventId())
+		}
+	}
+}
+
+func TestWriteEvents_WhenTheConnectionIsClosed(t *testing.T) {
+	received := make(chan struct{})
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		if _, err := readTestPackage(socket); err != nil {
+			return
+		}
+		close(received)
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}})
+		result <- err
+	}()
+	<-received
+	conn.Close()
+
+	select {
+	case err := <-result:
+		if err != goes.ErrConnectionClosed {
+			t.Fatalf("Expected %v got %v", goes.ErrConnectionClosed, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the write to fail once the connection is closed")
+	}
+}
+
+func TestOperations_WithArgumentsBeyond32Bits(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		for {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			t.Errorf("Expected no package to be sent got %v", pkg.Command)
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	beyond := int64(math.MaxInt32) + 1
+	for argument, operation := range map[string]func() error{
+		"expectedVersion": func() error {
+			_, err := conn.WriteEvents("testStream", beyond, []goes.EventData{createTestEventData()})
+			return err
+		},
+		"eventNumber": func() error {
+			_, err := conn.ReadEvent("testStream", beyond, false)
+			return err
+		},
+		"start": func() error {
+			_, err := conn.ReadStreamEventsForward("testStream", beyond, 1, false)
+			return err
+		},
+	} {
+		err := operation()
+		invalid, ok := err.(*goes.ErrInvalidArgument)
+		if !ok || invalid.Argument != argument || invalid.Value != beyond {
+			t.Fatalf("Expected %v got %v", &goes.ErrInvalidArgument{Argument: argument, Value: beyond}, err)
+		}
+	}
+}
+
+func TestWriteEvents_AfterTheConnectionIsClosed(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+	conn.Close()
+
+	_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{createTestEventData()})
+	if err != goes.ErrConnectionClosed {
+		t.Fatalf("Expected %v got %v", goes.ErrConnectionClosed, err)
+	}
+}
+
+// recordTestWrites answers a write with success and passes the request on
+func recordTestWrites(requests chan *protobuf.WriteEvents) fakeserver.HandlerFunc {
+	return func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		request := &protobuf.WriteEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		requests <- request
+		serverConn.Respond(pkg, fakeserver.WriteEventsCompleted, &protobuf.WriteEventsCompleted{
+			Result:           protobuf.OperationResult_Success.Enum(),
+			FirstEventNumber: proto.Int32(0),
+			LastEventNumber:  proto.Int32(0),
+		})
+	}
+}
+
+func TestAppendToStream(t *testing.T) {
+	requests := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	defer server.Close()
+	defer conn.Close()
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(requests))
+
+	evnt := createTestEventData()
+	if _, err := conn.AppendToStream("testStream", 3, evnt); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	if request.GetEventStreamId() != "testStream" || request.GetExpectedVersion() != 3 {
+		t.Fatalf("Expected a write to %v at %v got %+v", "testStream", 3, request)
+	}
+	if len(request.Events) != 1 || request.Events[0].GetEventType() != evnt.EventType {
+		t.Fatalf("Expected the event to be written got %+v", request.Events)
+	}
+}
+
+func TestWriteEvents_WithDefaultExpectedVersion(t *testing.T) {
+	for _, test := range []struct {
+		defaultExpectedVersion int64
+		expectedVersion        int64
+		expected               int32
+	}{
+		{goes.ExpectedVersionStreamExists, 3, 3},
+		{goes.ExpectedVersionStreamExists, goes.ExpectedVersionUnset, goes.ExpectedVersionStreamExists},
+		{0, goes.ExpectedVersionUnset, goes.ExpectedVersionAny},
+	} {
+		requests := make(chan *protobuf.WriteEvents, 1)
+		conn, server := startTestFakeServer(t, goes.NewConfiguration())
+		server.Handle(fakeserver.WriteEvents, recordTestWrites(requests))
+		conn.Config.DefaultExpectedVersion = test.defaultExpectedVersion
+		_, err := conn.WriteEvents("testStream", test.expectedVersion, []goes.EventData{createTestEventData()})
+		conn.Close()
+		server.Close()
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		if expectedVersion := (<-requests).GetExpectedVersion(); expectedVersion != test.expected {
+			t.Fatalf("Expected %v got %v", test.expected, expectedVersion)
+		}
+	}
+}
+
+func TestAppendJSON(t *testing.T) {
+	requests := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	defer server.Close()
+	defer conn.Close()
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(requests))
+
+	data := struct {
+		OrderID string `json:"orderId"`
+	}{"order-1"}
+	metadata := map[string]string{"userId": "user-1"}
+	if _, err := conn.AppendJSON("order-1", goes.ExpectedVersionNoStream, "OrderPlaced", data, metadata); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	if len(request.Events) != 1 {
+		t.Fatalf("Expected %v got %v", 1, len(request.Events))
+	}
+	evnt := request.Events[0]
+	if evnt.GetEventType() != "OrderPlaced" || len(evnt.GetEventId()) != 16 {
+		t.Fatalf("Expected an OrderPlaced event with an id got %+v", evnt)
+	}
+	if string(evnt.GetData()) != `{"orderId":"order-1"}` || evnt.GetDataContentType() != 1 {
+		t.Fatalf("Expected %v as json got %s", `{"orderId":"order-1"}`, evnt.GetData())
+	}
+	if string(evnt.GetMetadata()) != `{"userId":"user-1"}` || evnt.GetMetadataContentType() != 1 {
+		t.Fatalf("Expected %v as json got %s", `{"userId":"user-1"}`, evnt.GetMetadata())
+	}
+}
+
+func TestAppendJSON_WithoutMetadata(t *testing.T) {
+	requests := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	defer server.Close()
+	defer conn.Close()
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(requests))
+
+	if _, err := conn.AppendJSON("order-1", goes.ExpectedVersionAny, "OrderPlaced", map[string]string{}, nil); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	evnt := (<-requests).Events[0]
+	if len(evnt.GetMetadata()) != 0 || evnt.GetMetadataContentType() != 0 {
+		t.Fatalf("Expected no metadata got %s", evnt.GetMetadata())
+	}
+}
+
+func TestWriteEvents_WithBeforeWrite(t *testing.T) {
+	requests := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	defer server.Close()
+	defer conn.Close()
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(requests))
+	conn.Config.BeforeWrite = func(evnt *goes.EventData) {
+		metadata := map[string]string{}
+		json.Unmarshal(evnt.Metadata, &metadata)
+		metadata["correlationId"] = "correlation-1"
+		evnt.Metadata, _ = json.Marshal(metadata)
+	}
+
+	evnt := createTestEventData()
+	evnt.Metadata = []byte(`{"userId":"user-1"}`)
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{evnt}); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	expected := `{"correlationId":"correlation-1","userId":"user-1"}`
+	if metadata := string((<-requests).Events[0].GetMetadata()); metadata != expected {
+		t.Fatalf("Expected %v got %v", expected, metadata)
+	}
+	if string(evnt.Metadata) != `{"userId":"user-1"}` {
+		t.Fatalf("Expected the caller's event to be left as is got %s", evnt.Metadata)
+	}
+}
+
+func TestWriteEvents_RetriesOnlyTheTimeouts(t *testing.T) {
+	for _, test := range []struct {
+		result   protobuf.OperationResult
+		attempts int
+		err      error
+	}{
+		{protobuf.OperationResult_PrepareTimeout, 4, goes.ErrRetryLimitReached},
+		{protobuf.OperationResult_CommitTimeout, 4, goes.ErrRetryLimitReached},
+		{protobuf.OperationResult_ForwardTimeout, 4, goes.ErrRetryLimitReached},
+		{protobuf.OperationResult_StreamDeleted, 1, goes.ErrStreamDeleted},
+		{protobuf.OperationResult_InvalidTransaction, 1, goes.ErrInvalidTransaction},
+		{protobuf.OperationResult_AccessDenied, 1, goes.ErrAccessDenied},
+	} {
+		response := marshalTestMessage(t, &protobuf.WriteEventsCompleted{
+			Result:           test.result.Enum(),
+			FirstEventNumber: proto.Int32(0),
+			LastEventNumber:  proto.Int32(0),
+		})
+		attempts := make(chan int, 1)
+		config := goes.NewConfiguration()
+		config.MaxOperationRetries = 3
+		conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+			writes := 0
+			for {
+				pkg, err := readTestPackage(socket)
+				if err != nil {
+					attempts <- writes
+					return
+				}
+				writes++
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       writeEventsCompletedCommand,
+					CorrelationID: pkg.CorrelationID,
+					Data:          response,
+				}))
+			}
+		})
+
+		_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{createTestEventData()})
+		if !errors.Is(err, test.err) {
+			t.Fatalf("Expected %v for %v got %v", test.err, test.result, err)
+		}
+		if goes.IsRetryable(err) {
+			t.Fatalf("Expected %v not to be retryable once the retries are exhausted", err)
+		}
+		if exhausted, ok := err.(*goes.ErrRetriesExhausted); ok && exhausted.Retries != test.attempts-1 {
+			t.Fatalf("Expected %v retries got %v", test.attempts-1, exhausted.Retries)
+		}
+		conn.Close()
+		if writes := <-attempts; writes != test.attempts {
+			t.Fatalf("Expected %v attempts for %v got %v", test.attempts, test.result, writes)
+		}
+		listener.Close()
+	}
+}
+
+func TestWriteEvents_WithoutRetries(t *testing.T) {
+	config, err := goes.BuildConfiguration(goes.WithAddress("127.0.0.1", 1113), goes.WithRetryPolicy(0, 1))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if config.MaxOperationRetries != 0 {
+		t.Fatalf("Expected %v got %v", 0, config.MaxOperationRetries)
+	}
+	conn, server := startTestFakeServer(t, config)
+	defer server.Close()
+	defer conn.Close()
+	writes := make(chan *protobuf.WriteEvents, 2)
+	server.Handle(fakeserver.WriteEvents, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		writes <- &protobuf.WriteEvents{}
+		serverConn.Respond(pkg, fakeserver.WriteEventsCompleted, &protobuf.WriteEventsCompleted{
+			Result:           protobuf.OperationResult_CommitTimeout.Enum(),
+			FirstEventNumber: proto.Int32(0),
+			LastEventNumber:  proto.Int32(0),
+		})
+	})
+
+	_, err = conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{createTestEventData()})
+	exhausted, ok := err.(*goes.ErrRetriesExhausted)
+	if !ok {
+		t.Fatalf("Expected %T got %v", exhausted, err)
+	}
+	if exhausted.Retries != 0 || exhausted.Err != goes.ErrCommitTimeout {
+		t.Fatalf("Expected %v after %v retries got %v after %v", goes.ErrCommitTimeout, 0, exhausted.Err, exhausted.Retries)
+	}
+	if len(writes) != 1 {
+		t.Fatalf("Expected %v writes got %v", 1, len(writes))
+	}
+}
+
+const deleteStreamCompletedCommand byte = 0x8B
+
+func TestLegacyWrites_WhenTheRetriesAreExhausted(t *testing.T) {
+	writeCompleted := marshalTestMessage(t, &protobuf.WriteEventsCompleted{
+		Result:           protobuf.OperationResult_CommitTimeout.Enum(),
+		FirstEventNumber: proto.Int32(0),
+		LastEventNumber:  proto.Int32(0),
+	})
+	deleteCompleted := marshalTestMessage(t, &protobuf.DeleteStreamCompleted{
+		Result: protobuf.OperationResult_CommitTimeout.Enum(),
+	})
+	for _, test := range []struct {
+		name      string
+		completed byte
+		response  []byte
+		write     func(conn *goes.EventStoreConnection) error
+	}{
+		{"AppendToStream", writeEventsCompletedCommand, writeCompleted, func(conn *goes.EventStoreConnection) error {
+			_, err := goes.AppendToStream(conn, "testStream", goes.ExpectedVersionAny, []goes.Event{createTestEvent()})
+			return err
+		}},
+		{"DeleteStream", deleteStreamCompletedCommand, deleteCompleted, func(conn *goes.EventStoreConnection) error {
+			_, err := goes.DeleteStream(conn, "testStream", goes.ExpectedVersionAny, false, false)
+			return err
+		}},
+	} {
+		attempts := make(chan int, 1)
+		config := goes.NewConfiguration()
+		config.MaxOperationRetries = 2
+		conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+			writes := 0
+			for {
+				pkg, err := readTestPackage(socket)
+				if err != nil {
+					attempts <- writes
+					return
+				}
+				writes++
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       test.completed,
+					CorrelationID: pkg.CorrelationID,
+					Data:          test.response,
+				}))
+			}
+		})
+
+		err := test.write(conn)
+		exhausted, ok := err.(*goes.ErrRetriesExhausted)
+		if !ok || !errors.Is(err, goes.ErrRetryLimitReached) || exhausted.Retries != 2 || exhausted.Err != goes.ErrCommitTimeout {
+			t.Fatalf("Expected %v after %v retries for %v got %v", goes.ErrCommitTimeout, 2, test.name, err)
+		}
+		conn.Close()
+		if writes := <-attempts; writes != 3 {
+			t.Fatalf("Expected %v attempts for %v got %v", 3, test.name, writes)
+		}
+		listener.Close()
+	}
+}
+
+func TestWriteEvents_WithWrongExpectedVersionIsNotRetried(t *testing.T) {
+	response := marshalTestMessage(t, &protobuf.WriteEventsCompleted{
+		Result:           protobuf.OperationResult_WrongExpectedVersion.Enum(),
+		FirstEventNumber: proto.Int32(0),
+		LastEventNumber:  proto.Int32(0),
+	})
+	version := marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+		Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+		NextEventNumber:    proto.Int32(4),
+		LastEventNumber:    proto.Int32(5),
+		IsEndOfStream:      proto.Bool(false),
+		LastCommitPosition: proto.Int64(0),
+	})
+	attempts := make(chan int, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		writes := 0
+		for {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				attempts <- writes
+				return
+			}
+			if pkg.Command == readStreamEventsBackwardCommand {
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       readStreamEventsBackwardCompletedCommand,
+					CorrelationID: pkg.CorrelationID,
+					Data:          version,
+				}))
+				continue
+			}
+			writes++
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       writeEventsCompletedCommand,
+				CorrelationID: pkg.CorrelationID,
+				Data:          response,
+			}))
+		}
+	})
+	defer listener.Close()
+
+	_, err := conn.WriteEvents("testStream", 3, []goes.EventData{createTestEventData()})
+	wrongExpectedVersion, ok := err.(*goes.ErrWrongExpectedVersion)
+	if !ok {
+		t.Fatalf("Expected a wrong expected version error got %v", err)
+	}
+	if wrongExpectedVersion.CurrentVersion != 5 {
+		t.Fatalf("Expected %v got %v", 5, wrongExpectedVersion.CurrentVersion)
+	}
+	conn.Close()
+	if writes := <-attempts; writes != 1 {
+		t.Fatalf("Expected %v attempts got %v", 1, writes)
+	}
+}
+
+func TestWriteEvents_WithWrongExpectedVersionWhenTheLookupFails(t *testing.T) {
+	response := marshalTestMessage(t, &protobuf.WriteEventsCompleted{
+		Result:           protobuf.OperationResult_WrongExpectedVersion.Enum(),
+		FirstEventNumber: proto.Int32(0),
+		LastEventNumber:  proto.Int32(0),
+	})
+	version := marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+		Result:             protobuf.ReadStreamEventsCompleted_AccessDenied.Enum(),
+		NextEventNumber:    proto.Int32(0),
+		LastEventNumber:    proto.Int32(0),
+		IsEndOfStream:      proto.Bool(false),
+		LastCommitPosition: proto.Int64(0),
+	})
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		for {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command == readStreamEventsBackwardCommand {
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       readStreamEventsBackwardCompletedCommand,
+					CorrelationID: pkg.CorrelationID,
+					Data:          version,
+				}))
+				continue
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       writeEventsCompletedCommand,
+				CorrelationID: pkg.CorrelationID,
+				Data:          response,
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := conn.WriteEvents("testStream", 3, []goes.EventData{createTestEventData()})
+	wrongExpectedVersion, ok := err.(*goes.ErrWrongExpectedVersion)
+	if !ok {
+		t.Fatalf("Expected a wrong expected version error got %v", err)
+	}
+	if wrongExpectedVersion.CurrentVersion != goes.CurrentVersionUnknown {
+		t.Fatalf("Expected %v got %v", int64(goes.CurrentVersionUnknown), wrongExpectedVersion.CurrentVersion)
+	}
+	if wrongExpectedVersion.LookupErr != goes.ErrAccessDenied {
+		t.Fatalf("Expected %v got %v", goes.ErrAccessDenied, wrongExpectedVersion.LookupErr)
+	}
+}
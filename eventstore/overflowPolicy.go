@@ -0,0 +1,87 @@
+package goes
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+// OverflowPolicy decides what happens when a subscription's buffer is full because its handler cannot keep up
+type OverflowPolicy int
+
+const (
+	// OverflowPolicyBlock keeps every message, the messages that do not fit in the buffer are queued until the handler
+	// makes room without holding up the other subscriptions and operations on the connection
+	OverflowPolicyBlock OverflowPolicy = iota
+	// OverflowPolicyDropOldest discards the oldest buffered message to make room for the new one
+	OverflowPolicyDropOldest
+	// OverflowPolicyDropSubscription drops the subscription, which then fails with ErrSubscriptionBufferOverflow
+	OverflowPolicyDropSubscription
+)
+
+func (policy OverflowPolicy) String() string {
+	switch policy {
+	case OverflowPolicyBlock:
+		return "Block"
+	case OverflowPolicyDropOldest:
+		return "DropOldest"
+	case OverflowPolicyDropSubscription:
+		return "DropSubscription"
+	}
+	return "Unknown"
+}
+
+func (connection *EventStoreConnection) subscriptionBufferSize() int {
+	if connection.Config.SubscriptionBufferSize <= 0 {
+		return 1
+	}
+	return connection.Config.SubscriptionBufferSize
+}
+
+// deliverToSubscription passes the package to the subscription, applying the SubscriptionOverflowPolicy when its buffer is full
+func (connection *EventStoreConnection) deliverToSubscription(subscription *Subscription, channel chan<- TCPPackage, pkg TCPPackage) {
+	policy := connection.Config.SubscriptionOverflowPolicy
+	if policy == OverflowPolicyBlock {
+		subscription.enqueue(pkg)
+		return
+	}
+	for {
+		select {
+		case channel <- pkg:
+			return
+		default:
+		}
+		if policy == OverflowPolicyDropSubscription {
+			connection.dropOverflowingSubscription(subscription)
+			return
+		}
+		select {
+		case <-subscription.Channel:
+		default:
+		}
+	}
+}
+
+// dropOverflowingSubscription stops delivering to the subscription and drops it once its handler has processed the buffered messages
+func (connection *EventStoreConnection) dropOverflowingSubscription(subscription *Subscription) {
+	correlationID := subscription.correlationID()
+	connection.reportError(fmt.Errorf("dropping subscription %v: %s", correlationID, ErrSubscriptionBufferOverflow.Error()))
+	subscription.unregister()
+	subscription.dropping(SubscriptionDropReasonBufferOverflow, ErrSubscriptionBufferOverflow)
+	data, err := proto.Marshal(&protobuf.SubscriptionDropped{Reason: protobuf.SubscriptionDropped_Unsubscribed.Enum()})
+	if err != nil {
+		connection.reportError(fmt.Errorf("failed to marshal subscription dropped: %s", err.Error()))
+	}
+	dropped, _ := newPackage(subscriptionDropped, data, correlationID.Bytes(), "", "")
+	go func() {
+		err := sendSubscriptionPackage(subscription, unsubscribeFromStream, &protobuf.UnsubscribeFromStream{})
+		if err != nil {
+			connection.logger().Errorf("failed to unsubscribe the dropped subscription %v: %s", correlationID, err.Error())
+		}
+		select {
+		case subscription.Channel <- dropped:
+		case <-subscription.stopped:
+		}
+	}()
+}
@@ -0,0 +1,100 @@
+package goes_test
+
+import (
+	"testing"
+	"time"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+)
+
+// writeTestOverflow writes the events to the stream and pings, the fake server sends the events to the subscription before
+// it answers the ping, so that they have been handed to the subscription when it returns
+func writeTestOverflow(t *testing.T, conn *goes.EventStoreConnection, events int) {
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, createTestBatch(events)); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+}
+
+func TestSubscription_WithOverflowPolicyDropOldest(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.SubscriptionBufferSize = 2
+	config.SubscriptionOverflowPolicy = goes.OverflowPolicyDropOldest
+	config.KeepAliveInterval = 0
+	conn, server := startTestFakeServer(t, config)
+	defer server.Close()
+	defer conn.Close()
+
+	release := make(chan struct{})
+	received := make(chan int64, 5)
+	_, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {
+		<-release
+		received <- evnt.Event.EventNumber
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	writeTestOverflow(t, conn, 5)
+	close(release)
+
+	count := 0
+	for {
+		select {
+		case eventNumber := <-received:
+			count++
+			if eventNumber != 4 {
+				continue
+			}
+			// one event can be in the handler while the others wait in the buffer
+			if count > 1+config.SubscriptionBufferSize {
+				t.Fatalf("Expected the oldest events to be dropped got %d events", count)
+			}
+			return
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the latest event to be delivered")
+		}
+	}
+}
+
+func TestSubscription_WithOverflowPolicyDropSubscription(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.SubscriptionBufferSize = 2
+	config.SubscriptionOverflowPolicy = goes.OverflowPolicyDropSubscription
+	config.KeepAliveInterval = 0
+	conn, server := startTestFakeServer(t, config)
+	defer server.Close()
+	defer conn.Close()
+	unsubscribed := make(chan bool, 1)
+	unsubscribe := server.Handler(fakeserver.UnsubscribeFromStream)
+	server.Handle(fakeserver.UnsubscribeFromStream, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		unsubscribed <- true
+		unsubscribe(serverConn, pkg)
+	})
+
+	release := make(chan struct{})
+	subscription, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {
+		<-release
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	writeTestOverflow(t, conn, 5)
+	close(release)
+
+	select {
+	case <-subscription.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the subscription to be dropped")
+	}
+	if err := subscription.Err(); err != goes.ErrSubscriptionBufferOverflow {
+		t.Fatalf("Expected %v got %v", goes.ErrSubscriptionBufferOverflow, err)
+	}
+	select {
+	case <-unsubscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the server to be asked to drop the subscription")
+	}
+}
@@ -0,0 +1,122 @@
+package goes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+// RetryPolicy decides what happens to the events of a persistent subscription that are nacked for a retry
+type RetryPolicy struct {
+	// MaxAttempts is the number of times an event is delivered before it is parked instead of retried, zero retries it
+	// for as long as the server redelivers it
+	MaxAttempts int
+	// Delay is how long to wait before an event is nacked for a retry. The events that follow are not handled in the
+	// meantime, which keeps a failing consumer from spinning on the events the server redelivers.
+	Delay time.Duration
+}
+
+// SetRetryPolicy sets the policy the events that are nacked with NackActionRetry are subject to. The zero policy nacks
+// them for a retry straight away, every time.
+func (subscription *PersistentSubscription) SetRetryPolicy(policy RetryPolicy) {
+	subscription.mutex.Lock()
+	subscription.retryPolicy = policy
+	subscription.mutex.Unlock()
+}
+
+// Attempt returns how many times the event has been delivered since it was last acknowledged, parked or skipped. The
+// server does not tell how often it delivered an event, the deliveries are counted by the subscription itself, so they
+// start from one again after connecting to the group anew.
+func (subscription *PersistentSubscription) Attempt(eventID uuid.UUID) int {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	return subscription.attempts[eventID]
+}
+
+// ConsumePersistentSubscription connects to the persistent subscription group and passes each event to the handler
+// along with how many times it has been delivered, starting from one. The events the handler returns nil for are
+// acknowledged, the others are retried according to the policy and parked once they have been delivered
+// policy.MaxAttempts times.
+func (connection *EventStoreConnection) ConsumePersistentSubscription(stream string, groupName string, bufferSize int, policy RetryPolicy, handler func(evnt ResolvedEvent, attempt int) error, options ...OperationOption) (*PersistentSubscription, error) {
+	return connection.ConsumePersistentSubscriptionWithContext(context.Background(), stream, groupName, bufferSize, policy, handler, options...)
+}
+
+// ConsumePersistentSubscriptionWithContext is like ConsumePersistentSubscription but gives up when ctx is cancelled
+func (connection *EventStoreConnection) ConsumePersistentSubscriptionWithContext(ctx context.Context, stream string, groupName string, bufferSize int, policy RetryPolicy, handler func(evnt ResolvedEvent, attempt int) error, options ...OperationOption) (*PersistentSubscription, error) {
+	return connectPersistentSubscription(ctx, connection, stream, groupName, bufferSize, connection.credentials(options), func(subscription *Subscription) {
+		subscription.retryPolicy = policy
+		subscription.persistentHandler = handler
+		subscription.onDropped = onDroppedOption(options)
+	})
+}
+
+// handlePersistentEvent passes the event to the handler and acknowledges or retries it depending on the outcome
+func (subscription *Subscription) handlePersistentEvent(evnt ResolvedEvent, eventID uuid.UUID, attempt int) {
+	var err error
+	if handlerErr := subscription.persistentHandler(evnt, attempt); handlerErr == nil {
+		err = ackEvents(subscription, []uuid.UUID{eventID})
+	} else {
+		err = retryEvents(subscription, []uuid.UUID{eventID})
+	}
+	if err != nil {
+		subscription.Connection.reportError(fmt.Errorf("failed to settle event %v: %s", eventID, err.Error()))
+	}
+}
+
+// countAttempt records another delivery of the event and returns how many times it has been delivered
+func (subscription *Subscription) countAttempt(eventID uuid.UUID) int {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	if subscription.attempts == nil {
+		subscription.attempts = map[uuid.UUID]int{}
+	}
+	subscription.attempts[eventID]++
+	return subscription.attempts[eventID]
+}
+
+// forgetAttempts stops counting the deliveries of events the server will not deliver again
+func (subscription *Subscription) forgetAttempts(eventIDs []uuid.UUID) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	for _, eventID := range eventIDs {
+		delete(subscription.attempts, eventID)
+	}
+}
+
+// retryEvents parks the events that have been delivered as often as the retry policy allows and nacks the others for a
+// retry once the policy's delay has passed
+func retryEvents(subscription *Subscription, eventIDs []uuid.UUID) error {
+	subscription.mutex.Lock()
+	policy := subscription.retryPolicy
+	var park, retry []uuid.UUID
+	for _, eventID := range eventIDs {
+		if policy.MaxAttempts > 0 && subscription.attempts[eventID] >= policy.MaxAttempts {
+			park = append(park, eventID)
+		} else {
+			retry = append(retry, eventID)
+		}
+	}
+	subscription.mutex.Unlock()
+
+	if len(park) > 0 {
+		if err := nackEvents(subscription, park, NackActionPark); err != nil {
+			return err
+		}
+	}
+	if len(retry) == 0 {
+		return nil
+	}
+	if policy.Delay > 0 {
+		timer := time.NewTimer(policy.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-subscription.done:
+			// the server redelivers the events to another consumer once the subscription has been dropped
+			return nil
+		}
+	}
+	return nackEvents(subscription, retry, NackActionRetry)
+}
@@ -0,0 +1,85 @@
+package goes_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+const persistentSubscriptionStreamEventAppearedCommand fakeserver.Command = 0xC7
+
+// startTestRedeliveringServer confirms the persistent subscription and delivers the same event again every time it is
+// nacked for a retry, the actions of the nacks are sent to nacked. The nacks carry the correlation id of the subscription,
+// so the event is redelivered as the response to the nack.
+func startTestRedeliveringServer(t *testing.T, nacked chan protobuf.PersistentSubscriptionNakEvents_NakAction) (*goes.EventStoreConnection, *fakeserver.Server) {
+	connected := make(chan fakeserver.Package, 1)
+	conn, server := startTestPersistentSubscriptionServer(t, connected)
+	appeared := &protobuf.PersistentSubscriptionStreamEventAppeared{
+		Event: &protobuf.ResolvedIndexedEvent{Event: newTestEventRecord("testStream", 0)},
+	}
+	deliver := func(serverConn *fakeserver.Conn, request fakeserver.Package) {
+		serverConn.Respond(request, persistentSubscriptionStreamEventAppearedCommand, appeared)
+	}
+	connect := server.Handler(connectToPersistentSubscriptionCommand)
+	server.Handle(connectToPersistentSubscriptionCommand, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		connect(serverConn, pkg)
+		deliver(serverConn, pkg)
+	})
+	server.Handle(persistentSubscriptionNakEventsCommand, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		nak := &protobuf.PersistentSubscriptionNakEvents{}
+		if err := proto.Unmarshal(pkg.Data, nak); err != nil {
+			return
+		}
+		nacked <- nak.GetAction()
+		if nak.GetAction() == protobuf.PersistentSubscriptionNakEvents_Retry {
+			deliver(serverConn, pkg)
+		}
+	})
+	return conn, server
+}
+
+func TestConsumePersistentSubscription_ParksTheEventAfterMaxAttempts(t *testing.T) {
+	nacked := make(chan protobuf.PersistentSubscriptionNakEvents_NakAction, 10)
+	conn, server := startTestRedeliveringServer(t, nacked)
+	defer server.Close()
+	defer conn.Close()
+
+	attempts := make(chan int, 10)
+	policy := goes.RetryPolicy{MaxAttempts: 3, Delay: 10 * time.Millisecond}
+	_, err := conn.ConsumePersistentSubscription("testStream", "testGroup", 10, policy, func(evnt goes.ResolvedEvent, attempt int) error {
+		attempts <- attempt
+		return errors.New("failed to process the event")
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	expected := []protobuf.PersistentSubscriptionNakEvents_NakAction{
+		protobuf.PersistentSubscriptionNakEvents_Retry,
+		protobuf.PersistentSubscriptionNakEvents_Retry,
+		protobuf.PersistentSubscriptionNakEvents_Park,
+	}
+	for i, action := range expected {
+		select {
+		case attempt := <-attempts:
+			if attempt != i+1 {
+				t.Fatalf("Expected %v got %v", i+1, attempt)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the event to be delivered")
+		}
+		select {
+		case nack := <-nacked:
+			if nack != action {
+				t.Fatalf("Expected %v got %v", action, nack)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the event to be nacked")
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package goes
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// NackAction tells the server what to do with events that a persistent subscription consumer could not process
+type NackAction int32
+
+const (
+	// NackActionPark moves the events to the parked message stream of the subscription group
+	NackActionPark = NackAction(protobuf.PersistentSubscriptionNakEvents_Park)
+	// NackActionRetry redelivers the events
+	NackActionRetry = NackAction(protobuf.PersistentSubscriptionNakEvents_Retry)
+	// NackActionSkip discards the events
+	NackActionSkip = NackAction(protobuf.PersistentSubscriptionNakEvents_Skip)
+	// NackActionStop stops the subscription
+	NackActionStop = NackAction(protobuf.PersistentSubscriptionNakEvents_Stop)
+)
+
+// PersistentSubscription is a connection to a persistent subscription group. Events delivered to it have to be acknowledged
+// with Ack, or rejected with Nack, unless the subscription was connected with auto acknowledgement.
+type PersistentSubscription struct {
+	*Subscription
+	SubscriptionID string
+}
+
+// Ack tells the server that the events have been processed
+func (subscription *PersistentSubscription) Ack(eventIDs []uuid.UUID) error {
+	return ackEvents(subscription.Subscription, eventIDs)
+}
+
+// Nack tells the server that the events could not be processed and what should be done with them. Events that are
+// retried are subject to the retry policy, see SetRetryPolicy.
+func (subscription *PersistentSubscription) Nack(eventIDs []uuid.UUID, action NackAction) error {
+	if action == NackActionRetry {
+		return retryEvents(subscription.Subscription, eventIDs)
+	}
+	return nackEvents(subscription.Subscription, eventIDs, action)
+}
+
+func nackEvents(subscription *Subscription, eventIDs []uuid.UUID, action NackAction) error {
+	if action != NackActionRetry {
+		subscription.forgetAttempts(eventIDs)
+	}
+	nackAction := protobuf.PersistentSubscriptionNakEvents_NakAction(action)
+	nackData := &protobuf.PersistentSubscriptionNakEvents{
+		SubscriptionId:    proto.String(subscription.subscriptionID),
+		ProcessedEventIds: encodeEventIDs(eventIDs),
+		Action:            &nackAction,
+	}
+	return sendSubscriptionPackage(subscription, persistentSubscriptionNakEvents, nackData)
+}
+
+func ackEvents(subscription *Subscription, eventIDs []uuid.UUID) error {
+	subscription.forgetAttempts(eventIDs)
+	ackData := &protobuf.PersistentSubscriptionAckEvents{
+		SubscriptionId:    proto.String(subscription.subscriptionID),
+		ProcessedEventIds: encodeEventIDs(eventIDs),
+	}
+	return sendSubscriptionPackage(subscription, persistentSubscriptionAckEvents, ackData)
+}
+
+func encodeEventIDs(eventIDs []uuid.UUID) [][]byte {
+	encoded := make([][]byte, 0, len(eventIDs))
+	for _, eventID := range eventIDs {
+		encoded = append(encoded, EncodeNetUUID(eventID.Bytes()))
+	}
+	return encoded
+}
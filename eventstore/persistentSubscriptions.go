@@ -0,0 +1,179 @@
+package goes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+var (
+	// ErrPersistentSubscriptionAlreadyExists is returned when creating a persistent subscription group that already exists on the stream
+	ErrPersistentSubscriptionAlreadyExists = errors.New("persistent subscription already exists")
+	// ErrPersistentSubscriptionDoesNotExist is returned when updating or deleting a persistent subscription group that does not exist on the stream
+	ErrPersistentSubscriptionDoesNotExist = errors.New("persistent subscription does not exist")
+)
+
+// CreatePersistentSubscription creates a persistent subscription group on the stream
+func (connection *EventStoreConnection) CreatePersistentSubscription(stream string, groupName string, settings PersistentSubscriptionSettings, options ...OperationOption) error {
+	return connection.CreatePersistentSubscriptionWithContext(context.Background(), stream, groupName, settings, options...)
+}
+
+// CreatePersistentSubscriptionWithContext is like CreatePersistentSubscription but gives up when ctx is cancelled
+func (connection *EventStoreConnection) CreatePersistentSubscriptionWithContext(ctx context.Context, stream string, groupName string, settings PersistentSubscriptionSettings, options ...OperationOption) error {
+	if err := validateConsumerStrategy(settings.NamedConsumerStrategy); err != nil {
+		return err
+	}
+	subscriptionData := &protobuf.CreatePersistentSubscription{
+		SubscriptionGroupName:      proto.String(groupName),
+		EventStreamId:              proto.String(stream),
+		ResolveLinkTos:             proto.Bool(settings.ResolveLinkTos),
+		StartFrom:                  proto.Int(settings.StartFrom),
+		MessageTimeoutMilliseconds: proto.Int(settings.MessageTimeoutMilliseconds),
+		RecordStatistics:           proto.Bool(settings.RecordStatistics),
+		LiveBufferSize:             proto.Int(settings.LiveBufferSize),
+		ReadBatchSize:              proto.Int(settings.ReadBatchSize),
+		BufferSize:                 proto.Int(settings.BufferSize),
+		MaxRetryCount:              proto.Int(settings.MaxRetryCount),
+		PreferRoundRobin:           proto.Bool(settings.PreferRoundRobit),
+		CheckpointAfterTime:        proto.Int(settings.CheckpointAfterTime),
+		CheckpointMaxCount:         proto.Int(settings.CheckpointMaxCount),
+		CheckpointMinCount:         proto.Int(settings.CheckpointMinCount),
+		SubscriberMaxCount:         proto.Int(settings.SubscriberMaxCount),
+		NamedConsumerStrategy:      proto.String(settings.NamedConsumerStrategy),
+	}
+	message := &protobuf.CreatePersistentSubscriptionCompleted{}
+	err := connection.persistentSubscriptionOperation(ctx, createPersistentSubscription, createPersistentSubscriptionCompleted, subscriptionData, message, connection.credentials(options))
+	if err != nil {
+		return err
+	}
+
+	switch message.GetResult() {
+	case protobuf.CreatePersistentSubscriptionCompleted_Success:
+		return nil
+	case protobuf.CreatePersistentSubscriptionCompleted_AlreadyExists:
+		return ErrPersistentSubscriptionAlreadyExists
+	case protobuf.CreatePersistentSubscriptionCompleted_AccessDenied:
+		return ErrAccessDenied
+	}
+	return errors.New(message.GetReason())
+}
+
+// UpdatePersistentSubscription replaces the settings of an existing persistent subscription group on the stream
+func (connection *EventStoreConnection) UpdatePersistentSubscription(stream string, groupName string, settings PersistentSubscriptionSettings, options ...OperationOption) error {
+	return connection.UpdatePersistentSubscriptionWithContext(context.Background(), stream, groupName, settings, options...)
+}
+
+// UpdatePersistentSubscriptionWithContext is like UpdatePersistentSubscription but gives up when ctx is cancelled
+func (connection *EventStoreConnection) UpdatePersistentSubscriptionWithContext(ctx context.Context, stream string, groupName string, settings PersistentSubscriptionSettings, options ...OperationOption) error {
+	if err := validateConsumerStrategy(settings.NamedConsumerStrategy); err != nil {
+		return err
+	}
+	subscriptionData := &protobuf.UpdatePersistentSubscription{
+		SubscriptionGroupName:      proto.String(groupName),
+		EventStreamId:              proto.String(stream),
+		ResolveLinkTos:             proto.Bool(settings.ResolveLinkTos),
+		StartFrom:                  proto.Int(settings.StartFrom),
+		MessageTimeoutMilliseconds: proto.Int(settings.MessageTimeoutMilliseconds),
+		RecordStatistics:           proto.Bool(settings.RecordStatistics),
+		LiveBufferSize:             proto.Int(settings.LiveBufferSize),
+		ReadBatchSize:              proto.Int(settings.ReadBatchSize),
+		BufferSize:                 proto.Int(settings.BufferSize),
+		MaxRetryCount:              proto.Int(settings.MaxRetryCount),
+		PreferRoundRobin:           proto.Bool(settings.PreferRoundRobit),
+		CheckpointAfterTime:        proto.Int(settings.CheckpointAfterTime),
+		CheckpointMaxCount:         proto.Int(settings.CheckpointMaxCount),
+		CheckpointMinCount:         proto.Int(settings.CheckpointMinCount),
+		SubscriberMaxCount:         proto.Int(settings.SubscriberMaxCount),
+		NamedConsumerStrategy:      proto.String(settings.NamedConsumerStrategy),
+	}
+	message := &protobuf.UpdatePersistentSubscriptionCompleted{}
+	err := connection.persistentSubscriptionOperation(ctx, updatePersistentSubscription, updatePersistentSubscriptionCompleted, subscriptionData, message, connection.credentials(options))
+	if err != nil {
+		return err
+	}
+
+	switch message.GetResult() {
+	case protobuf.UpdatePersistentSubscriptionCompleted_Success:
+		return nil
+	case protobuf.UpdatePersistentSubscriptionCompleted_DoesNotExist:
+		return ErrPersistentSubscriptionDoesNotExist
+	case protobuf.UpdatePersistentSubscriptionCompleted_AccessDenied:
+		return ErrAccessDenied
+	}
+	return errors.New(message.GetReason())
+}
+
+// DeletePersistentSubscription deletes the persistent subscription group from the stream
+func (connection *EventStoreConnection) DeletePersistentSubscription(stream string, groupName string, options ...OperationOption) error {
+	return connection.DeletePersistentSubscriptionWithContext(context.Background(), stream, groupName, options...)
+}
+
+// DeletePersistentSubscriptionWithContext is like DeletePersistentSubscription but gives up when ctx is cancelled
+func (connection *EventStoreConnection) DeletePersistentSubscriptionWithContext(ctx context.Context, stream string, groupName string, options ...OperationOption) error {
+	subscriptionData := &protobuf.DeletePersistentSubscription{
+		SubscriptionGroupName: proto.String(groupName),
+		EventStreamId:         proto.String(stream),
+	}
+	message := &protobuf.DeletePersistentSubscriptionCompleted{}
+	err := connection.persistentSubscriptionOperation(ctx, deletePersistentSubscription, deletePersistentSubscriptionCompleted, subscriptionData, message, connection.credentials(options))
+	if err != nil {
+		return err
+	}
+
+	switch message.GetResult() {
+	case protobuf.DeletePersistentSubscriptionCompleted_Success:
+		return nil
+	case protobuf.DeletePersistentSubscriptionCompleted_DoesNotExist:
+		return ErrPersistentSubscriptionDoesNotExist
+	case protobuf.DeletePersistentSubscriptionCompleted_AccessDenied:
+		return ErrAccessDenied
+	}
+	return errors.New(message.GetReason())
+}
+
+// ReadParkedEvents reads up to count of the events that were parked by the persistent subscription group on the stream,
+// starting at and including the start event number of the parked stream. The parked stream holds links to the events,
+// which are resolved, so the Event of each ResolvedEvent is the parked event in its own stream and its Link is the entry
+// in the parked stream, whose event number is the one to continue reading from. Reading the parked stream requires the
+// credentials of an admin.
+func (connection *EventStoreConnection) ReadParkedEvents(stream string, groupName string, start int64, count int, options ...OperationOption) (*StreamEventsSlice, error) {
+	return connection.ReadParkedEventsWithContext(context.Background(), stream, groupName, start, count, options...)
+}
+
+// ReadParkedEventsWithContext is like ReadParkedEvents but gives up when ctx is cancelled
+func (connection *EventStoreConnection) ReadParkedEventsWithContext(ctx context.Context, stream string, groupName string, start int64, count int, options ...OperationOption) (*StreamEventsSlice, error) {
+	return connection.ReadStreamEventsForwardWithContext(ctx, ParkedStreamOf(stream, groupName), start, count, true, options...)
+}
+
+// ParkedStreamOf returns the name of the stream the persistent subscription group on the stream parks its events in
+func ParkedStreamOf(stream string, groupName string) string {
+	return "$persistentsubscription-" + stream + "::" + groupName + "-parked"
+}
+
+func (connection *EventStoreConnection) persistentSubscriptionOperation(ctx context.Context, command Command, completedCommand Command, request proto.Message, response proto.Message, credentials UserCredentials) error {
+	data, err := proto.Marshal(request)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return err
+	}
+
+	pkg, err := newPackage(command, data, uuid.NewV4().Bytes(), credentials.Login, credentials.Password)
+	if err != nil {
+		connection.logger().Errorf("failed to create new persistent subscription package")
+		return err
+	}
+
+	resultPackage, err := performOperation(ctx, connection, pkg, completedCommand)
+	if err != nil {
+		return err
+	}
+	err = proto.Unmarshal(resultPackage.Data, response)
+	if err != nil {
+		connection.logger().Errorf("unmarshaling error: %s", err)
+		return err
+	}
+	return nil
+}
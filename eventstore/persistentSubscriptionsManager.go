@@ -0,0 +1,37 @@
+package goes
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// PersistentSubscriptionsManager manages the persistent subscription groups of a node through its HTTP API, for the
+// operations the TCP protocol does not offer. The requests are authenticated with the Login and Password of the
+// configuration unless an operation is given other credentials with WithCredentials.
+type PersistentSubscriptionsManager struct {
+	api *httpAPI
+}
+
+// persistentSubscriptionStatusErrors are the errors returned for the statuses that are specific to persistent subscriptions
+var persistentSubscriptionStatusErrors = map[int]error{
+	http.StatusNotFound: ErrPersistentSubscriptionDoesNotExist,
+}
+
+// NewPersistentSubscriptionsManager creates a manager for the persistent subscription groups of the node at the Address of
+// the configuration, which serves its HTTP API on httpPort. The requests are sent over https with the TLSConfig of the
+// configuration when UseTLS is set.
+func NewPersistentSubscriptionsManager(config *Configuration, httpPort int) *PersistentSubscriptionsManager {
+	return &PersistentSubscriptionsManager{api: newHTTPAPI(config, httpPort)}
+}
+
+// ReplayParkedEvents has the server deliver the events that were parked by the group on the stream to its subscribers
+// again, the events that are parked once more end up at the end of the parked stream that ReadParkedEvents reads
+func (manager *PersistentSubscriptionsManager) ReplayParkedEvents(stream string, groupName string, options ...OperationOption) error {
+	return manager.ReplayParkedEventsWithContext(context.Background(), stream, groupName, options...)
+}
+
+// ReplayParkedEventsWithContext is like ReplayParkedEvents but gives up when ctx is cancelled
+func (manager *PersistentSubscriptionsManager) ReplayParkedEventsWithContext(ctx context.Context, stream string, groupName string, options ...OperationOption) error {
+	return manager.api.send(ctx, http.MethodPost, "/subscriptions/"+url.PathEscape(stream)+"/"+url.PathEscape(groupName)+"/replayParked", nil, nil, options, persistentSubscriptionStatusErrors)
+}
@@ -0,0 +1,44 @@
+package goes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+func startTestPersistentSubscriptionsServer(t *testing.T, handler http.HandlerFunc) (*goes.PersistentSubscriptionsManager, chan testHTTPRequest, *httptest.Server) {
+	config, port, requests, server := startTestHTTPServer(t, handler)
+	return goes.NewPersistentSubscriptionsManager(config, port), requests, server
+}
+
+func TestPersistentSubscriptionsManager_ReplayParkedEvents(t *testing.T) {
+	manager, requests, server := startTestPersistentSubscriptionsServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+
+	if err := manager.ReplayParkedEvents("order-1", "billing"); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	expected := "/subscriptions/order-1/billing/replayParked"
+	if request.method != http.MethodPost || request.uri != expected {
+		t.Fatalf("Expected %v %v got %v %v", http.MethodPost, expected, request.method, request.uri)
+	}
+	if request.login != "admin" {
+		t.Fatalf("Expected %v got %v", "admin", request.login)
+	}
+}
+
+func TestPersistentSubscriptionsManager_WhenTheGroupDoesNotExist(t *testing.T) {
+	manager, requests, server := startTestPersistentSubscriptionsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+
+	err := manager.ReplayParkedEvents("order-1", "missing")
+	<-requests
+	if err != goes.ErrPersistentSubscriptionDoesNotExist {
+		t.Fatalf("Expected %v got %v", goes.ErrPersistentSubscriptionDoesNotExist, err)
+	}
+}
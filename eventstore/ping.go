@@ -0,0 +1,23 @@
+package goes
+
+import (
+	"context"
+
+	"github.com/satori/go.uuid"
+)
+
+// Ping checks that the server responds on the connection, e.g. for a readiness probe
+func (connection *EventStoreConnection) Ping() error {
+	return connection.PingWithContext(context.Background())
+}
+
+// PingWithContext is like Ping but gives up when ctx is cancelled
+func (connection *EventStoreConnection) PingWithContext(ctx context.Context) error {
+	pkg, err := newPackage(ping, nil, uuid.NewV4().Bytes(), "", "")
+	if err != nil {
+		connection.logger().Errorf("failed to create new ping package")
+		return err
+	}
+	_, err = performOperation(ctx, connection, pkg, pong)
+	return err
+}
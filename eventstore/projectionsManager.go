@@ -0,0 +1,111 @@
+package goes
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ProjectionsManager manages the projections of a node through its HTTP API. The requests are authenticated with the Login
+// and Password of the configuration unless an operation is given other credentials with WithCredentials.
+type ProjectionsManager struct {
+	api *httpAPI
+}
+
+// projectionStatusErrors are the errors returned for the statuses that are specific to projections
+var projectionStatusErrors = map[int]error{
+	http.StatusNotFound: ErrProjectionNotFound,
+}
+
+// NewProjectionsManager creates a manager for the projections of the node at the Address of the configuration, which serves
+// its HTTP API on httpPort. The requests are sent over https with the TLSConfig of the configuration when UseTLS is set.
+func NewProjectionsManager(config *Configuration, httpPort int) *ProjectionsManager {
+	return &ProjectionsManager{api: newHTTPAPI(config, httpPort)}
+}
+
+// CreateContinuous creates a continuous projection that runs the query over the events that have been written and keeps
+// running it over the events that are written from then on. The projection is enabled once it has been created.
+func (manager *ProjectionsManager) CreateContinuous(name string, query string, trackEmittedStreams bool, options ...OperationOption) error {
+	return manager.CreateContinuousWithContext(context.Background(), name, query, trackEmittedStreams, options...)
+}
+
+// CreateContinuousWithContext is like CreateContinuous but gives up when ctx is cancelled
+func (manager *ProjectionsManager) CreateContinuousWithContext(ctx context.Context, name string, query string, trackEmittedStreams bool, options ...OperationOption) error {
+	parameters := url.Values{}
+	parameters.Set("name", name)
+	parameters.Set("type", "JS")
+	parameters.Set("enabled", "true")
+	parameters.Set("emit", "true")
+	parameters.Set("trackemittedstreams", strconv.FormatBool(trackEmittedStreams))
+	return manager.api.send(ctx, http.MethodPost, "/projections/continuous?"+parameters.Encode(), []byte(query), nil, options, projectionStatusErrors)
+}
+
+// Enable starts running the projection
+func (manager *ProjectionsManager) Enable(name string, options ...OperationOption) error {
+	return manager.EnableWithContext(context.Background(), name, options...)
+}
+
+// EnableWithContext is like Enable but gives up when ctx is cancelled
+func (manager *ProjectionsManager) EnableWithContext(ctx context.Context, name string, options ...OperationOption) error {
+	return manager.command(ctx, name, "enable", options)
+}
+
+// Disable stops running the projection, it continues from where it stopped once it is enabled again
+func (manager *ProjectionsManager) Disable(name string, options ...OperationOption) error {
+	return manager.DisableWithContext(context.Background(), name, options...)
+}
+
+// DisableWithContext is like Disable but gives up when ctx is cancelled
+func (manager *ProjectionsManager) DisableWithContext(ctx context.Context, name string, options ...OperationOption) error {
+	return manager.command(ctx, name, "disable", options)
+}
+
+// Reset discards the state of the projection so that it runs over all the events again
+func (manager *ProjectionsManager) Reset(name string, options ...OperationOption) error {
+	return manager.ResetWithContext(context.Background(), name, options...)
+}
+
+// ResetWithContext is like Reset but gives up when ctx is cancelled
+func (manager *ProjectionsManager) ResetWithContext(ctx context.Context, name string, options ...OperationOption) error {
+	return manager.command(ctx, name, "reset", options)
+}
+
+// Delete deletes the projection along with its state and checkpoints, the streams it emitted are deleted as well when
+// deleteEmittedStreams is set. Only a disabled projection can be deleted.
+func (manager *ProjectionsManager) Delete(name string, deleteEmittedStreams bool, options ...OperationOption) error {
+	return manager.DeleteWithContext(context.Background(), name, deleteEmittedStreams, options...)
+}
+
+// DeleteWithContext is like Delete but gives up when ctx is cancelled
+func (manager *ProjectionsManager) DeleteWithContext(ctx context.Context, name string, deleteEmittedStreams bool, options ...OperationOption) error {
+	parameters := url.Values{}
+	parameters.Set("deleteStateStream", "true")
+	parameters.Set("deleteCheckpointStream", "true")
+	parameters.Set("deleteEmittedStreams", strconv.FormatBool(deleteEmittedStreams))
+	return manager.api.send(ctx, http.MethodDelete, "/projection/"+url.PathEscape(name)+"?"+parameters.Encode(), nil, nil, options, projectionStatusErrors)
+}
+
+// GetState unmarshals the current state of the projection into v, v is left as is when the projection has no state yet
+func (manager *ProjectionsManager) GetState(name string, v interface{}, options ...OperationOption) error {
+	return manager.GetStateWithContext(context.Background(), name, v, options...)
+}
+
+// GetStateWithContext is like GetState but gives up when ctx is cancelled
+func (manager *ProjectionsManager) GetStateWithContext(ctx context.Context, name string, v interface{}, options ...OperationOption) error {
+	return manager.api.send(ctx, http.MethodGet, "/projection/"+url.PathEscape(name)+"/state", nil, v, options, projectionStatusErrors)
+}
+
+// GetResult unmarshals the result of the projection into v
+func (manager *ProjectionsManager) GetResult(name string, v interface{}, options ...OperationOption) error {
+	return manager.GetResultWithContext(context.Background(), name, v, options...)
+}
+
+// GetResultWithContext is like GetResult but gives up when ctx is cancelled
+func (manager *ProjectionsManager) GetResultWithContext(ctx context.Context, name string, v interface{}, options ...OperationOption) error {
+	return manager.api.send(ctx, http.MethodGet, "/projection/"+url.PathEscape(name)+"/result", nil, v, options, projectionStatusErrors)
+}
+
+func (manager *ProjectionsManager) command(ctx context.Context, name string, command string, options []OperationOption) error {
+	return manager.api.send(ctx, http.MethodPost, "/projection/"+url.PathEscape(name)+"/command/"+command, nil, nil, options, projectionStatusErrors)
+}
@@ -0,0 +1,124 @@
+package goes_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+type testHTTPRequest struct {
+	method string
+	uri    string
+	body   string
+	login  string
+}
+
+// startTestHTTPServer records the requests it receives and responds with the handler. It returns the configuration and
+// the port to create a manager for the server with.
+func startTestHTTPServer(t *testing.T, handler http.HandlerFunc) (*goes.Configuration, int, chan testHTTPRequest, *httptest.Server) {
+	requests := make(chan testHTTPRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		login, _, _ := r.BasicAuth()
+		requests <- testHTTPRequest{method: r.Method, uri: r.URL.RequestURI(), body: string(body), login: login}
+		handler(w, r)
+	}))
+	address := server.Listener.Addr().(*net.TCPAddr)
+	config := goes.NewConfiguration()
+	config.Address = address.IP.String()
+	config.Login = "admin"
+	config.Password = "changeit"
+	return config, address.Port, requests, server
+}
+
+func startTestProjectionsServer(t *testing.T, handler http.HandlerFunc) (*goes.ProjectionsManager, chan testHTTPRequest, *httptest.Server) {
+	config, port, requests, server := startTestHTTPServer(t, handler)
+	return goes.NewProjectionsManager(config, port), requests, server
+}
+
+func TestProjectionsManager_CreateContinuous(t *testing.T) {
+	manager, requests, server := startTestProjectionsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+
+	query := `fromCategory("order").foreachStream().when({})`
+	if err := manager.CreateContinuous("orders", query, true); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	expected := "/projections/continuous?emit=true&enabled=true&name=orders&trackemittedstreams=true&type=JS"
+	if request.method != http.MethodPost || request.uri != expected {
+		t.Fatalf("Expected %v %v got %v %v", http.MethodPost, expected, request.method, request.uri)
+	}
+	if request.body != query {
+		t.Fatalf("Expected %v got %v", query, request.body)
+	}
+	if request.login != "admin" {
+		t.Fatalf("Expected %v got %v", "admin", request.login)
+	}
+}
+
+func TestProjectionsManager_Commands(t *testing.T) {
+	manager, requests, server := startTestProjectionsServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+
+	for _, test := range []struct {
+		command func() error
+		method  string
+		uri     string
+	}{
+		{func() error { return manager.Enable("orders") }, http.MethodPost, "/projection/orders/command/enable"},
+		{func() error { return manager.Disable("orders") }, http.MethodPost, "/projection/orders/command/disable"},
+		{func() error { return manager.Reset("orders") }, http.MethodPost, "/projection/orders/command/reset"},
+		{func() error { return manager.Delete("orders", false) }, http.MethodDelete, "/projection/orders?deleteCheckpointStream=true&deleteEmittedStreams=false&deleteStateStream=true"},
+	} {
+		if err := test.command(); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		request := <-requests
+		if request.method != test.method || request.uri != test.uri {
+			t.Fatalf("Expected %v %v got %v %v", test.method, test.uri, request.method, request.uri)
+		}
+	}
+}
+
+func TestProjectionsManager_GetState(t *testing.T) {
+	manager, requests, server := startTestProjectionsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count":42}`))
+	})
+	defer server.Close()
+
+	state := struct {
+		Count int `json:"count"`
+	}{}
+	if err := manager.GetState("orders", &state, goes.WithCredentials(&goes.UserCredentials{Login: "ops", Password: "secret"})); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	if request.uri != "/projection/orders/state" || request.login != "ops" {
+		t.Fatalf("Expected the state of orders to be read by ops got %+v", request)
+	}
+	if state.Count != 42 {
+		t.Fatalf("Expected %v got %v", 42, state.Count)
+	}
+}
+
+func TestProjectionsManager_WhenTheProjectionDoesNotExist(t *testing.T) {
+	manager, requests, server := startTestProjectionsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+
+	var result map[string]interface{}
+	if err := manager.GetResult("missing", &result); err != goes.ErrProjectionNotFound {
+		t.Fatalf("Expected %v got %v", goes.ErrProjectionNotFound, err)
+	}
+	if request := <-requests; request.uri != "/projection/missing/result" {
+		t.Fatalf("Expected %v got %v", "/projection/missing/result", request.uri)
+	}
+}
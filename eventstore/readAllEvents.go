@@ -0,0 +1,111 @@
+package goes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// The positions that can be used to read from the start or the end of the transaction log
+var (
+	PositionStart = Position{CommitPosition: 0, PreparePosition: 0}
+	PositionEnd   = Position{CommitPosition: -1, PreparePosition: -1}
+)
+
+// AllEventsSlice is a page of events read from the transaction log of all the events in the store
+type AllEventsSlice struct {
+	FromPosition Position
+	Events       []ResolvedEvent
+	// NextPosition is the position to start the next read from when paging through the log
+	NextPosition  Position
+	IsEndOfStream bool
+}
+
+// ReadAllEventsForward reads up to count events from the transaction log, starting at the from position. Reading all the
+// events usually requires admin credentials, ErrAccessDenied is returned otherwise.
+func (connection *EventStoreConnection) ReadAllEventsForward(from Position, count int, resolveLinks bool, options ...OperationOption) (*AllEventsSlice, error) {
+	return connection.ReadAllEventsForwardWithContext(context.Background(), from, count, resolveLinks, options...)
+}
+
+// ReadAllEventsForwardWithContext is like ReadAllEventsForward but gives up when ctx is cancelled
+func (connection *EventStoreConnection) ReadAllEventsForwardWithContext(ctx context.Context, from Position, count int, resolveLinks bool, options ...OperationOption) (*AllEventsSlice, error) {
+	return readAllEvents(ctx, connection, readAllEventsForward, readAllEventsForwardCompleted, from, count, resolveLinks, connection.requireMaster(options), connection.credentials(options))
+}
+
+// ReadAllEventsBackward reads up to count events from the transaction log backwards, starting at the from position.
+// Use PositionEnd as the from position to read from the end of the log.
+func (connection *EventStoreConnection) ReadAllEventsBackward(from Position, count int, resolveLinks bool, options ...OperationOption) (*AllEventsSlice, error) {
+	return connection.ReadAllEventsBackwardWithContext(context.Background(), from, count, resolveLinks, options...)
+}
+
+// ReadAllEventsBackwardWithContext is like ReadAllEventsBackward but gives up when ctx is cancelled
+func (connection *EventStoreConnection) ReadAllEventsBackwardWithContext(ctx context.Context, from Position, count int, resolveLinks bool, options ...OperationOption) (*AllEventsSlice, error) {
+	return readAllEvents(ctx, connection, readAllEventsBackward, readAllEventsBackwardCompleted, from, count, resolveLinks, connection.requireMaster(options), connection.credentials(options))
+}
+
+func readAllEvents(ctx context.Context, connection *EventStoreConnection, command Command, completedCommand Command, from Position, count int, resolveLinks bool, requireMaster bool, credentials UserCredentials) (slice *AllEventsSlice, err error) {
+	ctx, span := connection.startSpan(ctx, command, "")
+	defer func() {
+		endSpan(span, err)
+	}()
+	maxCount, err := int32Argument("count", int64(count))
+	if err != nil {
+		return nil, err
+	}
+	readAllEventsData := &protobuf.ReadAllEvents{
+		CommitPosition:  proto.Int64(from.CommitPosition),
+		PreparePosition: proto.Int64(from.PreparePosition),
+		MaxCount:        proto.Int32(maxCount),
+		ResolveLinkTos:  proto.Bool(resolveLinks),
+		RequireMaster:   proto.Bool(requireMaster),
+	}
+	data, err := proto.Marshal(readAllEventsData)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return nil, err
+	}
+
+	pkg, err := newPackage(command, data, uuid.NewV4().Bytes(), credentials.Login, credentials.Password)
+	if err != nil {
+		connection.logger().Errorf("failed to create new read all events package")
+		return nil, err
+	}
+
+	resultPackage, err := performOperation(ctx, connection, pkg, completedCommand)
+	if err != nil {
+		return nil, err
+	}
+	message := &protobuf.ReadAllEventsCompleted{}
+	err = proto.Unmarshal(resultPackage.Data, message)
+	if err != nil {
+		connection.logger().Errorf("unmarshaling error: %s", err)
+		return nil, err
+	}
+
+	switch message.GetResult() {
+	case protobuf.ReadAllEventsCompleted_Success:
+		break
+	case protobuf.ReadAllEventsCompleted_AccessDenied:
+		return nil, ErrAccessDenied
+	default:
+		return nil, fmt.Errorf("read failed with %s: %s", message.GetResult(), message.GetError())
+	}
+
+	slice = &AllEventsSlice{
+		FromPosition: from,
+		Events:       make([]ResolvedEvent, 0, len(message.GetEvents())),
+		NextPosition: Position{
+			CommitPosition:  message.GetNextCommitPosition(),
+			PreparePosition: message.GetNextPreparePosition(),
+		},
+		// the response does not say whether the end was reached, a page that is not full is the last one
+		IsEndOfStream: len(message.GetEvents()) < count,
+	}
+	for _, resolved := range message.GetEvents() {
+		slice.Events = append(slice.Events, newPositionedEvent(resolved))
+	}
+	return slice, nil
+}
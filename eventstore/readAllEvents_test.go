@@ -0,0 +1,82 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+const readAllEventsForwardCompletedCommand byte = 0xB7
+
+func respondToTestReadAllEvents(t *testing.T, socket net.Conn, response *protobuf.ReadAllEventsCompleted) *protobuf.ReadAllEvents {
+	pkg, err := readTestPackage(socket)
+	if err != nil {
+		return nil
+	}
+	request := &protobuf.ReadAllEvents{}
+	proto.Unmarshal(pkg.Data, request)
+	socket.Write(encodeTestPackage(testPackage{
+		Command:       readAllEventsForwardCompletedCommand,
+		CorrelationID: pkg.CorrelationID,
+		Data:          marshalTestMessage(t, response),
+	}))
+	return request
+}
+
+func TestReadAllEventsForward(t *testing.T) {
+	requests := make(chan *protobuf.ReadAllEvents, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		requests <- respondToTestReadAllEvents(t, socket, &protobuf.ReadAllEventsCompleted{
+			CommitPosition:  proto.Int64(100),
+			PreparePosition: proto.Int64(100),
+			Events: []*protobuf.ResolvedEvent{
+				{Event: newTestEventRecord("order-1", 0), CommitPosition: proto.Int64(100), PreparePosition: proto.Int64(100)},
+				{Event: newTestEventRecord("order-2", 0), CommitPosition: proto.Int64(200), PreparePosition: proto.Int64(200)},
+			},
+			NextCommitPosition:  proto.Int64(300),
+			NextPreparePosition: proto.Int64(300),
+			Result:              protobuf.ReadAllEventsCompleted_Success.Enum(),
+		})
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	from := goes.Position{CommitPosition: 100, PreparePosition: 100}
+	slice, err := conn.ReadAllEventsForward(from, 10, false)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	if request.GetCommitPosition() != 100 || request.GetPreparePosition() != 100 || request.GetMaxCount() != 10 {
+		t.Fatalf("Expected a read of %v from %v got %+v", 10, from, request)
+	}
+	if len(slice.Events) != 2 || slice.Events[1].Event.StreamID != "order-2" || slice.Events[1].Position.CommitPosition != 200 {
+		t.Fatalf("Expected %v got %+v", "order-1 and order-2", slice.Events)
+	}
+	expected := goes.Position{CommitPosition: 300, PreparePosition: 300}
+	if slice.NextPosition != expected || !slice.IsEndOfStream {
+		t.Fatalf("Expected the end of the stream at %v got %+v", expected, slice)
+	}
+}
+
+func TestReadAllEventsForward_WithoutAccess(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		respondToTestReadAllEvents(t, socket, &protobuf.ReadAllEventsCompleted{
+			CommitPosition:      proto.Int64(0),
+			PreparePosition:     proto.Int64(0),
+			NextCommitPosition:  proto.Int64(0),
+			NextPreparePosition: proto.Int64(0),
+			Result:              protobuf.ReadAllEventsCompleted_AccessDenied.Enum(),
+		})
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := conn.ReadAllEventsForward(goes.PositionStart, 10, false)
+	if err != goes.ErrAccessDenied {
+		t.Fatalf("Expected %v got %v", goes.ErrAccessDenied, err)
+	}
+}
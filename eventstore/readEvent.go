@@ -0,0 +1,71 @@
+package goes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// ReadEvent reads a single event from the stream. When resolveLinks is set and the event is a link, the event it points to is returned.
+func (connection *EventStoreConnection) ReadEvent(stream string, eventNumber int64, resolveLinks bool, options ...OperationOption) (*ResolvedEvent, error) {
+	return connection.ReadEventWithContext(context.Background(), stream, eventNumber, resolveLinks, options...)
+}
+
+// ReadEventWithContext is like ReadEvent but gives up when ctx is cancelled
+func (connection *EventStoreConnection) ReadEventWithContext(ctx context.Context, stream string, eventNumber int64, resolveLinks bool, options ...OperationOption) (evnt *ResolvedEvent, err error) {
+	ctx, span := connection.startSpan(ctx, readEvent, stream)
+	defer func() {
+		endSpan(span, err)
+	}()
+	credentials := connection.credentials(options)
+	number, err := int32Argument("eventNumber", eventNumber)
+	if err != nil {
+		return nil, err
+	}
+	readEventData := &protobuf.ReadEvent{
+		EventStreamId:  proto.String(stream),
+		EventNumber:    proto.Int32(number),
+		ResolveLinkTos: proto.Bool(resolveLinks),
+		RequireMaster:  proto.Bool(connection.requireMaster(options)),
+	}
+	data, err := proto.Marshal(readEventData)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return nil, err
+	}
+
+	pkg, err := newPackage(readEvent, data, uuid.NewV4().Bytes(), credentials.Login, credentials.Password)
+	if err != nil {
+		connection.logger().Errorf("failed to create new read event package")
+		return nil, err
+	}
+
+	resultPackage, err := performOperation(ctx, connection, pkg, readEventCompleted)
+	if err != nil {
+		return nil, err
+	}
+	message := &protobuf.ReadEventCompleted{}
+	err = proto.Unmarshal(resultPackage.Data, message)
+	if err != nil {
+		connection.logger().Errorf("unmarshaling error: %s", err)
+		return nil, err
+	}
+
+	switch message.GetResult() {
+	case protobuf.ReadEventCompleted_Success:
+		resolved := newResolvedEvent(message.GetEvent().GetEvent(), message.GetEvent().GetLink())
+		return &resolved, nil
+	case protobuf.ReadEventCompleted_NotFound:
+		return nil, ErrEventNotFound
+	case protobuf.ReadEventCompleted_NoStream:
+		return nil, ErrNoStream
+	case protobuf.ReadEventCompleted_StreamDeleted:
+		return nil, ErrStreamDeleted
+	case protobuf.ReadEventCompleted_AccessDenied:
+		return nil, ErrAccessDenied
+	}
+	return nil, fmt.Errorf("read failed with %s: %s", message.GetResult(), message.GetError())
+}
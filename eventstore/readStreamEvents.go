@@ -0,0 +1,210 @@
+package goes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// The positions that can be used to read from the start or the end of a stream
+const (
+	StreamPositionStart = 0
+	StreamPositionEnd   = -1
+)
+
+// StreamEventsSlice is a page of events read from a stream
+type StreamEventsSlice struct {
+	Stream          string
+	FromEventNumber int64
+	Events          []ResolvedEvent
+	// NextEventNumber is the event number to start the next read from when paging through the stream
+	NextEventNumber int64
+	LastEventNumber int64
+	IsEndOfStream   bool
+}
+
+// ReadStreamEventsForward reads up to count events from the stream, starting at and including the start event number.
+// WithMaxBytes bounds the size of the events it returns.
+func (connection *EventStoreConnection) ReadStreamEventsForward(stream string, start int64, count int, resolveLinks bool, options ...OperationOption) (*StreamEventsSlice, error) {
+	return connection.ReadStreamEventsForwardWithContext(context.Background(), stream, start, count, resolveLinks, options...)
+}
+
+// ReadStreamEventsForwardWithContext is like ReadStreamEventsForward but gives up when ctx is cancelled
+func (connection *EventStoreConnection) ReadStreamEventsForwardWithContext(ctx context.Context, stream string, start int64, count int, resolveLinks bool, options ...OperationOption) (*StreamEventsSlice, error) {
+	return readStreamEvents(ctx, connection, readStreamEventsForward, readStreamEventsForwardCompleted, stream, start, count, resolveLinks, maxBytes(options), connection.requireMaster(options), connection.credentials(options))
+}
+
+// ReadStreamEventsBackward reads up to count events from the stream backwards, starting at and including the start event number.
+// Use StreamPositionEnd as the start to read from the end of the stream. WithMaxBytes bounds the size of the events it returns.
+func (connection *EventStoreConnection) ReadStreamEventsBackward(stream string, start int64, count int, resolveLinks bool, options ...OperationOption) (*StreamEventsSlice, error) {
+	return connection.ReadStreamEventsBackwardWithContext(context.Background(), stream, start, count, resolveLinks, options...)
+}
+
+// ReadStreamEventsBackwardWithContext is like ReadStreamEventsBackward but gives up when ctx is cancelled
+func (connection *EventStoreConnection) ReadStreamEventsBackwardWithContext(ctx context.Context, stream string, start int64, count int, resolveLinks bool, options ...OperationOption) (*StreamEventsSlice, error) {
+	return readStreamEvents(ctx, connection, readStreamEventsBackward, readStreamEventsBackwardCompleted, stream, start, count, resolveLinks, maxBytes(options), connection.requireMaster(options), connection.credentials(options))
+}
+
+// ReadCategory reads up to count events from the $ce-<category> stream the system projection links the events of the
+// streams named <category>-<id> into, starting at and including the start event number. The links are resolved, so the
+// Event of each ResolvedEvent is the event in its own stream with its stream id and event number, and its Link is the
+// entry in the category stream, whose event number is the one to continue reading from.
+func (connection *EventStoreConnection) ReadCategory(category string, start int64, count int, options ...OperationOption) (*StreamEventsSlice, error) {
+	return connection.ReadCategoryWithContext(context.Background(), category, start, count, options...)
+}
+
+// ReadCategoryWithContext is like ReadCategory but gives up when ctx is cancelled
+func (connection *EventStoreConnection) ReadCategoryWithContext(ctx context.Context, category string, start int64, count int, options ...OperationOption) (*StreamEventsSlice, error) {
+	return connection.ReadStreamEventsForwardWithContext(ctx, categoryStreamOf(category), start, count, true, options...)
+}
+
+// categoryStreamOf returns the name of the stream the system projection links the events of the category into
+func categoryStreamOf(category string) string {
+	return "$ce-" + category
+}
+
+// GetStreamVersion returns the event number of the last event in the stream, which is the expected version to append to it with.
+// ExpectedVersionNoStream is returned together with ErrNoStream when the stream does not exist and CurrentVersionUnknown
+// together with any other error.
+func (connection *EventStoreConnection) GetStreamVersion(stream string, options ...OperationOption) (int64, error) {
+	return connection.GetStreamVersionWithContext(context.Background(), stream, options...)
+}
+
+// GetStreamVersionWithContext is like GetStreamVersion but gives up when ctx is cancelled
+func (connection *EventStoreConnection) GetStreamVersionWithContext(ctx context.Context, stream string, options ...OperationOption) (int64, error) {
+	return streamVersion(ctx, connection, stream, connection.requireMaster(options), connection.credentials(options))
+}
+
+// streamVersion reads the last event of the stream backwards to learn its version
+func streamVersion(ctx context.Context, connection *EventStoreConnection, stream string, requireMaster bool, credentials UserCredentials) (int64, error) {
+	message, err := readStreamEventsCompleted(ctx, connection, readStreamEventsBackward, readStreamEventsBackwardCompleted, stream, StreamPositionEnd, 1, false, requireMaster, credentials)
+	if err == ErrNoStream {
+		return ExpectedVersionNoStream, err
+	}
+	if err != nil {
+		return CurrentVersionUnknown, err
+	}
+	return int64(message.GetLastEventNumber()), nil
+}
+
+// readStreamEvents reads a slice of the stream, which ends after the event that reaches maxBytes unless it is zero. A read
+// bounded by maxBytes is made in pages, the first of a single event and each of the next of as many events of the largest
+// size seen so far as fit in what is left of the budget, so that a response is not much larger than the budget.
+func readStreamEvents(ctx context.Context, connection *EventStoreConnection, command Command, completedCommand Command, stream string, start int64, count int, resolveLinks bool, maxBytes int, requireMaster bool, credentials UserCredentials) (*StreamEventsSlice, error) {
+	slice := &StreamEventsSlice{
+		Stream:          stream,
+		FromEventNumber: start,
+		NextEventNumber: start,
+	}
+	size := 0
+	largest := 0
+	for remaining := count; ; {
+		pageCount := remaining
+		if maxBytes > 0 {
+			pageCount = 1
+			if largest > 0 {
+				pageCount = (maxBytes - size) / largest
+			}
+			if pageCount < 1 {
+				pageCount = 1
+			}
+			if pageCount > remaining {
+				pageCount = remaining
+			}
+		}
+		message, err := readStreamEventsCompleted(ctx, connection, command, completedCommand, stream, slice.NextEventNumber, pageCount, resolveLinks, requireMaster, credentials)
+		if err != nil {
+			return nil, err
+		}
+		slice.NextEventNumber = int64(message.GetNextEventNumber())
+		slice.LastEventNumber = int64(message.GetLastEventNumber())
+		slice.IsEndOfStream = message.GetIsEndOfStream()
+		for i, evnt := range message.GetEvents() {
+			resolved := newResolvedEvent(evnt.GetEvent(), evnt.GetLink())
+			slice.Events = append(slice.Events, resolved)
+			size += resolved.size()
+			if resolved.size() > largest {
+				largest = resolved.size()
+			}
+			if maxBytes > 0 && size >= maxBytes && i < len(message.GetEvents())-1 {
+				// an event was larger than the ones before it, the events after it are not kept
+				slice.NextEventNumber = resolved.OriginalEventNumber() + 1
+				if command == readStreamEventsBackward {
+					slice.NextEventNumber = resolved.OriginalEventNumber() - 1
+				}
+				slice.IsEndOfStream = false
+				break
+			}
+		}
+		remaining -= len(message.GetEvents())
+		if maxBytes == 0 || slice.IsEndOfStream || remaining <= 0 || size >= maxBytes || len(message.GetEvents()) == 0 {
+			break
+		}
+	}
+	if slice.Events == nil {
+		slice.Events = []ResolvedEvent{}
+	}
+	return slice, nil
+}
+
+// readStreamEventsCompleted performs the read and returns the raw response when the read succeeded
+func readStreamEventsCompleted(ctx context.Context, connection *EventStoreConnection, command Command, completedCommand Command, stream string, start int64, count int, resolveLinks bool, requireMaster bool, credentials UserCredentials) (message *protobuf.ReadStreamEventsCompleted, err error) {
+	ctx, span := connection.startSpan(ctx, command, stream)
+	defer func() {
+		endSpan(span, err)
+	}()
+	from, err := int32Argument("start", start)
+	if err != nil {
+		return nil, err
+	}
+	maxCount, err := int32Argument("count", int64(count))
+	if err != nil {
+		return nil, err
+	}
+	readStreamEventsData := &protobuf.ReadStreamEvents{
+		EventStreamId:   proto.String(stream),
+		FromEventNumber: proto.Int32(from),
+		MaxCount:        proto.Int32(maxCount),
+		ResolveLinkTos:  proto.Bool(resolveLinks),
+		RequireMaster:   proto.Bool(requireMaster),
+	}
+	data, err := proto.Marshal(readStreamEventsData)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return nil, err
+	}
+
+	pkg, err := newPackage(command, data, uuid.NewV4().Bytes(), credentials.Login, credentials.Password)
+	if err != nil {
+		connection.logger().Errorf("failed to create new read stream events package")
+		return nil, err
+	}
+
+	resultPackage, err := performOperation(ctx, connection, pkg, completedCommand)
+	if err != nil {
+		return nil, err
+	}
+	message = &protobuf.ReadStreamEventsCompleted{}
+	err = proto.Unmarshal(resultPackage.Data, message)
+	if err != nil {
+		connection.logger().Errorf("unmarshaling error: %s", err)
+		return nil, err
+	}
+
+	switch message.GetResult() {
+	case protobuf.ReadStreamEventsCompleted_Success:
+		break
+	case protobuf.ReadStreamEventsCompleted_NoStream:
+		return nil, ErrNoStream
+	case protobuf.ReadStreamEventsCompleted_StreamDeleted:
+		return nil, ErrStreamDeleted
+	case protobuf.ReadStreamEventsCompleted_AccessDenied:
+		return nil, ErrAccessDenied
+	default:
+		return nil, fmt.Errorf("read failed with %s: %s", message.GetResult(), message.GetError())
+	}
+	return message, nil
+}
@@ -0,0 +1,275 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// startTestStreamVersionServer writes the number of events to the stream orders on a fake server and sends the reads of
+// the stream to requests
+func startTestStreamVersionServer(t *testing.T, events int, requests chan *protobuf.ReadStreamEvents) (*goes.EventStoreConnection, *fakeserver.Server) {
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	if events > 0 {
+		if _, err := conn.WriteEvents("orders", goes.ExpectedVersionNoStream, createTestBatch(events)); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+	read := server.Handler(fakeserver.ReadStreamEventsBackward)
+	server.Handle(fakeserver.ReadStreamEventsBackward, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		request := &protobuf.ReadStreamEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		requests <- request
+		read(serverConn, pkg)
+	})
+	return conn, server
+}
+
+func TestGetStreamVersion(t *testing.T) {
+	requests := make(chan *protobuf.ReadStreamEvents, 1)
+	conn, server := startTestStreamVersionServer(t, 42, requests)
+	defer server.Close()
+	defer conn.Close()
+
+	version, err := conn.GetStreamVersion("orders")
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if version != 41 {
+		t.Fatalf("Expected %v got %v", 41, version)
+	}
+	request := <-requests
+	if request.GetFromEventNumber() != goes.StreamPositionEnd || request.GetMaxCount() != 1 {
+		t.Fatalf("Expected a read of the last event got %+v", request)
+	}
+}
+
+func TestGetStreamVersion_WhenTheStreamDoesNotExist(t *testing.T) {
+	requests := make(chan *protobuf.ReadStreamEvents, 1)
+	conn, server := startTestStreamVersionServer(t, 0, requests)
+	defer server.Close()
+	defer conn.Close()
+
+	version, err := conn.GetStreamVersion("orders")
+	if err != goes.ErrNoStream {
+		t.Fatalf("Expected %v got %v", goes.ErrNoStream, err)
+	}
+	if version != goes.ExpectedVersionNoStream {
+		t.Fatalf("Expected %v got %v", goes.ExpectedVersionNoStream, version)
+	}
+}
+
+func TestGetStreamVersion_WhenTheReadFails(t *testing.T) {
+	requests := make(chan *protobuf.ReadStreamEvents, 1)
+	conn, server := startTestStreamVersionServer(t, 0, requests)
+	defer server.Close()
+	defer conn.Close()
+	server.Handle(fakeserver.ReadStreamEventsBackward, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		serverConn.Respond(pkg, fakeserver.ReadStreamEventsBackwardCompleted, &protobuf.ReadStreamEventsCompleted{
+			Result:             protobuf.ReadStreamEventsCompleted_AccessDenied.Enum(),
+			NextEventNumber:    proto.Int32(-1),
+			LastEventNumber:    proto.Int32(-1),
+			IsEndOfStream:      proto.Bool(true),
+			LastCommitPosition: proto.Int64(0),
+		})
+	})
+
+	version, err := conn.GetStreamVersion("orders")
+	if err != goes.ErrAccessDenied {
+		t.Fatalf("Expected %v got %v", goes.ErrAccessDenied, err)
+	}
+	if version != goes.CurrentVersionUnknown {
+		t.Fatalf("Expected %v got %v", int64(goes.CurrentVersionUnknown), version)
+	}
+}
+
+func TestReadCategory(t *testing.T) {
+	requests := make(chan *protobuf.ReadStreamEvents, 1)
+	response := marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+		Events: []*protobuf.ResolvedIndexedEvent{
+			{Event: newTestEventRecord("order-1", 3), Link: newTestEventRecord("$ce-order", 0)},
+			{Event: newTestEventRecord("order-2", 0), Link: newTestEventRecord("$ce-order", 1)},
+		},
+		Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+		NextEventNumber:    proto.Int32(2),
+		LastEventNumber:    proto.Int32(1),
+		IsEndOfStream:      proto.Bool(true),
+		LastCommitPosition: proto.Int64(0),
+	})
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		request := &protobuf.ReadStreamEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		requests <- request
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsForwardCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data:          response,
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	slice, err := conn.ReadCategory("order", 0, 10)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	if request.GetEventStreamId() != "$ce-order" || !request.GetResolveLinkTos() {
+		t.Fatalf("Expected a read of %v resolving links got %+v", "$ce-order", request)
+	}
+	if len(slice.Events) != 2 {
+		t.Fatalf("Expected %v got %v", 2, len(slice.Events))
+	}
+	for i, expected := range []struct {
+		stream      string
+		eventNumber int64
+	}{{"order-1", 3}, {"order-2", 0}} {
+		evnt := slice.Events[i]
+		if evnt.Event.StreamID != expected.stream || evnt.Event.EventNumber != expected.eventNumber {
+			t.Fatalf("Expected %v@%v got %v@%v", expected.stream, expected.eventNumber, evnt.Event.StreamID, evnt.Event.EventNumber)
+		}
+		if evnt.OriginalStreamID() != "$ce-order" || evnt.OriginalEventNumber() != int64(i) {
+			t.Fatalf("Expected %v@%v got %v@%v", "$ce-order", i, evnt.OriginalStreamID(), evnt.OriginalEventNumber())
+		}
+	}
+}
+
+func TestReadParkedEvents(t *testing.T) {
+	parked := "$persistentsubscription-order-1::billing-parked"
+	requests := make(chan *protobuf.ReadStreamEvents, 1)
+	response := marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+		Events: []*protobuf.ResolvedIndexedEvent{
+			{Event: newTestEventRecord("order-1", 7), Link: newTestEventRecord(parked, 0)},
+		},
+		Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+		NextEventNumber:    proto.Int32(1),
+		LastEventNumber:    proto.Int32(0),
+		IsEndOfStream:      proto.Bool(true),
+		LastCommitPosition: proto.Int64(0),
+	})
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		request := &protobuf.ReadStreamEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		requests <- request
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsForwardCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data:          response,
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	slice, err := conn.ReadParkedEvents("order-1", "billing", 0, 10)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	if request.GetEventStreamId() != parked || !request.GetResolveLinkTos() {
+		t.Fatalf("Expected a read of %v resolving links got %+v", parked, request)
+	}
+	if len(slice.Events) != 1 || slice.Events[0].Event.StreamID != "order-1" || slice.Events[0].Event.EventNumber != 7 {
+		t.Fatalf("Expected %v@%v got %+v", "order-1", 7, slice.Events)
+	}
+}
+
+// startTestLargeEventsServer serves a stream of 15 events of 100 bytes each and passes on the number of events each read
+// asks for
+func startTestLargeEventsServer(t *testing.T, command fakeserver.Command, maxCounts chan int32) (*goes.EventStoreConnection, *fakeserver.Server) {
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	events := make([]goes.EventData, 15)
+	for i := range events {
+		events[i] = goes.EventData{EventID: uuid.NewV4(), EventType: "TestEvent", Data: make([]byte, 100)}
+	}
+	if _, err := conn.WriteEvents("testStream", goes.ExpectedVersionNoStream, events); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	read := server.Handler(command)
+	server.Handle(command, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		request := &protobuf.ReadStreamEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		maxCounts <- request.GetMaxCount()
+		read(serverConn, pkg)
+	})
+	return conn, server
+}
+
+func TestReadStreamEventsForward_WithMaxBytes(t *testing.T) {
+	maxCounts := make(chan int32, 10)
+	conn, server := startTestLargeEventsServer(t, fakeserver.ReadStreamEventsForward, maxCounts)
+	defer server.Close()
+	defer conn.Close()
+
+	slice, err := conn.ReadStreamEventsForward("testStream", 10, 5, false, goes.WithMaxBytes(250))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 3 || slice.Events[0].Event.EventNumber != 10 {
+		t.Fatalf("Expected %v events from %v got %+v", 3, 10, slice.Events)
+	}
+	if slice.NextEventNumber != 13 || slice.IsEndOfStream {
+		t.Fatalf("Expected to continue from %v got %v (end of stream %v)", 13, slice.NextEventNumber, slice.IsEndOfStream)
+	}
+	// no read asks for more events than fit in what is left of the budget
+	if len(maxCounts) != 3 {
+		t.Fatalf("Expected %v reads got %v", 3, len(maxCounts))
+	}
+	for i := 0; i < 3; i++ {
+		if maxCount := <-maxCounts; maxCount != 1 {
+			t.Fatalf("Expected %v got %v", 1, maxCount)
+		}
+	}
+}
+
+func TestReadStreamEventsBackward_WithMaxBytes(t *testing.T) {
+	maxCounts := make(chan int32, 10)
+	conn, server := startTestLargeEventsServer(t, fakeserver.ReadStreamEventsBackward, maxCounts)
+	defer server.Close()
+	defer conn.Close()
+
+	slice, err := conn.ReadStreamEventsBackward("testStream", 14, 5, false, goes.WithMaxBytes(200))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 2 || slice.Events[0].Event.EventNumber != 14 || slice.Events[1].Event.EventNumber != 13 {
+		t.Fatalf("Expected events %v and %v got %+v", 14, 13, slice.Events)
+	}
+	if slice.NextEventNumber != 12 {
+		t.Fatalf("Expected to continue from %v got %v", 12, slice.NextEventNumber)
+	}
+	if len(maxCounts) != 2 {
+		t.Fatalf("Expected %v reads got %v", 2, len(maxCounts))
+	}
+}
+
+func TestReadStreamEventsForward_WithMaxBytesLargerThanTheSlice(t *testing.T) {
+	maxCounts := make(chan int32, 10)
+	conn, server := startTestLargeEventsServer(t, fakeserver.ReadStreamEventsForward, maxCounts)
+	defer server.Close()
+	defer conn.Close()
+
+	slice, err := conn.ReadStreamEventsForward("testStream", 10, 5, false, goes.WithMaxBytes(500))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if len(slice.Events) != 5 || slice.NextEventNumber != 15 || !slice.IsEndOfStream {
+		t.Fatalf("Expected the whole slice got %v events up to %v", len(slice.Events), slice.NextEventNumber)
+	}
+	// the first read learns the size of the events, the second asks for the rest of the slice
+	if first, second := <-maxCounts, <-maxCounts; first != 1 || second != 4 {
+		t.Fatalf("Expected reads of %v and %v events got %v and %v", 1, 4, first, second)
+	}
+}
@@ -0,0 +1,42 @@
+package goes
+
+import "context"
+
+// ReadStreamIterator reads the whole stream forward in batches of batchSize events and emits each event on the returned channel.
+// Both channels are closed once the end of the stream is reached or the read fails, in which case the error is sent first.
+// Use ReadStreamIteratorWithContext to be able to stop reading before the end of the stream.
+func (connection *EventStoreConnection) ReadStreamIterator(stream string, batchSize int, resolveLinks bool, options ...OperationOption) (<-chan ResolvedEvent, <-chan error) {
+	return connection.ReadStreamIteratorWithContext(context.Background(), stream, batchSize, resolveLinks, options...)
+}
+
+// ReadStreamIteratorWithContext is like ReadStreamIterator but stops reading when ctx is cancelled. Cancel the context when you stop
+// consuming the events before the end of the stream so that the reading goroutine can exit.
+func (connection *EventStoreConnection) ReadStreamIteratorWithContext(ctx context.Context, stream string, batchSize int, resolveLinks bool, options ...OperationOption) (<-chan ResolvedEvent, <-chan error) {
+	events := make(chan ResolvedEvent)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		from := int64(StreamPositionStart)
+		for {
+			slice, err := connection.ReadStreamEventsForwardWithContext(ctx, stream, from, batchSize, resolveLinks, options...)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, evnt := range slice.Events {
+				select {
+				case events <- evnt:
+				case <-ctx.Done():
+					errs <- ctx.Err()
+					return
+				}
+			}
+			if slice.IsEndOfStream {
+				return
+			}
+			from = slice.NextEventNumber
+		}
+	}()
+	return events, errs
+}
@@ -0,0 +1,22 @@
+package goes
+
+// WithRequireMaster overrides the RequireMaster setting of the configuration for a single operation.
+// Live subscriptions are always served by the node the connection is connected to, only the reads of the history of a
+// catch-up subscription honour the option.
+func WithRequireMaster(requireMaster bool) OperationOption {
+	return func(options *operationOptions) {
+		options.requireMaster = &requireMaster
+	}
+}
+
+// requireMaster returns whether the operation must be served by the master
+func (connection *EventStoreConnection) requireMaster(options []OperationOption) bool {
+	resolved := operationOptions{}
+	for _, option := range options {
+		option(&resolved)
+	}
+	if resolved.requireMaster != nil {
+		return *resolved.requireMaster
+	}
+	return connection.Config.RequireMaster
+}
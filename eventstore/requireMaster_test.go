@@ -0,0 +1,64 @@
+package goes_test
+
+import (
+	"testing"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// startTestRequireMasterServer sends the writes it receives to writes
+func startTestRequireMasterServer(t *testing.T, config *goes.Configuration, writes chan *protobuf.WriteEvents) (*goes.EventStoreConnection, *fakeserver.Server) {
+	conn, server := startTestFakeServer(t, config)
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(writes))
+	return conn, server
+}
+
+func TestWriteEvents_RequiresTheMasterByDefault(t *testing.T) {
+	writes := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestRequireMasterServer(t, goes.NewConfiguration(), writes)
+	defer server.Close()
+	defer conn.Close()
+
+	_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if actual := (<-writes).GetRequireMaster(); !actual {
+		t.Fatalf("Expected %v got %v", true, actual)
+	}
+}
+
+func TestWriteEvents_WithRequireMasterOption(t *testing.T) {
+	writes := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestRequireMasterServer(t, goes.NewConfiguration(), writes)
+	defer server.Close()
+	defer conn.Close()
+
+	_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}}, goes.WithRequireMaster(false))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if actual := (<-writes).GetRequireMaster(); actual {
+		t.Fatalf("Expected %v got %v", false, actual)
+	}
+}
+
+func TestWriteEvents_WhenTheConfigurationDoesNotRequireTheMaster(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.RequireMaster = false
+	writes := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestRequireMasterServer(t, config, writes)
+	defer server.Close()
+	defer conn.Close()
+
+	_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if actual := (<-writes).GetRequireMaster(); actual {
+		t.Fatalf("Expected %v got %v", false, actual)
+	}
+}
@@ -0,0 +1,86 @@
+package goes
+
+import (
+	"context"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+// transient reports whether a subscription dropped for the reason may succeed when it subscribes again
+func (reason SubscriptionDropReason) transient() bool {
+	switch reason {
+	case SubscriptionDropReasonServerShutdown, SubscriptionDropReasonSubscriberMaxCountReached:
+		return true
+	}
+	return false
+}
+
+// subscribeAgain subscribes again after the subscription was dropped for a transient reason, waiting the
+// ResubscribeBackoff before every attempt. It returns false when the subscription is to be dropped for good, the reason
+// and the error it is dropped with are recorded by then. It is only called by the goroutine that runs Start.
+func (subscription *Subscription) subscribeAgain(reason SubscriptionDropReason, err error) bool {
+	connection := subscription.Connection
+	config := connection.Config
+	if config.MaxResubscribes <= 0 || !reason.transient() || subscription.subscribeCommand == 0 {
+		return false
+	}
+	for {
+		if subscription.resubscribes >= config.MaxResubscribes {
+			subscription.dropped(reason, &ErrResubscribesExhausted{Resubscribes: subscription.resubscribes, Err: err})
+			return false
+		}
+		subscription.resubscribes++
+		connection.logger().Debugf("resubscribing to %s after it was dropped (attempt %d): %v", subscription.stream, subscription.resubscribes, err)
+		if config.OnResubscribe != nil {
+			config.OnResubscribe(subscription.stream, subscription.resubscribes, err)
+		}
+		timer := time.NewTimer(config.ResubscribeBackoff(subscription.resubscribes))
+		select {
+		case <-timer.C:
+		case <-connection.closed():
+			timer.Stop()
+		}
+		if awaitReconnected(context.Background(), connection) != nil {
+			subscription.dropped(SubscriptionDropReasonConnectionClosed, ErrConnectionClosed)
+			return false
+		}
+		subscription.mutex.Lock()
+		unsubscribing := subscription.unsubscribing
+		subscription.mutex.Unlock()
+		if unsubscribing {
+			subscription.dropped(SubscriptionDropReasonUnsubscribed, nil)
+			return false
+		}
+		if err = subscription.sendSubscribe(); err == nil {
+			subscription.dropped(0, nil)
+			return true
+		}
+	}
+}
+
+// dropped replaces the reason and the error the subscription is dropped with
+func (subscription *Subscription) dropped(reason SubscriptionDropReason, err error) {
+	subscription.mutex.Lock()
+	subscription.reason, subscription.err = reason, err
+	subscription.mutex.Unlock()
+}
+
+// sendSubscribe registers the subscription with a new correlation id and sends the subscribe package again
+func (subscription *Subscription) sendSubscribe() error {
+	connection := subscription.Connection
+	connection.mutex.Lock()
+	subscription.CorrelationID = uuid.NewV4()
+	connection.requests[subscription.CorrelationID] = subscription.Channel
+	connection.subscriptions[subscription.CorrelationID] = subscription
+	connection.mutex.Unlock()
+
+	pkg, err := newPackage(subscription.subscribeCommand, subscription.subscribeData, subscription.correlationID().Bytes(), subscription.credentials.Login, subscription.credentials.Password)
+	if err == nil {
+		err = pkg.write(connection)
+	}
+	if err != nil {
+		subscription.unregister()
+	}
+	return err
+}
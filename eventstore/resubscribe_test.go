@@ -0,0 +1,82 @@
+package goes_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+func TestSubscribeToStream_ResubscribesAfterATransientDrop(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.MaxResubscribes = 2
+	config.ResubscribeDelay = 10
+	var mutex sync.Mutex
+	var attempts []int
+	config.OnResubscribe = func(stream string, attempt int, err error) {
+		mutex.Lock()
+		attempts = append(attempts, attempt)
+		mutex.Unlock()
+	}
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		// the events received after the first two subscribes start the count of attempts over
+		for i := 0; i < 4; i++ {
+			subscribe, err := confirmTestSubscription(t, socket)
+			if err != nil || subscribe.Command != subscribeToStreamCommand {
+				return
+			}
+			if i < 2 {
+				writeTestEventAppeared(t, socket, subscribe.CorrelationID, "testStream", int32(i))
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       subscriptionDroppedCommand,
+				CorrelationID: subscribe.CorrelationID,
+				Data: marshalTestMessage(t, &protobuf.SubscriptionDropped{
+					Reason: protobuf.SubscriptionDropped_SubscriberMaxCountReached.Enum(),
+				}),
+			}))
+		}
+		readTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan int64, 10)
+	drops := make(chan testDrop, 1)
+	subscription, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {
+		received <- evnt.Event.EventNumber
+	}, goes.WithOnDropped(func(reason goes.SubscriptionDropReason, err error) {
+		drops <- testDrop{reason: reason, err: err}
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	select {
+	case drop := <-drops:
+		if drop.reason != goes.SubscriptionDropReasonSubscriberMaxCountReached {
+			t.Fatalf("Expected %v got %v", goes.SubscriptionDropReasonSubscriberMaxCountReached, drop.reason)
+		}
+		exhausted, ok := drop.err.(*goes.ErrResubscribesExhausted)
+		if !ok || exhausted.Resubscribes != 2 {
+			t.Fatalf("Expected %v got %v", &goes.ErrResubscribesExhausted{Resubscribes: 2}, drop.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the subscription to be dropped")
+	}
+	<-subscription.Done()
+	if _, ok := subscription.Err().(*goes.ErrResubscribesExhausted); !ok {
+		t.Fatalf("Expected %T got %v", &goes.ErrResubscribesExhausted{}, subscription.Err())
+	}
+	if len(received) != 2 {
+		t.Fatalf("Expected %v got %v", 2, len(received))
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[1] != 1 || attempts[2] != 2 {
+		t.Fatalf("Expected %v got %v", []int{1, 1, 2}, attempts)
+	}
+}
@@ -0,0 +1,93 @@
+package goes
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// socketWriter writes the packages of a connection to its socket from a single goroutine, so that every package is written
+// whole and in the order it was queued regardless of how many goroutines are sending
+type socketWriter struct {
+	socket net.Conn
+	// queue is unbuffered so that a write is either taken by the writer goroutine, which then always reports its result,
+	// or fails once the writer is stopped
+	queue chan writeRequest
+	stop  chan struct{}
+	// timeout bounds each write when it is set
+	timeout time.Duration
+	// timedOut is set once a write timed out and the socket was closed
+	timedOut int32
+}
+
+type writeRequest struct {
+	data   []byte
+	result chan error
+}
+
+func newSocketWriter(socket net.Conn, timeout time.Duration) *socketWriter {
+	writer := &socketWriter{
+		socket:  socket,
+		queue:   make(chan writeRequest),
+		stop:    make(chan struct{}),
+		timeout: timeout,
+	}
+	go writer.run()
+	return writer
+}
+
+func (writer *socketWriter) run() {
+	for {
+		select {
+		case request := <-writer.queue:
+			request.result <- writer.send(request.data)
+		case <-writer.stop:
+			return
+		}
+	}
+}
+
+// send writes the data to the socket within the timeout. A package that timed out may have been written partially, which
+// leaves the socket unusable, so it is closed and the socket reader re-establishes the connection.
+func (writer *socketWriter) send(data []byte) error {
+	if atomic.LoadInt32(&writer.timedOut) == 1 {
+		return ErrWriteTimeout
+	}
+	if writer.timeout > 0 {
+		err := writer.socket.SetWriteDeadline(time.Now().Add(writer.timeout))
+		if err != nil {
+			return err
+		}
+	}
+	_, err := writer.socket.Write(data)
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		atomic.StoreInt32(&writer.timedOut, 1)
+		writer.socket.Close()
+		return ErrWriteTimeout
+	}
+	return err
+}
+
+// write queues the data and waits until it has been written to the socket. The data must not be modified until write returns.
+func (writer *socketWriter) write(data []byte) error {
+	result := make(chan error, 1)
+	select {
+	case writer.queue <- writeRequest{data: data, result: result}:
+	case <-writer.stop:
+		return ErrConnectionClosed
+	}
+	return <-result
+}
+
+// close stops the writer goroutine, packages that are queued afterwards fail with ErrConnectionClosed
+func (writer *socketWriter) close() {
+	close(writer.stop)
+}
+
+// writeTimedOut reports whether the socket was closed because a package could not be written to it in time
+func (connection *EventStoreConnection) writeTimedOut(socket net.Conn) bool {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	writer := connection.writer
+	return writer != nil && writer.socket == socket && atomic.LoadInt32(&writer.timedOut) == 1
+}
@@ -0,0 +1,86 @@
+package goes
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionStats is a snapshot of the counters of a connection, a dependency free alternative to Metrics for reporting the
+// health of the client. The counters are kept over the lifetime of the connection, across reconnects.
+type ConnectionStats struct {
+	BytesRead    int64
+	BytesWritten int64
+	// PackagesSent is the number of packages sent to the server by their command
+	PackagesSent map[Command]int64
+	// PackagesReceived is the number of packages received from the server by their command
+	PackagesReceived map[Command]int64
+	// Reconnects is the number of times a lost connection was re-established
+	Reconnects int64
+	// Inflight is the number of operations waiting for a response
+	Inflight int
+	// Subscriptions is the number of active subscriptions
+	Subscriptions int
+	// LastHeartbeat is when the last heartbeat request was received from the server, or the zero time when none was received
+	LastHeartbeat time.Time
+	// Uptime is how long the current socket has been connected, or zero when the connection is not connected
+	Uptime time.Duration
+}
+
+// connectionStats holds the counters of a connection, they are updated atomically so that they can be read at any time
+type connectionStats struct {
+	bytesRead        int64
+	bytesWritten     int64
+	packagesSent     [256]int64
+	packagesReceived [256]int64
+	reconnects       int64
+	// lastHeartbeat and connectedAt are unix nano timestamps, zero when unset
+	lastHeartbeat int64
+	connectedAt   int64
+}
+
+// Stats returns a snapshot of the connection's counters
+func (connection *EventStoreConnection) Stats() ConnectionStats {
+	connection.mutex.Lock()
+	connected := connection.state == ConnectionStateConnected
+	subscriptions := len(connection.subscriptions)
+	connection.mutex.Unlock()
+
+	stats := &connection.stats
+	snapshot := ConnectionStats{
+		BytesRead:        atomic.LoadInt64(&stats.bytesRead),
+		BytesWritten:     atomic.LoadInt64(&stats.bytesWritten),
+		PackagesSent:     countsByCommand(&stats.packagesSent),
+		PackagesReceived: countsByCommand(&stats.packagesReceived),
+		Reconnects:       atomic.LoadInt64(&stats.reconnects),
+		Inflight:         int(atomic.LoadInt64(&connection.inflightCount)),
+		Subscriptions:    subscriptions,
+	}
+	if lastHeartbeat := atomic.LoadInt64(&stats.lastHeartbeat); lastHeartbeat != 0 {
+		snapshot.LastHeartbeat = time.Unix(0, lastHeartbeat)
+	}
+	if connectedAt := atomic.LoadInt64(&stats.connectedAt); connected && connectedAt != 0 {
+		snapshot.Uptime = time.Since(time.Unix(0, connectedAt))
+	}
+	return snapshot
+}
+
+// countsByCommand returns the non-zero counts of the commands
+func countsByCommand(counts *[256]int64) map[Command]int64 {
+	result := make(map[Command]int64)
+	for command := range counts {
+		if count := atomic.LoadInt64(&counts[command]); count != 0 {
+			result[Command(command)] = count
+		}
+	}
+	return result
+}
+
+func (stats *connectionStats) packageSent(command Command, length int) {
+	atomic.AddInt64(&stats.bytesWritten, int64(length))
+	atomic.AddInt64(&stats.packagesSent[command], 1)
+}
+
+func (stats *connectionStats) packageReceived(command Command, length int) {
+	atomic.AddInt64(&stats.bytesRead, int64(length))
+	atomic.AddInt64(&stats.packagesReceived[command], 1)
+}
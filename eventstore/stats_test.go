@@ -0,0 +1,97 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+func TestStats_CountsPackagesAndHeartbeats(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		for {
+			pkg, err := readRawTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command != pingCommand {
+				continue
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       heartbeatRequestCommand,
+				CorrelationID: pkg.CorrelationID,
+			}))
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       pongCommand,
+				CorrelationID: pkg.CorrelationID,
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	stats := conn.Stats()
+	if sent := stats.PackagesSent[goes.Command(pingCommand)]; sent != 1 {
+		t.Fatalf("Expected %v got %v", 1, sent)
+	}
+	if received := stats.PackagesReceived[goes.Command(pongCommand)]; received != 1 {
+		t.Fatalf("Expected %v got %v", 1, received)
+	}
+	if received := stats.PackagesReceived[goes.Command(heartbeatRequestCommand)]; received != 1 {
+		t.Fatalf("Expected %v got %v", 1, received)
+	}
+	// every package is at least the length prefix, command, flags and correlation id
+	if stats.BytesRead < 2*22 || stats.BytesWritten < 2*22 {
+		t.Fatalf("Expected the packages to be counted got %v bytes read and %v bytes written", stats.BytesRead, stats.BytesWritten)
+	}
+	if stats.LastHeartbeat.IsZero() || time.Since(stats.LastHeartbeat) > 5*time.Second {
+		t.Fatalf("Expected the heartbeat to be recorded got %v", stats.LastHeartbeat)
+	}
+	if stats.Uptime <= 0 {
+		t.Fatalf("Expected the connection to be up got %v", stats.Uptime)
+	}
+	if stats.Inflight != 0 || stats.Subscriptions != 0 || stats.Reconnects != 0 {
+		t.Fatalf("Expected no operations, subscriptions or reconnects got %+v", stats)
+	}
+}
+
+func TestStats_CountsReconnects(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			socket.Close()
+		},
+		func(socket net.Conn) {
+			readRawTestPackage(socket)
+		})
+	defer listener.Close()
+	defer conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.Stats().Reconnects != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v got %v", 1, conn.Stats().Reconnects)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStats_WhenClosed(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		readRawTestPackage(socket)
+	})
+	defer listener.Close()
+	conn.Close()
+
+	if uptime := conn.Stats().Uptime; uptime != 0 {
+		t.Fatalf("Expected %v got %v", time.Duration(0), uptime)
+	}
+}
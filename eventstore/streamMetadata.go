@@ -0,0 +1,136 @@
+package goes
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/satori/go.uuid"
+)
+
+const streamMetadataEventType = "$metadata"
+
+// StreamMetadata describes the retention policy and access control of a stream. The zero value of a field leaves it unset,
+// in which case the server's defaults apply.
+type StreamMetadata struct {
+	// MaxAge is the number of seconds an event is kept for before it is scavenged
+	MaxAge int64 `json:"$maxAge,omitempty"`
+	// MaxCount is the number of most recent events that are kept
+	MaxCount int64 `json:"$maxCount,omitempty"`
+	// TruncateBefore hides and eventually scavenges the events before this event number
+	TruncateBefore int64 `json:"$tb,omitempty"`
+	// CacheControl is the number of seconds the stream's pages may be cached for by http clients
+	CacheControl int64 `json:"$cacheControl,omitempty"`
+	// ACL restricts the users and roles that have access to the stream
+	ACL *StreamACL `json:"$acl,omitempty"`
+}
+
+// StreamACL lists the users and roles that are allowed to perform each kind of operation on a stream
+type StreamACL struct {
+	ReadRoles      []string `json:"$r,omitempty"`
+	WriteRoles     []string `json:"$w,omitempty"`
+	DeleteRoles    []string `json:"$d,omitempty"`
+	MetaReadRoles  []string `json:"$mr,omitempty"`
+	MetaWriteRoles []string `json:"$mw,omitempty"`
+}
+
+// SetStreamMetadata replaces the metadata of the stream, provided that the stream's metadata is at the expected version
+func (connection *EventStoreConnection) SetStreamMetadata(stream string, expectedMetaVersion int64, meta StreamMetadata, options ...OperationOption) error {
+	return connection.SetStreamMetadataWithContext(context.Background(), stream, expectedMetaVersion, meta, options...)
+}
+
+// SetStreamMetadataWithContext is like SetStreamMetadata but gives up when ctx is cancelled
+func (connection *EventStoreConnection) SetStreamMetadataWithContext(ctx context.Context, stream string, expectedMetaVersion int64, meta StreamMetadata, options ...OperationOption) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return err
+	}
+	return connection.writeStreamMetadata(ctx, stream, expectedMetaVersion, data, options)
+}
+
+// GetStreamMetadata reads the current metadata of the stream. A stream whose metadata was never set has empty metadata.
+func (connection *EventStoreConnection) GetStreamMetadata(stream string, options ...OperationOption) (*StreamMetadata, error) {
+	return connection.GetStreamMetadataWithContext(context.Background(), stream, options...)
+}
+
+// GetStreamMetadataWithContext is like GetStreamMetadata but gives up when ctx is cancelled
+func (connection *EventStoreConnection) GetStreamMetadataWithContext(ctx context.Context, stream string, options ...OperationOption) (*StreamMetadata, error) {
+	data, _, err := connection.readStreamMetadata(ctx, stream, options)
+	if err != nil {
+		return nil, err
+	}
+	meta := &StreamMetadata{}
+	if len(data) == 0 {
+		return meta, nil
+	}
+	if err := json.Unmarshal(data, meta); err != nil {
+		connection.logger().Errorf("unmarshaling error: %s", err)
+		return nil, err
+	}
+	return meta, nil
+}
+
+// TruncateStream hides the events before the event number, reads of the stream return as if they do not exist and they are
+// removed by the next scavenge. The other metadata of the stream, including properties StreamMetadata does not know about,
+// is kept. The metadata is written at the version it was read at, so the truncation fails with ErrWrongExpectedVersion when
+// the metadata is changed concurrently.
+func (connection *EventStoreConnection) TruncateStream(stream string, beforeEventNumber int64, options ...OperationOption) error {
+	return connection.TruncateStreamWithContext(context.Background(), stream, beforeEventNumber, options...)
+}
+
+// TruncateStreamWithContext is like TruncateStream but gives up when ctx is cancelled
+func (connection *EventStoreConnection) TruncateStreamWithContext(ctx context.Context, stream string, beforeEventNumber int64, options ...OperationOption) error {
+	data, version, err := connection.readStreamMetadata(ctx, stream, options)
+	if err != nil {
+		return err
+	}
+	properties := map[string]json.RawMessage{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &properties); err != nil {
+			connection.logger().Errorf("unmarshaling error: %s", err)
+			return err
+		}
+	}
+	properties["$tb"], err = json.Marshal(beforeEventNumber)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(properties)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return err
+	}
+	return connection.writeStreamMetadata(ctx, stream, version, data, options)
+}
+
+// readStreamMetadata reads the raw metadata of the stream and the version of its metastream. A stream whose metadata was
+// never set has no metadata at ExpectedVersionNoStream.
+func (connection *EventStoreConnection) readStreamMetadata(ctx context.Context, stream string, options []OperationOption) ([]byte, int64, error) {
+	message, err := readStreamEventsCompleted(ctx, connection, readStreamEventsBackward, readStreamEventsBackwardCompleted, metastreamOf(stream), StreamPositionEnd, 1, false, connection.requireMaster(options), connection.credentials(options))
+	if err == ErrNoStream {
+		return nil, ExpectedVersionNoStream, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(message.GetEvents()) == 0 {
+		return nil, int64(message.GetLastEventNumber()), nil
+	}
+	return message.GetEvents()[0].GetEvent().GetData(), int64(message.GetLastEventNumber()), nil
+}
+
+func (connection *EventStoreConnection) writeStreamMetadata(ctx context.Context, stream string, expectedMetaVersion int64, data []byte, options []OperationOption) error {
+	evnt := EventData{
+		EventID:   uuid.NewV4(),
+		EventType: streamMetadataEventType,
+		IsJSON:    true,
+		Data:      data,
+	}
+	_, err := connection.WriteEventsWithContext(ctx, metastreamOf(stream), expectedMetaVersion, []EventData{evnt}, options...)
+	return err
+}
+
+// metastreamOf returns the name of the stream that holds the metadata of the stream
+func metastreamOf(stream string) string {
+	return "$$" + stream
+}
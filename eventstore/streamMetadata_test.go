@@ -0,0 +1,197 @@
+package goes_test
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+const (
+	readStreamEventsBackwardCommand          byte = 0xB4
+	readStreamEventsBackwardCompletedCommand byte = 0xB5
+)
+
+func TestSetStreamMetadata(t *testing.T) {
+	written := make(chan *protobuf.WriteEvents, 1)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		request := &protobuf.WriteEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		written <- request
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       writeEventsCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data:          newTestWriteEventsCompleted(t),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	meta := goes.StreamMetadata{MaxCount: 10, ACL: &goes.StreamACL{ReadRoles: []string{"$admins"}}}
+	if err := conn.SetStreamMetadata("orders", goes.ExpectedVersionAny, meta); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	request := <-written
+	if request.GetEventStreamId() != "$$orders" {
+		t.Fatalf("Expected %v got %v", "$$orders", request.GetEventStreamId())
+	}
+	evnt := request.GetEvents()[0]
+	if evnt.GetEventType() != "$metadata" || evnt.GetDataContentType() != 1 {
+		t.Fatalf("Expected a json $metadata event got %+v", evnt)
+	}
+	expected := `{"$maxCount":10,"$acl":{"$r":["$admins"]}}`
+	if string(evnt.GetData()) != expected {
+		t.Fatalf("Expected %v got %v", expected, string(evnt.GetData()))
+	}
+}
+
+func TestGetStreamMetadata(t *testing.T) {
+	expected := goes.StreamMetadata{MaxAge: 3600, TruncateBefore: 5, CacheControl: 60, ACL: &goes.StreamACL{WriteRoles: []string{"ops"}}}
+	data, _ := json.Marshal(expected)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		request := &protobuf.ReadStreamEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		if request.GetEventStreamId() != "$$orders" {
+			return
+		}
+		record := newTestEventRecord("$$orders", 0)
+		record.Data = data
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsBackwardCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+				Events:             []*protobuf.ResolvedIndexedEvent{{Event: record}},
+				Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+				NextEventNumber:    proto.Int32(-1),
+				LastEventNumber:    proto.Int32(0),
+				IsEndOfStream:      proto.Bool(true),
+				LastCommitPosition: proto.Int64(0),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	meta, err := conn.GetStreamMetadata("orders")
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if !reflect.DeepEqual(*meta, expected) {
+		t.Fatalf("Expected %+v got %+v", expected, meta)
+	}
+}
+
+func TestGetStreamMetadata_WhenNoMetadataWasSet(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsBackwardCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+				Result:             protobuf.ReadStreamEventsCompleted_NoStream.Enum(),
+				NextEventNumber:    proto.Int32(-1),
+				LastEventNumber:    proto.Int32(-1),
+				IsEndOfStream:      proto.Bool(true),
+				LastCommitPosition: proto.Int64(0),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	meta, err := conn.GetStreamMetadata("orders")
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if !reflect.DeepEqual(*meta, goes.StreamMetadata{}) {
+		t.Fatalf("Expected empty metadata got %+v", meta)
+	}
+}
+
+// startTestTruncateServer answers the read of the metastream with the metadata, when it is not nil, at the version and
+// reports the metadata write that follows
+func startTestTruncateServer(t *testing.T, metadata []byte, version int32, written chan *protobuf.WriteEvents) (*goes.EventStoreConnection, *fakeserver.Server) {
+	result := &protobuf.ReadStreamEventsCompleted{
+		Result:             protobuf.ReadStreamEventsCompleted_NoStream.Enum(),
+		NextEventNumber:    proto.Int32(-1),
+		LastEventNumber:    proto.Int32(version),
+		IsEndOfStream:      proto.Bool(true),
+		LastCommitPosition: proto.Int64(0),
+	}
+	if metadata != nil {
+		record := newTestEventRecord("$$orders", version)
+		record.Data = metadata
+		result.Result = protobuf.ReadStreamEventsCompleted_Success.Enum()
+		result.Events = []*protobuf.ResolvedIndexedEvent{{Event: record}}
+	}
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	server.Handle(fakeserver.ReadStreamEventsBackward, func(serverConn *fakeserver.Conn, pkg fakeserver.Package) {
+		serverConn.Respond(pkg, fakeserver.ReadStreamEventsBackwardCompleted, result)
+	})
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(written))
+	return conn, server
+}
+
+func TestTruncateStream_KeepsTheOtherMetadata(t *testing.T) {
+	written := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestTruncateServer(t, []byte(`{"$maxAge":3600,"$tb":2,"owner":"billing"}`), 3, written)
+	defer server.Close()
+	defer conn.Close()
+
+	if err := conn.TruncateStream("orders", 10); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	request := <-written
+	if request.GetEventStreamId() != "$$orders" {
+		t.Fatalf("Expected %v got %v", "$$orders", request.GetEventStreamId())
+	}
+	if request.GetExpectedVersion() != 3 {
+		t.Fatalf("Expected %v got %v", 3, request.GetExpectedVersion())
+	}
+	var actual map[string]interface{}
+	if err := json.Unmarshal(request.GetEvents()[0].GetData(), &actual); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	expected := map[string]interface{}{"$maxAge": 3600.0, "$tb": 10.0, "owner": "billing"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v got %+v", expected, actual)
+	}
+}
+
+func TestTruncateStream_WhenNoMetadataWasSet(t *testing.T) {
+	written := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestTruncateServer(t, nil, -1, written)
+	defer server.Close()
+	defer conn.Close()
+
+	if err := conn.TruncateStream("orders", 5); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	request := <-written
+	if request.GetExpectedVersion() != goes.ExpectedVersionNoStream {
+		t.Fatalf("Expected %v got %v", goes.ExpectedVersionNoStream, request.GetExpectedVersion())
+	}
+	expected := `{"$tb":5}`
+	if data := string(request.GetEvents()[0].GetData()); data != expected {
+		t.Fatalf("Expected %v got %v", expected, data)
+	}
+}
@@ -0,0 +1,17 @@
+package goes
+
+import "context"
+
+// allStream is the stream id used to subscribe to the $all stream
+const allStream = ""
+
+// SubscribeToAll subscribes to the events that are written to any stream from now on and passes each of them to the handler.
+// The position of each event is set so that it can be used as a checkpoint. Call Unsubscribe on the returned subscription to stop it.
+func (connection *EventStoreConnection) SubscribeToAll(resolveLinks bool, handler func(ResolvedEvent), options ...OperationOption) (*Subscription, error) {
+	return connection.SubscribeToAllWithContext(context.Background(), resolveLinks, handler, options...)
+}
+
+// SubscribeToAllWithContext is like SubscribeToAll but gives up waiting for the subscription to be confirmed when ctx is cancelled
+func (connection *EventStoreConnection) SubscribeToAllWithContext(ctx context.Context, resolveLinks bool, handler func(ResolvedEvent), options ...OperationOption) (*Subscription, error) {
+	return subscribe(ctx, connection, allStream, resolveLinks, subscriptionHandler(handler), nil, nil, onDroppedOption(options), connection.credentials(options))
+}
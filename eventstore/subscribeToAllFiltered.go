@@ -0,0 +1,96 @@
+package goes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// FilterTarget is the part of an event that a filter is matched against
+type FilterTarget int
+
+const (
+	// FilterTargetEventType matches the type of the event
+	FilterTargetEventType FilterTarget = iota
+	// FilterTargetStreamID matches the id of the stream the event was written to
+	FilterTargetStreamID
+)
+
+// FilterMode is how the values of a filter are matched
+type FilterMode int
+
+const (
+	// FilterModePrefix matches the events that start with any of the values
+	FilterModePrefix FilterMode = iota
+	// FilterModeRegex matches the events that match the regular expression, which is evaluated by the server
+	FilterModeRegex
+)
+
+// Filter selects the events that the server delivers on a filtered subscription
+type Filter struct {
+	Target FilterTarget
+	Mode   FilterMode
+	// Values are the prefixes to match, or a single regular expression in FilterModeRegex
+	Values []string
+}
+
+// SubscribeToAllFiltered subscribes to the events that are written to any stream from now on and that match the filter, passing
+// each of them to the handler. The server scans up to checkpointInterval events before it passes the position it reached to
+// checkpointReached, so that consumers can store their progress even when no events match. checkpointReached may be nil.
+func (connection *EventStoreConnection) SubscribeToAllFiltered(filter Filter, checkpointInterval int, resolveLinks bool, handler func(ResolvedEvent), checkpointReached func(Position), options ...OperationOption) (*Subscription, error) {
+	return connection.SubscribeToAllFilteredWithContext(context.Background(), filter, checkpointInterval, resolveLinks, handler, checkpointReached, options...)
+}
+
+// SubscribeToAllFilteredWithContext is like SubscribeToAllFiltered but gives up waiting for the subscription to be confirmed when ctx is cancelled
+func (connection *EventStoreConnection) SubscribeToAllFilteredWithContext(ctx context.Context, filter Filter, checkpointInterval int, resolveLinks bool, handler func(ResolvedEvent), checkpointReached func(Position), options ...OperationOption) (*Subscription, error) {
+	filterData, err := filter.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if checkpointInterval <= 0 {
+		return nil, errors.New("the checkpoint interval must be positive")
+	}
+	interval, err := int32Argument("checkpointInterval", int64(checkpointInterval))
+	if err != nil {
+		return nil, err
+	}
+	subscriptionData := &protobuf.FilteredSubscribeToStream{
+		EventStreamId:      proto.String(allStream),
+		ResolveLinkTos:     proto.Bool(resolveLinks),
+		Filter:             filterData,
+		CheckpointInterval: proto.Int32(interval),
+	}
+	subscription := newSubscription(connection, uuid.NewV4(), make(chan TCPPackage, connection.subscriptionBufferSize()), subscriptionHandler(handler), nil)
+	subscription.credentials = connection.credentials(options)
+	subscription.onDropped = onDroppedOption(options)
+	subscription.checkpointReached = checkpointReached
+	err = startSubscription(ctx, subscription, filteredSubscribeToStream, "", subscriptionData)
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (filter Filter) marshal() (*protobuf.Filter, error) {
+	if len(filter.Values) == 0 {
+		return nil, errors.New("the filter has no values")
+	}
+	message := &protobuf.Filter{
+		Context: protobuf.Filter_EventType.Enum(),
+		Type:    protobuf.Filter_Prefix.Enum(),
+		Data:    filter.Values,
+	}
+	if filter.Target == FilterTargetStreamID {
+		message.Context = protobuf.Filter_StreamId.Enum()
+	}
+	if filter.Mode == FilterModeRegex {
+		if len(filter.Values) > 1 {
+			return nil, errors.New("a regex filter has a single regular expression")
+		}
+		message.Type = protobuf.Filter_Regex.Enum()
+	}
+	return message, nil
+}
@@ -0,0 +1,25 @@
+package goes
+
+import (
+	"context"
+
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+// SubscribeToStream subscribes to the events that are written to the stream from now on and passes each of them to the handler.
+// The handler is called from a single goroutine, one event at a time. Call Unsubscribe on the returned subscription to stop it.
+func (connection *EventStoreConnection) SubscribeToStream(stream string, resolveLinks bool, handler func(ResolvedEvent), options ...OperationOption) (*Subscription, error) {
+	return connection.SubscribeToStreamWithContext(context.Background(), stream, resolveLinks, handler, options...)
+}
+
+// SubscribeToStreamWithContext is like SubscribeToStream but gives up waiting for the subscription to be confirmed when ctx is cancelled
+func (connection *EventStoreConnection) SubscribeToStreamWithContext(ctx context.Context, stream string, resolveLinks bool, handler func(ResolvedEvent), options ...OperationOption) (*Subscription, error) {
+	return subscribe(ctx, connection, stream, resolveLinks, subscriptionHandler(handler), nil, nil, onDroppedOption(options), connection.credentials(options))
+}
+
+// subscriptionHandler passes the events that appear on a subscription to the handler along with their position
+func subscriptionHandler(handler func(ResolvedEvent)) eventAppeared {
+	return func(appeared *protobuf.StreamEventAppeared) {
+		handler(newPositionedEvent(appeared.GetEvent()))
+	}
+}
@@ -1,7 +1,9 @@
 package goes
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"sync"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pgermishuys/goes/protobuf"
@@ -16,51 +18,336 @@ type Subscription struct {
 	EventAppeared eventAppeared
 	Dropped       dropped
 	Started       bool
+
+	// done is closed once the subscription has been dropped
+	done chan struct{}
+	// stopped is closed by Stop, the goroutines that pass messages to the Channel give up once it is closed. The Channel
+	// itself is never closed by the subscription, so that a pending send can not panic.
+	stopped chan struct{}
+	// subscribeCommand and subscribeData are sent again to resubscribe after a reconnect
+	subscribeCommand Command
+	subscribeData    []byte
+	// confirmed is called when the subscription is confirmed after resubscribing
+	confirmed func()
+	// subscriptionID and autoAck are only set for persistent subscriptions
+	subscriptionID string
+	autoAck        bool
+	// persistentHandler is set for persistent subscriptions that acknowledge the events the handler processed and retry
+	// the others according to the retry policy
+	persistentHandler func(evnt ResolvedEvent, attempt int) error
+	// credentials authenticate the packages sent for the subscription
+	credentials UserCredentials
+	// checkpointReached is only set for filtered subscriptions
+	checkpointReached func(Position)
+	// onDropped is called with the reason once the subscription has been dropped
+	onDropped func(SubscriptionDropReason, error)
+
+	mutex sync.Mutex
+	// pending holds the messages that wait for room in the Channel, they are moved to the Channel by a dispatch goroutine
+	// that runs while dispatching is set so that the socket reader never waits for a slow handler
+	pending     []TCPPackage
+	dispatching bool
+	// reason and err describe why the subscription was dropped. The client sets them before it drops the subscription
+	// itself, otherwise they are set from the server's reason once the subscription is dropped.
+	reason SubscriptionDropReason
+	err    error
+	// unsubscribing is set once the unsubscribe has been sent
+	unsubscribing bool
+	// resubscribes counts the attempts to subscribe again since the last event was received, it is only used by the
+	// goroutine that runs Start
+	resubscribes int
+	// retryPolicy and attempts are only used by persistent subscriptions, attempts counts the deliveries of the events
+	// that have not been acknowledged, parked or skipped yet
+	retryPolicy RetryPolicy
+	attempts    map[uuid.UUID]int
+	// stream, subscriptionType and lastEventNumber describe the subscription to Subscriptions
+	stream           string
+	subscriptionType SubscriptionType
+	lastEventNumber  int64
 }
 
 //NewSubscription creates a new subscription to a stream
 func NewSubscription(connection *EventStoreConnection, correlationID uuid.UUID, channel chan TCPPackage, appeared eventAppeared, dropped dropped) (*Subscription, error) {
-	subscription := &Subscription{
+	subscription := newSubscription(connection, correlationID, channel, appeared, dropped)
+	go subscription.Start()
+	return subscription, nil
+}
+
+func newSubscription(connection *EventStoreConnection, correlationID uuid.UUID, channel chan TCPPackage, appeared eventAppeared, dropped dropped) *Subscription {
+	return &Subscription{
 		Connection:    connection,
 		CorrelationID: correlationID,
 		Channel:       channel,
 		EventAppeared: appeared,
 		Dropped:       dropped,
+		Started:       true,
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		credentials:   connection.credentials(nil),
+
+		lastEventNumber: -1,
 	}
-	go subscription.Start()
-	return subscription, nil
 }
 
-//Stop stops a subscription from receiving events
+//Stop stops a subscription from receiving events, the messages that have not been handled yet are discarded
 func (subscription *Subscription) Stop() error {
-	log.Printf("[info] Stopping subscription")
+	subscription.Connection.logger().Infof("Stopping subscription")
 	subscription.Started = false
-	subscription.Connection.requests[subscription.CorrelationID] = nil
-	close(subscription.Channel)
+	subscription.unregister()
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	select {
+	case <-subscription.stopped:
+	default:
+		close(subscription.stopped)
+	}
+	subscription.pending = nil
 	return nil
 }
 
+// Unsubscribe asks the server to drop the subscription and waits until it has been dropped. It is safe to call more than once,
+// also concurrently, the unsubscribe is only sent once and Unsubscribe returns nil once the subscription has been dropped.
+func (subscription *Subscription) Unsubscribe() error {
+	return subscription.UnsubscribeWithContext(context.Background())
+}
+
+// UnsubscribeWithContext is like Unsubscribe but gives up waiting for the subscription to be dropped when ctx is cancelled
+func (subscription *Subscription) UnsubscribeWithContext(ctx context.Context) error {
+	select {
+	case <-subscription.done:
+		return nil
+	default:
+	}
+	// the unsubscribe is only sent once, later calls wait for the same drop
+	subscription.mutex.Lock()
+	send := !subscription.unsubscribing
+	subscription.unsubscribing = true
+	subscription.mutex.Unlock()
+	if send {
+		err := sendSubscriptionPackage(subscription, unsubscribeFromStream, &protobuf.UnsubscribeFromStream{})
+		if err != nil {
+			subscription.mutex.Lock()
+			subscription.unsubscribing = false
+			subscription.mutex.Unlock()
+			return err
+		}
+	}
+	select {
+	case <-subscription.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Done returns a channel that is closed once the subscription has been dropped
+func (subscription *Subscription) Done() <-chan struct{} {
+	return subscription.done
+}
+
+// Err returns the error the subscription was dropped with, such as ErrSubscriptionBufferOverflow, once Done is closed.
+// It is nil while the subscription is active and when the subscription was unsubscribed.
+func (subscription *Subscription) Err() error {
+	select {
+	case <-subscription.done:
+		subscription.mutex.Lock()
+		defer subscription.mutex.Unlock()
+		return subscription.err
+	default:
+		return nil
+	}
+}
+
+// dropping records why the client is about to drop the subscription
+func (subscription *Subscription) dropping(reason SubscriptionDropReason, err error) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	if subscription.err == nil {
+		subscription.reason = reason
+		subscription.err = err
+	}
+}
+
+func (subscription *Subscription) unregister() {
+	connection := subscription.Connection
+	connection.mutex.Lock()
+	delete(connection.requests, subscription.CorrelationID)
+	delete(connection.subscriptions, subscription.CorrelationID)
+	connection.mutex.Unlock()
+}
+
+// correlationID returns the id the subscription is currently registered with, which changes when it is resubscribed
+func (subscription *Subscription) correlationID() uuid.UUID {
+	subscription.Connection.mutex.Lock()
+	defer subscription.Connection.mutex.Unlock()
+	return subscription.CorrelationID
+}
+
+// enqueue passes the message to the subscription without waiting for its handler. The message is queued behind the pending
+// messages when the Channel is full.
+func (subscription *Subscription) enqueue(pkg TCPPackage) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	if !subscription.dispatching {
+		select {
+		case subscription.Channel <- pkg:
+			return
+		default:
+		}
+		subscription.dispatching = true
+		go subscription.dispatch()
+	}
+	subscription.pending = append(subscription.pending, pkg)
+}
+
+// dispatch moves the pending messages to the Channel in order as the handler makes room
+func (subscription *Subscription) dispatch() {
+	for {
+		subscription.mutex.Lock()
+		if len(subscription.pending) == 0 {
+			subscription.dispatching = false
+			subscription.mutex.Unlock()
+			return
+		}
+		pkg := subscription.pending[0]
+		subscription.pending[0] = TCPPackage{}
+		subscription.pending = subscription.pending[1:]
+		subscription.mutex.Unlock()
+		select {
+		case subscription.Channel <- pkg:
+		case <-subscription.stopped:
+			subscription.mutex.Lock()
+			subscription.pending = nil
+			subscription.dispatching = false
+			subscription.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// next returns the next message passed to the Channel, or false once the subscription has been stopped
+func (subscription *Subscription) next() (TCPPackage, bool) {
+	select {
+	case pkg, ok := <-subscription.Channel:
+		return pkg, ok
+	case <-subscription.stopped:
+		return TCPPackage{}, false
+	}
+}
+
 //Start starts a subscription
 func (subscription *Subscription) Start() error {
-	subscription.Started = true
-	for subscription.Started {
-		result := <-subscription.Channel
+	for {
+		result, ok := subscription.next()
+		if !ok {
+			return nil
+		}
 		switch result.Command {
 		case streamEventAppeared:
 			eventAppeared := &protobuf.StreamEventAppeared{}
 			err := proto.Unmarshal(result.Data, eventAppeared)
 			if err != nil {
+				subscription.Connection.reportError(fmt.Errorf("failed to decode stream event appeared: %s", err.Error()))
+				continue
 			}
+			subscription.delivered(eventAppeared.GetEvent())
+			subscription.resubscribes = 0
 			subscription.EventAppeared(eventAppeared)
+		case persistentSubscriptionStreamEventAppeared:
+			persistentEventAppeared := &protobuf.PersistentSubscriptionStreamEventAppeared{}
+			err := proto.Unmarshal(result.Data, persistentEventAppeared)
+			if err != nil {
+				subscription.Connection.reportError(fmt.Errorf("failed to decode persistent subscription stream event appeared: %s", err.Error()))
+				continue
+			}
+			evnt := persistentEventAppeared.GetEvent()
+			resolved := &protobuf.ResolvedEvent{
+				Event:           evnt.GetEvent(),
+				Link:            evnt.GetLink(),
+				CommitPosition:  proto.Int64(0),
+				PreparePosition: proto.Int64(0),
+			}
+			record := evnt.GetEvent()
+			if evnt.GetLink() != nil {
+				record = evnt.GetLink()
+			}
+			eventID, _ := uuid.FromBytes(DecodeNetUUID(record.GetEventId()))
+			attempt := subscription.countAttempt(eventID)
+			subscription.delivered(resolved)
+			subscription.resubscribes = 0
+			if subscription.persistentHandler != nil {
+				subscription.handlePersistentEvent(newResolvedEvent(evnt.GetEvent(), evnt.GetLink()), eventID, attempt)
+				continue
+			}
+			subscription.EventAppeared(&protobuf.StreamEventAppeared{Event: resolved})
+			if subscription.autoAck {
+				err = ackEvents(subscription, []uuid.UUID{eventID})
+				if err != nil {
+					subscription.Connection.reportError(fmt.Errorf("failed to acknowledge event %v: %s", eventID, err.Error()))
+				}
+			}
+		case checkpointReached:
+			checkpoint := &protobuf.CheckpointReached{}
+			err := proto.Unmarshal(result.Data, checkpoint)
+			if err != nil {
+				subscription.Connection.reportError(fmt.Errorf("failed to decode checkpoint reached: %s", err.Error()))
+				continue
+			}
+			if subscription.checkpointReached != nil {
+				subscription.checkpointReached(Position{
+					CommitPosition:  checkpoint.GetCommitPosition(),
+					PreparePosition: checkpoint.GetPreparePosition(),
+				})
+			}
+		case subscriptionConfirmation, persistentSubscriptionConfirmation:
+			if subscription.confirmed != nil {
+				subscription.confirmed()
+			}
 		case subscriptionDropped:
 			subscriptionDropped := &protobuf.SubscriptionDropped{}
 			err := proto.Unmarshal(result.Data, subscriptionDropped)
 			if err != nil {
+				subscription.Connection.reportError(fmt.Errorf("failed to decode subscription dropped: %s", err.Error()))
+			}
+			subscription.unregister()
+			subscription.mutex.Lock()
+			if subscription.err == nil {
+				subscription.reason, subscription.err = subscriptionDropReason(subscriptionDropped)
+			}
+			reason, dropErr := subscription.reason, subscription.err
+			subscription.mutex.Unlock()
+			if subscription.subscribeAgain(reason, dropErr) {
+				continue
 			}
-			subscription.Dropped(subscriptionDropped)
+			subscription.mutex.Lock()
+			reason, dropErr = subscription.reason, subscription.err
+			subscription.mutex.Unlock()
+			if subscription.Dropped != nil {
+				subscription.Dropped(subscriptionDropped)
+			}
+			if subscription.onDropped != nil {
+				subscription.onDropped(reason, dropErr)
+			}
+			close(subscription.done)
+			return nil
 		default:
 			//do something meaningful
 		}
 	}
-	return nil
+}
+
+// sendSubscriptionPackage writes a package with the subscription's correlation id. Any response is delivered on the subscription's
+// channel, so the package is written without registering a request that would replace it.
+func sendSubscriptionPackage(subscription *Subscription, command Command, message proto.Message) error {
+	connection := subscription.Connection
+	data, err := proto.Marshal(message)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return err
+	}
+	pkg, err := newPackage(command, data, subscription.correlationID().Bytes(), subscription.credentials.Login, subscription.credentials.Password)
+	if err != nil {
+		return err
+	}
+	return pkg.write(connection)
 }
@@ -0,0 +1,103 @@
+package goes
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+// SubscriptionDropReason is the reason a subscription was dropped
+type SubscriptionDropReason int
+
+const (
+	// SubscriptionDropReasonUnsubscribed is the reason when the subscription was unsubscribed
+	SubscriptionDropReasonUnsubscribed SubscriptionDropReason = iota
+	// SubscriptionDropReasonAccessDenied is the reason when the credentials do not grant access to the stream
+	SubscriptionDropReasonAccessDenied
+	// SubscriptionDropReasonNotFound is the reason when the persistent subscription does not exist
+	SubscriptionDropReasonNotFound
+	// SubscriptionDropReasonPersistentSubscriptionDeleted is the reason when the persistent subscription was deleted
+	SubscriptionDropReasonPersistentSubscriptionDeleted
+	// SubscriptionDropReasonSubscriberMaxCountReached is the reason when the persistent subscription has its maximum number of subscribers
+	SubscriptionDropReasonSubscriberMaxCountReached
+	// SubscriptionDropReasonConnectionClosed is the reason when the connection was closed or could not be re-established
+	SubscriptionDropReasonConnectionClosed
+	// SubscriptionDropReasonBufferOverflow is the reason when the handler could not keep up with the events
+	SubscriptionDropReasonBufferOverflow
+	// SubscriptionDropReasonServerShutdown is the reason when the node the subscription was subscribed on is shutting down.
+	// The connection is re-established with a healthy node right away, the subscription can be subscribed again from there.
+	SubscriptionDropReasonServerShutdown
+)
+
+// serverShutdownDropReason is the reason a node that is going down drops its subscriptions with. The generated protocol
+// messages predate it.
+const serverShutdownDropReason protobuf.SubscriptionDropped_SubscriptionDropReason = 5
+
+func (reason SubscriptionDropReason) String() string {
+	switch reason {
+	case SubscriptionDropReasonUnsubscribed:
+		return "Unsubscribed"
+	case SubscriptionDropReasonAccessDenied:
+		return "AccessDenied"
+	case SubscriptionDropReasonNotFound:
+		return "NotFound"
+	case SubscriptionDropReasonPersistentSubscriptionDeleted:
+		return "PersistentSubscriptionDeleted"
+	case SubscriptionDropReasonSubscriberMaxCountReached:
+		return "SubscriberMaxCountReached"
+	case SubscriptionDropReasonConnectionClosed:
+		return "ConnectionClosed"
+	case SubscriptionDropReasonBufferOverflow:
+		return "BufferOverflow"
+	case SubscriptionDropReasonServerShutdown:
+		return "ServerShutdown"
+	}
+	return "Unknown"
+}
+
+// WithOnDropped calls onDropped when the subscription is dropped, with the error that caused it unless it was unsubscribed
+func WithOnDropped(onDropped func(reason SubscriptionDropReason, err error)) OperationOption {
+	return func(options *operationOptions) {
+		options.onDropped = onDropped
+	}
+}
+
+func onDroppedOption(options []OperationOption) func(SubscriptionDropReason, error) {
+	resolved := operationOptions{}
+	for _, option := range options {
+		option(&resolved)
+	}
+	return resolved.onDropped
+}
+
+// subscriptionDropReason maps the reason the server dropped a subscription for onto the reason and the error it is reported with
+func subscriptionDropReason(dropped *protobuf.SubscriptionDropped) (SubscriptionDropReason, error) {
+	switch dropped.GetReason() {
+	case protobuf.SubscriptionDropped_Unsubscribed:
+		return SubscriptionDropReasonUnsubscribed, nil
+	case protobuf.SubscriptionDropped_AccessDenied:
+		return SubscriptionDropReasonAccessDenied, ErrAccessDenied
+	case protobuf.SubscriptionDropped_NotFound:
+		return SubscriptionDropReasonNotFound, fmt.Errorf("subscription dropped: %s", SubscriptionDropReasonNotFound)
+	case protobuf.SubscriptionDropped_PersistentSubscriptionDeleted:
+		return SubscriptionDropReasonPersistentSubscriptionDeleted, fmt.Errorf("subscription dropped: %s", SubscriptionDropReasonPersistentSubscriptionDeleted)
+	case protobuf.SubscriptionDropped_SubscriberMaxCountReached:
+		return SubscriptionDropReasonSubscriberMaxCountReached, fmt.Errorf("subscription dropped: %s", SubscriptionDropReasonSubscriberMaxCountReached)
+	case serverShutdownDropReason:
+		return SubscriptionDropReasonServerShutdown, ErrServerShutdown
+	}
+	return SubscriptionDropReasonUnsubscribed, fmt.Errorf("subscription dropped: %s", dropped.GetReason())
+}
+
+// isServerShutdown reports whether the package drops a subscription because the node is shutting down
+func isServerShutdown(pkg TCPPackage) bool {
+	if pkg.Command != subscriptionDropped {
+		return false
+	}
+	dropped := &protobuf.SubscriptionDropped{}
+	if err := proto.Unmarshal(pkg.Data, dropped); err != nil {
+		return false
+	}
+	return dropped.GetReason() == serverShutdownDropReason
+}
@@ -0,0 +1,185 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+type testDrop struct {
+	reason goes.SubscriptionDropReason
+	err    error
+}
+
+func expectTestDrop(t *testing.T, drops chan testDrop, reason goes.SubscriptionDropReason, err error) {
+	select {
+	case drop := <-drops:
+		if drop.reason != reason {
+			t.Fatalf("Expected %v got %v", reason, drop.reason)
+		}
+		if drop.err != err {
+			t.Fatalf("Expected %v got %v", err, drop.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the subscription to be dropped")
+	}
+}
+
+func TestSubscribeToStream_WhenTheServerDropsTheSubscription(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		subscribe, err := confirmTestSubscription(t, socket)
+		if err != nil {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       subscriptionDroppedCommand,
+			CorrelationID: subscribe.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.SubscriptionDropped{
+				Reason: protobuf.SubscriptionDropped_AccessDenied.Enum(),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	drops := make(chan testDrop, 1)
+	subscription, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {},
+		goes.WithOnDropped(func(reason goes.SubscriptionDropReason, err error) {
+			drops <- testDrop{reason: reason, err: err}
+		}))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	expectTestDrop(t, drops, goes.SubscriptionDropReasonAccessDenied, goes.ErrAccessDenied)
+	<-subscription.Done()
+	if err := subscription.Err(); err != goes.ErrAccessDenied {
+		t.Fatalf("Expected %v got %v", goes.ErrAccessDenied, err)
+	}
+}
+
+func TestSubscribeToStream_WhenTheConnectionIsClosed(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		if _, err := confirmTestSubscription(t, socket); err != nil {
+			return
+		}
+		readRawTestPackage(socket)
+	})
+	defer listener.Close()
+
+	drops := make(chan testDrop, 1)
+	_, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {},
+		goes.WithOnDropped(func(reason goes.SubscriptionDropReason, err error) {
+			drops <- testDrop{reason: reason, err: err}
+		}))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	conn.Close()
+	expectTestDrop(t, drops, goes.SubscriptionDropReasonConnectionClosed, goes.ErrConnectionClosed)
+}
+
+func TestSubscribeToStream_WhenUnsubscribed(t *testing.T) {
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		if _, err := confirmTestSubscription(t, socket); err != nil {
+			return
+		}
+		respondToUnsubscribe(t, socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	drops := make(chan testDrop, 1)
+	subscription, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {},
+		goes.WithOnDropped(func(reason goes.SubscriptionDropReason, err error) {
+			drops <- testDrop{reason: reason, err: err}
+		}))
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if err := subscription.Unsubscribe(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	expectTestDrop(t, drops, goes.SubscriptionDropReasonUnsubscribed, nil)
+}
+
+func TestSubscribeToStream_WhenTheServerShutsDown(t *testing.T) {
+	const writeEventsCommand byte = 0x82
+	commands := make(chan byte, 1)
+	subscribed := make(chan struct{})
+	disconnected := make(chan error, 1)
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.ReconnectionDelay = 1
+	config.OnDisconnected = func(err error) {
+		disconnected <- err
+	}
+	shutdown := marshalTestMessage(t, &protobuf.SubscriptionDropped{
+		Reason: protobuf.SubscriptionDropped_SubscriptionDropReason(5).Enum(),
+	})
+	conn, listener := startTestServerWithHandlers(t, config,
+		func(socket net.Conn) {
+			subscribe, err := confirmTestSubscription(t, socket)
+			if err != nil {
+				return
+			}
+			<-subscribed
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       subscriptionDroppedCommand,
+				CorrelationID: subscribe.CorrelationID,
+				Data:          shutdown,
+			}))
+			readRawTestPackage(socket)
+		},
+		func(socket net.Conn) {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			commands <- pkg.Command
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       writeEventsCompletedCommand,
+				CorrelationID: pkg.CorrelationID,
+				Data:          newTestWriteEventsCompleted(t),
+			}))
+			readRawTestPackage(socket)
+		})
+	defer listener.Close()
+	defer conn.Close()
+
+	drops := make(chan testDrop, 1)
+	_, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {},
+		goes.WithOnDropped(func(reason goes.SubscriptionDropReason, err error) {
+			drops <- testDrop{reason: reason, err: err}
+		}))
+	close(subscribed)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	expectTestDrop(t, drops, goes.SubscriptionDropReasonServerShutdown, goes.ErrServerShutdown)
+	select {
+	case err := <-disconnected:
+		if err != goes.ErrServerShutdown {
+			t.Fatalf("Expected %v got %v", goes.ErrServerShutdown, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the connection to be re-established")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.State() != goes.ConnectionStateConnected {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v got %v", goes.ConnectionStateConnected, conn.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := writeTestEvent(conn, "testStream"); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	// the dropped subscription is not subscribed again on the new connection
+	if command := <-commands; command != writeEventsCommand {
+		t.Fatalf("Expected %v got %v", writeEventsCommand, command)
+	}
+}
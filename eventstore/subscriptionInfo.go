@@ -0,0 +1,88 @@
+package goes
+
+import (
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// SubscriptionType is the kind of a subscription on a connection
+type SubscriptionType int
+
+const (
+	// SubscriptionTypeVolatile is a subscription to a stream or to $all that only receives the events written after it was made
+	SubscriptionTypeVolatile SubscriptionType = iota
+	// SubscriptionTypePersistent is a connection to a persistent subscription group
+	SubscriptionTypePersistent
+	// SubscriptionTypeCatchUp is the live subscription of a catch-up subscription
+	SubscriptionTypeCatchUp
+)
+
+func (subscriptionType SubscriptionType) String() string {
+	switch subscriptionType {
+	case SubscriptionTypeVolatile:
+		return "Volatile"
+	case SubscriptionTypePersistent:
+		return "Persistent"
+	case SubscriptionTypeCatchUp:
+		return "CatchUp"
+	}
+	return "Unknown"
+}
+
+// SubscriptionInfo describes a subscription that is active on a connection
+type SubscriptionInfo struct {
+	// Stream is the stream that is subscribed to, it is empty for subscriptions to $all
+	Stream        string
+	CorrelationID uuid.UUID
+	Type          SubscriptionType
+	// LastEventNumber is the number of the last event delivered to the subscription, or -1 when none was delivered yet.
+	// It is the number of the link when the event was resolved from one.
+	LastEventNumber int64
+	// Subscription is the subscription itself, e.g. to unsubscribe it
+	Subscription *Subscription
+}
+
+// Subscriptions returns the subscriptions that are active on the connection, in no particular order
+func (connection *EventStoreConnection) Subscriptions() []SubscriptionInfo {
+	connection.mutex.Lock()
+	infos := make([]SubscriptionInfo, 0, len(connection.subscriptions))
+	for correlationID, subscription := range connection.subscriptions {
+		infos = append(infos, SubscriptionInfo{
+			CorrelationID: correlationID,
+			Subscription:  subscription,
+		})
+	}
+	connection.mutex.Unlock()
+
+	// the subscription's own fields are read after releasing the connection, which is never locked while holding a subscription
+	for i := range infos {
+		subscription := infos[i].Subscription
+		subscription.mutex.Lock()
+		infos[i].Stream = subscription.stream
+		infos[i].Type = subscription.subscriptionType
+		infos[i].LastEventNumber = subscription.lastEventNumber
+		subscription.mutex.Unlock()
+	}
+	return infos
+}
+
+// delivered records the number of the event that is about to be delivered to the subscription
+func (subscription *Subscription) delivered(evnt *protobuf.ResolvedEvent) {
+	record := evnt.GetEvent()
+	if evnt.GetLink() != nil {
+		record = evnt.GetLink()
+	}
+	if record == nil {
+		return
+	}
+	subscription.mutex.Lock()
+	subscription.lastEventNumber = int64(record.GetEventNumber())
+	subscription.mutex.Unlock()
+}
+
+// setSubscriptionType records the kind of the subscription
+func (subscription *Subscription) setSubscriptionType(subscriptionType SubscriptionType) {
+	subscription.mutex.Lock()
+	subscription.subscriptionType = subscriptionType
+	subscription.mutex.Unlock()
+}
@@ -0,0 +1,291 @@
+package goes_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/satori/go.uuid"
+)
+
+func TestSubscription_WithSlowAndFastSubscribers(t *testing.T) {
+	const events = 200
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.SubscriptionBufferSize = 2
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		slow, err := confirmTestSubscription(t, socket)
+		if err != nil {
+			return
+		}
+		fast, err := confirmTestSubscription(t, socket)
+		if err != nil {
+			return
+		}
+		for {
+			pkg, err := readRawTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command != pingCommand {
+				continue
+			}
+			for i := 0; i < events; i++ {
+				writeTestEventAppeared(t, socket, slow.CorrelationID, "slowStream", int32(i))
+				writeTestEventAppeared(t, socket, fast.CorrelationID, "fastStream", int32(i))
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       pongCommand,
+				CorrelationID: pkg.CorrelationID,
+			}))
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	release := make(chan struct{})
+	slowReceived := make(chan int64, events)
+	_, err := conn.SubscribeToStream("slowStream", false, func(evnt goes.ResolvedEvent) {
+		<-release
+		slowReceived <- evnt.Event.EventNumber
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	fastReceived := make(chan int64, events)
+	_, err = conn.SubscribeToStream("fastStream", false, func(evnt goes.ResolvedEvent) {
+		fastReceived <- evnt.Event.EventNumber
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	// the pong is read after every event, so the slow subscriber must not hold up the socket reader
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := conn.PingWithContext(ctx); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	expectTestEventsInOrder(t, fastReceived, events)
+
+	close(release)
+	expectTestEventsInOrder(t, slowReceived, events)
+}
+
+func TestSubscription_StopWhileMessagesArePending(t *testing.T) {
+	const events = 50
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	config.SubscriptionBufferSize = 1
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		subscribe, err := confirmTestSubscription(t, socket)
+		if err != nil {
+			return
+		}
+		for i := 0; i < events; i++ {
+			writeTestEventAppeared(t, socket, subscribe.CorrelationID, "testStream", int32(i))
+		}
+		readRawTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	release := make(chan struct{})
+	received := make(chan int64, events)
+	subscription, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {
+		received <- evnt.Event.EventNumber
+		<-release
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected an event to be delivered")
+	}
+	// the handler holds up the subscription, so the events that follow wait in the dispatch queue
+	time.Sleep(50 * time.Millisecond)
+	if err := subscription.Stop(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	if len(received) > 1 {
+		t.Fatalf("Expected at most %v more event to be handled after stopping got %v", 1, len(received))
+	}
+}
+
+func expectTestEventsInOrder(t *testing.T, received chan int64, events int) {
+	for i := 0; i < events; i++ {
+		select {
+		case eventNumber := <-received:
+			if eventNumber != int64(i) {
+				t.Fatalf("Expected %v got %v", i, eventNumber)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected event %v to be delivered", i)
+		}
+	}
+}
+
+func TestSubscription_InterleavedWithOperationResponses(t *testing.T) {
+	const writes = 50
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		subscribe, err := confirmTestSubscription(t, socket)
+		if err != nil {
+			return
+		}
+		for i := 0; i < writes; i++ {
+			write, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			// the write response shares the socket with events for the subscription before and after it
+			var frames []byte
+			frames = append(frames, encodeTestPackage(testPackage{
+				Command:       streamEventAppearedCommand,
+				CorrelationID: subscribe.CorrelationID,
+				Data:          newTestEventAppeared(t, "testStream", int32(2*i)),
+			})...)
+			frames = append(frames, encodeTestPackage(testPackage{
+				Command:       writeEventsCompletedCommand,
+				CorrelationID: write.CorrelationID,
+				Data:          newTestWriteEventsCompleted(t),
+			})...)
+			frames = append(frames, encodeTestPackage(testPackage{
+				Command:       streamEventAppearedCommand,
+				CorrelationID: subscribe.CorrelationID,
+				Data:          newTestEventAppeared(t, "testStream", int32(2*i+1)),
+			})...)
+			socket.Write(frames)
+		}
+		respondToUnsubscribe(t, socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan int64, 2*writes)
+	subscription, err := conn.SubscribeToStream("testStream", false, func(evnt goes.ResolvedEvent) {
+		received <- evnt.Event.EventNumber
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	defer subscription.Unsubscribe()
+
+	for i := 0; i < writes; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := conn.WriteEventsWithContext(ctx, "otherStream", goes.ExpectedVersionAny, []goes.EventData{{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}})
+		cancel()
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+	expectTestEventsInOrder(t, received, 2*writes)
+}
+
+func TestSubscription_DropsPackagesForUnknownCorrelationIDs(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		for {
+			pkg, err := readRawTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command != pingCommand {
+				continue
+			}
+			// neither the events of an unknown subscription nor a repeated response may hold up the reader
+			for i := 0; i < 10; i++ {
+				writeTestEventAppeared(t, socket, uuid.NewV4().Bytes(), "unknownStream", int32(i))
+			}
+			for i := 0; i < 10; i++ {
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       pongCommand,
+					CorrelationID: pkg.CorrelationID,
+				}))
+			}
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := conn.PingWithContext(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+	}
+}
+
+func TestSubscriptions_ListsTheActiveSubscriptions(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.KeepAliveInterval = 0
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		first, err := confirmTestSubscription(t, socket)
+		if err != nil {
+			return
+		}
+		if _, err := confirmTestSubscription(t, socket); err != nil {
+			return
+		}
+		writeTestEventAppeared(t, socket, first.CorrelationID, "firstStream", 3)
+		respondToUnsubscribe(t, socket)
+		readRawTestPackage(socket)
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	received := make(chan int64, 1)
+	first, err := conn.SubscribeToStream("firstStream", false, func(evnt goes.ResolvedEvent) {
+		received <- evnt.Event.EventNumber
+	})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if _, err := conn.SubscribeToStream("secondStream", false, func(goes.ResolvedEvent) {}); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the event to be delivered")
+	}
+
+	infos := conn.Subscriptions()
+	if len(infos) != 2 {
+		t.Fatalf("Expected %v got %v", 2, len(infos))
+	}
+	lastEventNumbers := map[string]int64{}
+	for _, info := range infos {
+		if info.Type != goes.SubscriptionTypeVolatile {
+			t.Fatalf("Expected %v got %v", goes.SubscriptionTypeVolatile, info.Type)
+		}
+		if info.CorrelationID != info.Subscription.CorrelationID {
+			t.Fatalf("Expected %v got %v", info.Subscription.CorrelationID, info.CorrelationID)
+		}
+		lastEventNumbers[info.Stream] = info.LastEventNumber
+	}
+	if lastEventNumbers["firstStream"] != 3 {
+		t.Fatalf("Expected %v got %v", 3, lastEventNumbers["firstStream"])
+	}
+	if lastEventNumbers["secondStream"] != -1 {
+		t.Fatalf("Expected %v got %v", -1, lastEventNumbers["secondStream"])
+	}
+
+	if err := first.Unsubscribe(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	infos = conn.Subscriptions()
+	if len(infos) != 1 || infos[0].Stream != "secondStream" {
+		t.Fatalf("Expected only the subscription to %v got %+v", "secondStream", infos)
+	}
+}
@@ -0,0 +1,70 @@
+package goes
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/satori/go.uuid"
+)
+
+const (
+	systemSettingsStream    = "$settings"
+	systemSettingsEventType = "$settings"
+)
+
+// SystemSettings holds the access control that applies to the streams that do not set their own ACL in their metadata
+type SystemSettings struct {
+	// UserStreamACL is the default ACL of the user streams, whose names do not start with $
+	UserStreamACL *StreamACL `json:"$userStreamAcl,omitempty"`
+	// SystemStreamACL is the default ACL of the system streams, whose names start with $
+	SystemStreamACL *StreamACL `json:"$systemStreamAcl,omitempty"`
+}
+
+// SetSystemSettings replaces the system settings, which are kept in the $settings stream. Writing them usually requires
+// admin credentials, ErrAccessDenied is returned otherwise.
+func (connection *EventStoreConnection) SetSystemSettings(settings SystemSettings, options ...OperationOption) error {
+	return connection.SetSystemSettingsWithContext(context.Background(), settings, options...)
+}
+
+// SetSystemSettingsWithContext is like SetSystemSettings but gives up when ctx is cancelled
+func (connection *EventStoreConnection) SetSystemSettingsWithContext(ctx context.Context, settings SystemSettings, options ...OperationOption) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return err
+	}
+	evnt := EventData{
+		EventID:   uuid.NewV4(),
+		EventType: systemSettingsEventType,
+		IsJSON:    true,
+		Data:      data,
+	}
+	_, err = connection.WriteEventsWithContext(ctx, systemSettingsStream, ExpectedVersionAny, []EventData{evnt}, options...)
+	return err
+}
+
+// GetSystemSettings reads the current system settings. The settings are empty when they were never set, in which case the
+// server's defaults apply.
+func (connection *EventStoreConnection) GetSystemSettings(options ...OperationOption) (*SystemSettings, error) {
+	return connection.GetSystemSettingsWithContext(context.Background(), options...)
+}
+
+// GetSystemSettingsWithContext is like GetSystemSettings but gives up when ctx is cancelled
+func (connection *EventStoreConnection) GetSystemSettingsWithContext(ctx context.Context, options ...OperationOption) (*SystemSettings, error) {
+	settings := &SystemSettings{}
+	message, err := readStreamEventsCompleted(ctx, connection, readStreamEventsBackward, readStreamEventsBackwardCompleted, systemSettingsStream, StreamPositionEnd, 1, false, connection.requireMaster(options), connection.credentials(options))
+	if err == ErrNoStream {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(message.GetEvents()) == 0 {
+		return settings, nil
+	}
+	if err := json.Unmarshal(message.GetEvents()[0].GetEvent().GetData(), settings); err != nil {
+		connection.logger().Errorf("unmarshaling error: %s", err)
+		return nil, err
+	}
+	return settings, nil
+}
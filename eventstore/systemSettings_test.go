@@ -0,0 +1,82 @@
+package goes_test
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+)
+
+func TestSetSystemSettings(t *testing.T) {
+	written := make(chan *protobuf.WriteEvents, 1)
+	conn, server := startTestFakeServer(t, goes.NewConfiguration())
+	defer server.Close()
+	defer conn.Close()
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(written))
+
+	settings := goes.SystemSettings{
+		UserStreamACL:   &goes.StreamACL{ReadRoles: []string{"$all"}, WriteRoles: []string{"ops"}},
+		SystemStreamACL: &goes.StreamACL{ReadRoles: []string{"$admins"}},
+	}
+	if err := conn.SetSystemSettings(settings); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	request := <-written
+	if request.GetEventStreamId() != "$settings" {
+		t.Fatalf("Expected %v got %v", "$settings", request.GetEventStreamId())
+	}
+	evnt := request.GetEvents()[0]
+	if evnt.GetEventType() != "$settings" || evnt.GetDataContentType() != 1 {
+		t.Fatalf("Expected a json $settings event got %+v", evnt)
+	}
+	expected := `{"$userStreamAcl":{"$r":["$all"],"$w":["ops"]},"$systemStreamAcl":{"$r":["$admins"]}}`
+	if string(evnt.GetData()) != expected {
+		t.Fatalf("Expected %v got %v", expected, string(evnt.GetData()))
+	}
+}
+
+func TestGetSystemSettings(t *testing.T) {
+	expected := goes.SystemSettings{UserStreamACL: &goes.StreamACL{DeleteRoles: []string{"$admins"}}}
+	data, _ := json.Marshal(expected)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		pkg, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		request := &protobuf.ReadStreamEvents{}
+		proto.Unmarshal(pkg.Data, request)
+		if request.GetEventStreamId() != "$settings" {
+			return
+		}
+		record := newTestEventRecord("$settings", 0)
+		record.Data = data
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       readStreamEventsBackwardCompletedCommand,
+			CorrelationID: pkg.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.ReadStreamEventsCompleted{
+				Events:             []*protobuf.ResolvedIndexedEvent{{Event: record}},
+				Result:             protobuf.ReadStreamEventsCompleted_Success.Enum(),
+				NextEventNumber:    proto.Int32(-1),
+				LastEventNumber:    proto.Int32(0),
+				IsEndOfStream:      proto.Bool(true),
+				LastCommitPosition: proto.Int64(0),
+			}),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	settings, err := conn.GetSystemSettings()
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if !reflect.DeepEqual(*settings, expected) {
+		t.Fatalf("Expected %+v got %+v", expected, settings)
+	}
+}
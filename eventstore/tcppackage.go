@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
 )
 
 // TCPPackage for describing the TCP Package structure from Event Store
@@ -32,35 +35,80 @@ func newPackage(command Command, data []byte, corrID []byte, login string, passw
 	return pkg, nil
 }
 
-func parsePackage(packageBytes []byte) (TCPPackage, error) {
-	reader := bytes.NewReader(packageBytes)
-	var pkg TCPPackage
-	err := binary.Read(reader, binary.LittleEndian, &pkg.PackageLength)
-	if err != nil {
-		return pkg, err
+// bufferPool holds the buffers used to frame packages read from and written to the socket
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// maxPooledBufferSize keeps the buffers of unusually large packages from being held on to by the pool
+const maxPooledBufferSize = 1024 * 1024
+
+func getBuffer() *bytes.Buffer {
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	return buffer
+}
+
+func putBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
 	}
-	err = binary.Read(reader, binary.LittleEndian, &pkg.Command)
+	bufferPool.Put(buffer)
+}
+
+// readPackage reads a single length prefixed package from the reader into the buffer. The returned bytes include the 4 byte
+// length prefix and are only valid until the buffer is reused. Packages longer than maxPackageSize are skipped without being
+// buffered and an *ErrPackageTooLarge is returned.
+func readPackage(reader io.Reader, maxPackageSize int, buffer *bytes.Buffer) ([]byte, error) {
+	buffer.Grow(4)
+	header := buffer.Bytes()[:4]
+	_, err := io.ReadFull(reader, header)
 	if err != nil {
-		return pkg, err
-	}
-	err = binary.Read(reader, binary.LittleEndian, &pkg.Flags)
+		return nil, err
+	}
+	// a package that is too short is read all the same, so that parsePackage rejects it without losing track of the
+	// packages that follow it
+	packageLength := binary.LittleEndian.Uint32(header)
+	if int64(packageLength) > int64(maxPackageSize) {
+		_, err = io.CopyN(ioutil.Discard, reader, int64(packageLength))
+		if err != nil {
+			return nil, err
+		}
+		return nil, &ErrPackageTooLarge{PackageLength: int(packageLength), MaxPackageSize: maxPackageSize}
+	}
+	buffer.Grow(4 + int(packageLength))
+	packageBytes := buffer.Bytes()[:4+packageLength]
+	binary.LittleEndian.PutUint32(packageBytes, packageLength)
+	_, err = io.ReadFull(reader, packageBytes[4:])
 	if err != nil {
-		return pkg, err
+		return nil, err
 	}
-	uuid := make([]byte, 16)
-	err = binary.Read(reader, binary.LittleEndian, uuid)
-	if err != nil {
-		return pkg, err
+	return packageBytes, nil
+}
+
+// parsePackage decodes the package bytes, the data is copied so that the package bytes can be reused. Package bytes that
+// are too short or whose length does not match fail to decode, whatever they hold.
+func parsePackage(packageBytes []byte) (TCPPackage, error) {
+	var pkg TCPPackage
+	if len(packageBytes) < 4+minimumTCPPackageSize {
+		return pkg, fmt.Errorf("package of %d bytes is less than the minimum package size of %d bytes", len(packageBytes), 4+minimumTCPPackageSize)
 	}
-	pkg.CorrelationID = DecodeNetUUID(uuid)
+	pkg.PackageLength = binary.LittleEndian.Uint32(packageBytes)
+	pkg.Command = Command(packageBytes[4])
+	pkg.Flags = packageBytes[5]
+	pkg.CorrelationID = DecodeNetUUID(packageBytes[6:22])
 
-	dataSize := pkg.PackageLength - minimumTCPPackageSize
-	data := make([]byte, dataSize)
-	err = binary.Read(reader, binary.LittleEndian, data)
-	if err != nil {
-		return pkg, err
+	if pkg.PackageLength < minimumTCPPackageSize {
+		return pkg, fmt.Errorf("package length %d is less than the minimum package size of %d bytes", pkg.PackageLength, minimumTCPPackageSize)
+	}
+	if uint64(pkg.PackageLength) > uint64(len(packageBytes)-4) {
+		return pkg, io.ErrUnexpectedEOF
 	}
-	pkg.Data = data
+	data := packageBytes[4+minimumTCPPackageSize : 4+pkg.PackageLength]
+	pkg.Data = make([]byte, len(data))
+	copy(pkg.Data, data)
 	return pkg, nil
 }
 
@@ -75,54 +123,41 @@ func (pkg *TCPPackage) write(connection *EventStoreConnection) error {
 		return fmt.Errorf("password is %d bytes, maximum length 255 bytes", len(passwordBytes))
 	}
 
-	totalMessageLength := minimumTCPPackageSize +
-		1 +
-		len(loginBytes) +
-		1 +
-		len(passwordBytes) +
-		len(pkg.Data)
-
-	//TODO handle error and written
-	_, err := connection.Socket.Write([]byte{
-		byte(totalMessageLength),
-		byte(totalMessageLength >> 8),
-		byte(totalMessageLength >> 16),
-		byte(totalMessageLength >> 24),
-	})
-	if err != nil {
-		return err
+	totalMessageLength := minimumTCPPackageSize + len(pkg.Data)
+	if pkg.Flags&0x01 == 0x01 {
+		totalMessageLength += 1 +
+			len(loginBytes) +
+			1 +
+			len(passwordBytes)
 	}
-	_, err = connection.Socket.Write([]byte{
-		byte(pkg.Command),
-		byte(pkg.Flags),
-	})
-	if err != nil {
-		return err
-	}
-	_, err = connection.Socket.Write(EncodeNetUUID(pkg.CorrelationID))
-	if err != nil {
-		return err
-	}
-	_, err = connection.Socket.Write([]byte{byte(len(loginBytes))})
-	if err != nil {
-		return err
-	}
-	_, err = connection.Socket.Write(loginBytes)
-	if err != nil {
-		return err
-	}
-	_, err = connection.Socket.Write([]byte{byte(len(passwordBytes))})
-	if err != nil {
-		return err
-	}
-	_, err = connection.Socket.Write(passwordBytes)
-	if err != nil {
-		return err
+
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	buffer.Grow(4 + totalMessageLength)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(totalMessageLength))
+	buffer.Write(length[:])
+	buffer.WriteByte(byte(pkg.Command))
+	buffer.WriteByte(pkg.Flags)
+	buffer.Write(EncodeNetUUID(pkg.CorrelationID))
+	if pkg.Flags&0x01 == 0x01 {
+		buffer.WriteByte(byte(len(loginBytes)))
+		buffer.Write(loginBytes)
+		buffer.WriteByte(byte(len(passwordBytes)))
+		buffer.Write(passwordBytes)
+	}
+	buffer.Write(pkg.Data)
+
+	writer := connection.socketWriter()
+	if writer == nil {
+		return ErrConnectionClosed
 	}
-	_, err = connection.Socket.Write(pkg.Data)
+	// the writer waits for the package to be written, so the buffer can be returned to the pool afterwards
+	err := writer.write(buffer.Bytes())
 	if err != nil {
 		return err
 	}
+	connection.stats.packageSent(pkg.Command, buffer.Len())
 	return nil
 }
 
@@ -0,0 +1,52 @@
+//go:build go1.18
+// +build go1.18
+
+package goes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// fuzzMaxPackageSize keeps the packages the fuzzer reads small, so that a length beyond it is found quickly
+const fuzzMaxPackageSize = 1024
+
+// FuzzParsePackage reads the bytes as they would arrive from the server, run it with
+// go test -fuzz=FuzzParsePackage ./eventstore
+func FuzzParsePackage(f *testing.F) {
+	valid := make([]byte, 4+minimumTCPPackageSize+2)
+	binary.LittleEndian.PutUint32(valid, minimumTCPPackageSize+2)
+	valid[4] = byte(pong)
+	f.Add(valid)
+	f.Add(valid[:10])
+	f.Add([]byte{0x05, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05})
+	f.Add([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x04})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		buffer := &bytes.Buffer{}
+		packageBytes, err := readPackage(bytes.NewReader(data), fuzzMaxPackageSize, buffer)
+		// bytes.Buffer at most doubles its capacity as it grows
+		if buffer.Cap() > 2*(4+fuzzMaxPackageSize) {
+			t.Fatalf("Expected a buffer of at most %v bytes got %v", 2*(4+fuzzMaxPackageSize), buffer.Cap())
+		}
+		if err != nil {
+			return
+		}
+		if len(packageBytes) > 4+fuzzMaxPackageSize {
+			t.Fatalf("Expected at most %v bytes got %v", 4+fuzzMaxPackageSize, len(packageBytes))
+		}
+		pkg, err := parsePackage(packageBytes)
+		if len(packageBytes) < 4+minimumTCPPackageSize {
+			if err == nil {
+				t.Fatalf("Expected a package of %v bytes to fail to decode", len(packageBytes))
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		if len(pkg.Data) != int(pkg.PackageLength)-minimumTCPPackageSize || len(pkg.CorrelationID) != 16 {
+			t.Fatalf("Expected %v bytes of data and a correlation id got %+v", int(pkg.PackageLength)-minimumTCPPackageSize, pkg)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package goes_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"net"
+	"testing"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+// newTestRandomPackage frames random bytes as a package, which is too short to hold a header at times and carries any
+// command but subscription dropped, as a random drop might read as the node shutting down
+func newTestRandomPackage(random *rand.Rand) []byte {
+	body := make([]byte, random.Intn(64))
+	random.Read(body)
+	if len(body) > 0 && body[0] == subscriptionDroppedCommand {
+		body[0] = 0xEE
+	}
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, uint32(len(body)))
+	buffer.Write(body)
+	return buffer.Bytes()
+}
+
+func TestReadFromSocket_WithRandomPackages(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	config := goes.NewConfiguration()
+	config.Logger = &testLogger{}
+	config.OnError = func(err error) {}
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		for i := 0; i < 1000; i++ {
+			socket.Write(newTestRandomPackage(random))
+		}
+		for {
+			pkg, err := readRawTestPackage(socket)
+			if err != nil {
+				return
+			}
+			if pkg.Command == pingCommand {
+				socket.Write(encodeTestPackage(testPackage{Command: pongCommand, CorrelationID: pkg.CorrelationID}))
+			}
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if conn.State() != goes.ConnectionStateConnected {
+		t.Fatalf("Expected %v got %v", goes.ConnectionStateConnected, conn.State())
+	}
+}
@@ -0,0 +1,155 @@
+package goes
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Tracer starts the spans that trace the operations of a connection, e.g. through an adapter for OpenTelemetry.
+// Set Configuration.Tracer to trace the operations, nothing is traced when it is nil.
+type Tracer interface {
+	// StartSpan starts a span as a child of the span in ctx, if any, and returns the context that carries the new span
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+	// Inject writes the trace context of ctx to the carrier
+	Inject(ctx context.Context, carrier map[string]string)
+	// Extract returns a context that continues the trace read from the carrier
+	Extract(ctx context.Context, carrier map[string]string) context.Context
+}
+
+// Span is a single traced operation
+type Span interface {
+	SetAttribute(key string, value string)
+	RecordError(err error)
+	End()
+}
+
+// The attributes set on the span of an operation. The stream is empty for the operations on all the events.
+const (
+	SpanAttributeStream        = "eventstore.stream"
+	SpanAttributeCorrelationID = "eventstore.correlation_id"
+	SpanAttributeResult        = "eventstore.result"
+)
+
+// traceContextMetadataKey is the key of the trace context in the metadata of the written events
+const traceContextMetadataKey = "$traceContext"
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value string) {}
+func (noopSpan) RecordError(err error)                 {}
+func (noopSpan) End()                                  {}
+
+type spanContextKey struct{}
+
+// startSpan starts the span of an operation on the stream. A span that does nothing is returned when no Tracer is configured.
+func (connection *EventStoreConnection) startSpan(ctx context.Context, command Command, stream string) (context.Context, Span) {
+	tracer := connection.Config.Tracer
+	if tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := tracer.StartSpan(ctx, operationName(command))
+	span.SetAttribute(SpanAttributeStream, stream)
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// spanFromContext returns the span of the operation that is performed with ctx
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanContextKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// endSpan records the outcome of the operation and ends its span
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttribute(SpanAttributeResult, err.Error())
+	} else {
+		span.SetAttribute(SpanAttributeResult, "Success")
+	}
+	span.End()
+}
+
+// operationName names the span of an operation after its command
+func operationName(command Command) string {
+	switch command {
+	case writeEvents:
+		return "WriteEvents"
+	case deleteStream:
+		return "DeleteStream"
+	case readEvent:
+		return "ReadEvent"
+	case readStreamEventsForward:
+		return "ReadStreamEventsForward"
+	case readStreamEventsBackward:
+		return "ReadStreamEventsBackward"
+	case readAllEventsForward:
+		return "ReadAllEventsForward"
+	case readAllEventsBackward:
+		return "ReadAllEventsBackward"
+	case transactionStart:
+		return "TransactionStart"
+	case transactionWrite:
+		return "TransactionWrite"
+	case transactionCommit:
+		return "TransactionCommit"
+	case subscribeToStream:
+		return "SubscribeToStream"
+	case filteredSubscribeToStream:
+		return "FilteredSubscribeToStream"
+	}
+	return "Operation"
+}
+
+// injectTraceContext adds the trace context of ctx to the metadata of the events so that the consumers of the events can
+// continue the trace. The metadata of an event is left as is when it is not a JSON object, otherwise it is marked as JSON.
+// The events are not modified, the events with the trace context are returned instead.
+func (connection *EventStoreConnection) injectTraceContext(ctx context.Context, events []EventData) []EventData {
+	tracer := connection.Config.Tracer
+	if tracer == nil {
+		return events
+	}
+	carrier := map[string]string{}
+	tracer.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return events
+	}
+	traceContext, err := json.Marshal(carrier)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return events
+	}
+	traced := make([]EventData, len(events))
+	for i, evnt := range events {
+		traced[i] = evnt
+		metadata := map[string]json.RawMessage{}
+		if len(evnt.Metadata) > 0 && json.Unmarshal(evnt.Metadata, &metadata) != nil {
+			continue
+		}
+		metadata[traceContextMetadataKey] = traceContext
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			continue
+		}
+		traced[i].Metadata = data
+		traced[i].IsJSONMetadata = true
+	}
+	return traced
+}
+
+// ExtractTraceContext returns a context that continues the trace of the write of the event, so that processing the event
+// can be traced as part of it. ctx is returned as is when no Tracer is configured or the event carries no trace context.
+func (connection *EventStoreConnection) ExtractTraceContext(ctx context.Context, evnt RecordedEvent) context.Context {
+	tracer := connection.Config.Tracer
+	if tracer == nil || len(evnt.Metadata) == 0 {
+		return ctx
+	}
+	metadata := struct {
+		TraceContext map[string]string `json:"$traceContext"`
+	}{}
+	if err := json.Unmarshal(evnt.Metadata, &metadata); err != nil || len(metadata.TraceContext) == 0 {
+		return ctx
+	}
+	return tracer.Extract(ctx, metadata.TraceContext)
+}
@@ -0,0 +1,163 @@
+package goes_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/eventstore/fakeserver"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+type testTraceKey struct{}
+
+// testTracer identifies a span by its name and carries the name of the current span as the trace context
+type testTracer struct {
+	mutex sync.Mutex
+	spans []*testSpan
+}
+
+type testSpan struct {
+	name       string
+	attributes map[string]string
+	err        error
+	ended      bool
+}
+
+func (tracer *testTracer) StartSpan(ctx context.Context, name string) (context.Context, goes.Span) {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	span := &testSpan{name: name, attributes: map[string]string{}}
+	tracer.spans = append(tracer.spans, span)
+	return context.WithValue(ctx, testTraceKey{}, name), span
+}
+
+func (tracer *testTracer) Inject(ctx context.Context, carrier map[string]string) {
+	if name, ok := ctx.Value(testTraceKey{}).(string); ok {
+		carrier["span"] = name
+	}
+}
+
+func (tracer *testTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return context.WithValue(ctx, testTraceKey{}, carrier["span"])
+}
+
+func (span *testSpan) SetAttribute(key string, value string) {
+	span.attributes[key] = value
+}
+
+func (span *testSpan) RecordError(err error) {
+	span.err = err
+}
+
+func (span *testSpan) End() {
+	span.ended = true
+}
+
+func TestWriteEvents_WithTracer(t *testing.T) {
+	tracer := &testTracer{}
+	config := goes.NewConfiguration()
+	config.Tracer = tracer
+	written := make(chan *protobuf.WriteEvents, 1)
+	correlationIDs := make(chan uuid.UUID, 1)
+	conn, listener := startTestServerWithConfiguration(t, config, func(socket net.Conn) {
+		write, err := readTestPackage(socket)
+		if err != nil {
+			return
+		}
+		message := &protobuf.WriteEvents{}
+		if err := proto.Unmarshal(write.Data, message); err != nil {
+			t.Errorf("Unexpected failure unmarshalling write events: %s", err.Error())
+			return
+		}
+		correlationID, _ := uuid.FromBytes(goes.DecodeNetUUID(write.CorrelationID))
+		correlationIDs <- correlationID
+		written <- message
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       writeEventsCompletedCommand,
+			CorrelationID: write.CorrelationID,
+			Data:          newTestWriteEventsCompleted(t),
+		}))
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{{
+		EventID:   uuid.NewV4(),
+		EventType: "TestEvent",
+		Data:      []byte("{}"),
+		Metadata:  []byte(`{"tenant":"test"}`),
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Expected %v got %v", 1, len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "WriteEvents" || !span.ended || span.err != nil {
+		t.Fatalf("Expected an ended WriteEvents span got %+v", span)
+	}
+	if span.attributes[goes.SpanAttributeStream] != "testStream" {
+		t.Fatalf("Expected %v got %v", "testStream", span.attributes[goes.SpanAttributeStream])
+	}
+	if correlationID := <-correlationIDs; span.attributes[goes.SpanAttributeCorrelationID] != correlationID.String() {
+		t.Fatalf("Expected %v got %v", correlationID, span.attributes[goes.SpanAttributeCorrelationID])
+	}
+	if span.attributes[goes.SpanAttributeResult] != "Success" {
+		t.Fatalf("Expected %v got %v", "Success", span.attributes[goes.SpanAttributeResult])
+	}
+
+	data := (<-written).GetEvents()[0].GetMetadata()
+	metadata := map[string]interface{}{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatalf("Unexpected failure unmarshalling the metadata: %s", err.Error())
+	}
+	if metadata["tenant"] != "test" {
+		t.Fatalf("Expected %v got %v", "test", metadata["tenant"])
+	}
+	evnt := goes.RecordedEvent{Metadata: data}
+	ctx := conn.ExtractTraceContext(context.Background(), evnt)
+	if name := ctx.Value(testTraceKey{}); name != "WriteEvents" {
+		t.Fatalf("Expected %v got %v", "WriteEvents", name)
+	}
+}
+
+func TestWriteEvents_WithTracerMarksTheMetadataAsJSON(t *testing.T) {
+	config := goes.NewConfiguration()
+	config.Tracer = &testTracer{}
+	conn, server := startTestFakeServer(t, config)
+	defer server.Close()
+	defer conn.Close()
+	written := make(chan *protobuf.WriteEvents, 1)
+	server.Handle(fakeserver.WriteEvents, recordTestWrites(written))
+
+	_, err := conn.WriteEvents("testStream", goes.ExpectedVersionAny, []goes.EventData{{
+		EventID:   uuid.NewV4(),
+		EventType: "TestEvent",
+		Data:      []byte("data"),
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+
+	evnt := (<-written).GetEvents()[0]
+	if evnt.GetMetadataContentType() != 1 {
+		t.Fatalf("Expected %v got %v", 1, evnt.GetMetadataContentType())
+	}
+	if evnt.GetDataContentType() != 0 {
+		t.Fatalf("Expected %v got %v", 0, evnt.GetDataContentType())
+	}
+	metadata := map[string]interface{}{}
+	if err := json.Unmarshal(evnt.GetMetadata(), &metadata); err != nil {
+		t.Fatalf("Unexpected failure unmarshalling the metadata: %s", err.Error())
+	}
+}
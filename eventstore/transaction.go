@@ -0,0 +1,132 @@
+package goes
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// Transaction appends events to a stream in several batches. The events only become visible, all at once, when the transaction is committed.
+type Transaction struct {
+	// TransactionID is the id the server assigned to the transaction
+	TransactionID   int64
+	connection      *EventStoreConnection
+	stream          string
+	expectedVersion int64
+	requireMaster   bool
+	credentials     UserCredentials
+}
+
+// transactionResult is implemented by the responses to the transaction commands
+type transactionResult interface {
+	proto.Message
+	GetResult() protobuf.OperationResult
+}
+
+// StartTransaction starts a transaction on the stream. The transaction is committed provided that the stream is at the expected version.
+func (connection *EventStoreConnection) StartTransaction(stream string, expectedVersion int64, options ...OperationOption) (*Transaction, error) {
+	return connection.StartTransactionWithContext(context.Background(), stream, expectedVersion, options...)
+}
+
+// StartTransactionWithContext is like StartTransaction but gives up when ctx is cancelled
+func (connection *EventStoreConnection) StartTransactionWithContext(ctx context.Context, stream string, expectedVersion int64, options ...OperationOption) (*Transaction, error) {
+	expectedVersion = connection.expectedVersion(expectedVersion)
+	version, err := int32Argument("expectedVersion", expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	transaction := &Transaction{
+		connection:      connection,
+		stream:          stream,
+		expectedVersion: expectedVersion,
+		requireMaster:   connection.requireMaster(options),
+		credentials:     connection.credentials(options),
+	}
+	request := &protobuf.TransactionStart{
+		EventStreamId:   proto.String(stream),
+		ExpectedVersion: proto.Int32(version),
+		RequireMaster:   proto.Bool(transaction.requireMaster),
+	}
+	message := &protobuf.TransactionStartCompleted{}
+	err = transaction.perform(ctx, transactionStart, transactionStartCompleted, request, message)
+	if err != nil {
+		return nil, err
+	}
+	transaction.TransactionID = message.GetTransactionId()
+	return transaction, nil
+}
+
+// Write adds the events to the transaction
+func (transaction *Transaction) Write(events []EventData) error {
+	return transaction.WriteWithContext(context.Background(), events)
+}
+
+// WriteWithContext is like Write but gives up when ctx is cancelled
+func (transaction *Transaction) WriteWithContext(ctx context.Context, events []EventData) error {
+	request := &protobuf.TransactionWrite{
+		TransactionId: proto.Int64(transaction.TransactionID),
+		Events:        marshalEventData(transaction.connection.prepareEvents(ctx, events)),
+		RequireMaster: proto.Bool(transaction.requireMaster),
+	}
+	return transaction.perform(ctx, transactionWrite, transactionWriteCompleted, request, &protobuf.TransactionWriteCompleted{})
+}
+
+// Commit appends the events written to the transaction to the stream
+func (transaction *Transaction) Commit() (*WriteResult, error) {
+	return transaction.CommitWithContext(context.Background())
+}
+
+// CommitWithContext is like Commit but gives up when ctx is cancelled
+func (transaction *Transaction) CommitWithContext(ctx context.Context) (*WriteResult, error) {
+	request := &protobuf.TransactionCommit{
+		TransactionId: proto.Int64(transaction.TransactionID),
+		RequireMaster: proto.Bool(transaction.requireMaster),
+	}
+	message := &protobuf.TransactionCommitCompleted{}
+	err := transaction.perform(ctx, transactionCommit, transactionCommitCompleted, request, message)
+	if err != nil {
+		return nil, err
+	}
+	return &WriteResult{
+		FirstEventNumber: int64(message.GetFirstEventNumber()),
+		LastEventNumber:  int64(message.GetLastEventNumber()),
+		Position: Position{
+			CommitPosition:  message.GetCommitPosition(),
+			PreparePosition: message.GetPreparePosition(),
+		},
+	}, nil
+}
+
+// perform sends the request and sends it again while the server times out, up to MaxOperationRetries times
+func (transaction *Transaction) perform(ctx context.Context, command Command, completedCommand Command, request proto.Message, response transactionResult) (err error) {
+	connection := transaction.connection
+	ctx, span := connection.startSpan(ctx, command, transaction.stream)
+	defer func() {
+		endSpan(span, err)
+	}()
+	data, err := proto.Marshal(request)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return err
+	}
+
+	pkg, err := newPackage(command, data, uuid.NewV4().Bytes(), transaction.credentials.Login, transaction.credentials.Password)
+	if err != nil {
+		connection.logger().Errorf("failed to create new transaction package")
+		return err
+	}
+
+	_, err = performRetriedOperation(ctx, connection, pkg, completedCommand, func(result TCPPackage) error {
+		if err := proto.Unmarshal(result.Data, response); err != nil {
+			connection.logger().Errorf("unmarshaling error: %s", err)
+			return err
+		}
+		return operationResultError(response.GetResult())
+	})
+	if response.GetResult() == protobuf.OperationResult_WrongExpectedVersion {
+		return newWrongExpectedVersionError(ctx, connection, transaction.stream, transaction.expectedVersion, transaction.credentials)
+	}
+	return err
+}
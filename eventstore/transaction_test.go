@@ -0,0 +1,102 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	goes "github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	transactionStartCommand           byte = 0x84
+	transactionStartCompletedCommand  byte = 0x85
+	transactionWriteCommand           byte = 0x86
+	transactionWriteCompletedCommand  byte = 0x87
+	transactionCommitCommand          byte = 0x88
+	transactionCommitCompletedCommand byte = 0x89
+)
+
+func TestTransaction(t *testing.T) {
+	transactionID := int64(42)
+	written := make(chan int, 2)
+	conn, listener := startTestServer(t, func(socket net.Conn) {
+		start, err := readTestPackage(socket)
+		if err != nil || start.Command != transactionStartCommand {
+			return
+		}
+		socket.Write(encodeTestPackage(testPackage{
+			Command:       transactionStartCompletedCommand,
+			CorrelationID: start.CorrelationID,
+			Data: marshalTestMessage(t, &protobuf.TransactionStartCompleted{
+				TransactionId: proto.Int64(transactionID),
+				Result:        protobuf.OperationResult_Success.Enum(),
+			}),
+		}))
+		for {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			switch pkg.Command {
+			case transactionWriteCommand:
+				write := &protobuf.TransactionWrite{}
+				proto.Unmarshal(pkg.Data, write)
+				if write.GetTransactionId() != transactionID {
+					return
+				}
+				written <- len(write.GetEvents())
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       transactionWriteCompletedCommand,
+					CorrelationID: pkg.CorrelationID,
+					Data: marshalTestMessage(t, &protobuf.TransactionWriteCompleted{
+						TransactionId: proto.Int64(transactionID),
+						Result:        protobuf.OperationResult_Success.Enum(),
+					}),
+				}))
+			case transactionCommitCommand:
+				socket.Write(encodeTestPackage(testPackage{
+					Command:       transactionCommitCompletedCommand,
+					CorrelationID: pkg.CorrelationID,
+					Data: marshalTestMessage(t, &protobuf.TransactionCommitCompleted{
+						TransactionId:    proto.Int64(transactionID),
+						Result:           protobuf.OperationResult_Success.Enum(),
+						FirstEventNumber: proto.Int32(0),
+						LastEventNumber:  proto.Int32(2),
+					}),
+				}))
+				return
+			}
+		}
+	})
+	defer listener.Close()
+	defer conn.Close()
+
+	transaction, err := conn.StartTransaction("testStream", goes.ExpectedVersionAny)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if transaction.TransactionID != transactionID {
+		t.Fatalf("Expected %v got %v", transactionID, transaction.TransactionID)
+	}
+	newEvent := func() goes.EventData {
+		return goes.EventData{EventID: uuid.NewV4(), EventType: "TestEvent", Data: []byte("{}")}
+	}
+	for _, events := range [][]goes.EventData{{newEvent(), newEvent()}, {newEvent()}} {
+		if err := transaction.Write(events); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		if actual := <-written; actual != len(events) {
+			t.Fatalf("Expected %v got %v", len(events), actual)
+		}
+	}
+	result, err := transaction.Commit()
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if result.FirstEventNumber != 0 || result.LastEventNumber != 2 {
+		t.Fatalf("Expected %v got %+v", "events 0 to 2", result)
+	}
+}
@@ -0,0 +1,126 @@
+package goes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// UsersManager manages the users of a node through its HTTP API. The requests are authenticated with the Login and Password
+// of the configuration, which must be those of an admin, unless an operation is given other credentials with WithCredentials.
+type UsersManager struct {
+	api *httpAPI
+}
+
+// userStatusErrors are the errors returned for the statuses that are specific to users
+var userStatusErrors = map[int]error{
+	http.StatusNotFound: ErrUserNotFound,
+	http.StatusConflict: ErrUserAlreadyExists,
+}
+
+// NewUsersManager creates a manager for the users of the node at the Address of the configuration, which serves its HTTP
+// API on httpPort. The requests are sent over https with the TLSConfig of the configuration when UseTLS is set.
+func NewUsersManager(config *Configuration, httpPort int) *UsersManager {
+	return &UsersManager{api: newHTTPAPI(config, httpPort)}
+}
+
+// Create creates a user that is a member of the groups, e.g. $admins
+func (manager *UsersManager) Create(loginName string, fullName string, password string, groups []string, options ...OperationOption) error {
+	return manager.CreateWithContext(context.Background(), loginName, fullName, password, groups, options...)
+}
+
+// CreateWithContext is like Create but gives up when ctx is cancelled
+func (manager *UsersManager) CreateWithContext(ctx context.Context, loginName string, fullName string, password string, groups []string, options ...OperationOption) error {
+	request := struct {
+		LoginName string   `json:"loginName"`
+		FullName  string   `json:"fullName"`
+		Password  string   `json:"password"`
+		Groups    []string `json:"groups"`
+	}{loginName, fullName, password, groups}
+	return manager.send(ctx, http.MethodPost, "/users/", request, options)
+}
+
+// Update replaces the full name and the groups of the user
+func (manager *UsersManager) Update(loginName string, fullName string, groups []string, options ...OperationOption) error {
+	return manager.UpdateWithContext(context.Background(), loginName, fullName, groups, options...)
+}
+
+// UpdateWithContext is like Update but gives up when ctx is cancelled
+func (manager *UsersManager) UpdateWithContext(ctx context.Context, loginName string, fullName string, groups []string, options ...OperationOption) error {
+	request := struct {
+		FullName string   `json:"fullName"`
+		Groups   []string `json:"groups"`
+	}{fullName, groups}
+	return manager.send(ctx, http.MethodPut, "/users/"+url.PathEscape(loginName), request, options)
+}
+
+// Disable prevents the user from authenticating until the user is enabled again
+func (manager *UsersManager) Disable(loginName string, options ...OperationOption) error {
+	return manager.DisableWithContext(context.Background(), loginName, options...)
+}
+
+// DisableWithContext is like Disable but gives up when ctx is cancelled
+func (manager *UsersManager) DisableWithContext(ctx context.Context, loginName string, options ...OperationOption) error {
+	return manager.send(ctx, http.MethodPost, "/users/"+url.PathEscape(loginName)+"/command/disable", nil, options)
+}
+
+// Enable allows a disabled user to authenticate again
+func (manager *UsersManager) Enable(loginName string, options ...OperationOption) error {
+	return manager.EnableWithContext(context.Background(), loginName, options...)
+}
+
+// EnableWithContext is like Enable but gives up when ctx is cancelled
+func (manager *UsersManager) EnableWithContext(ctx context.Context, loginName string, options ...OperationOption) error {
+	return manager.send(ctx, http.MethodPost, "/users/"+url.PathEscape(loginName)+"/command/enable", nil, options)
+}
+
+// ChangePassword changes the password of the user provided that currentPassword is the user's password
+func (manager *UsersManager) ChangePassword(loginName string, currentPassword string, newPassword string, options ...OperationOption) error {
+	return manager.ChangePasswordWithContext(context.Background(), loginName, currentPassword, newPassword, options...)
+}
+
+// ChangePasswordWithContext is like ChangePassword but gives up when ctx is cancelled
+func (manager *UsersManager) ChangePasswordWithContext(ctx context.Context, loginName string, currentPassword string, newPassword string, options ...OperationOption) error {
+	request := struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}{currentPassword, newPassword}
+	return manager.send(ctx, http.MethodPost, "/users/"+url.PathEscape(loginName)+"/command/change-password", request, options)
+}
+
+// ResetPassword sets the password of the user without knowing the current one
+func (manager *UsersManager) ResetPassword(loginName string, newPassword string, options ...OperationOption) error {
+	return manager.ResetPasswordWithContext(context.Background(), loginName, newPassword, options...)
+}
+
+// ResetPasswordWithContext is like ResetPassword but gives up when ctx is cancelled
+func (manager *UsersManager) ResetPasswordWithContext(ctx context.Context, loginName string, newPassword string, options ...OperationOption) error {
+	request := struct {
+		NewPassword string `json:"newPassword"`
+	}{newPassword}
+	return manager.send(ctx, http.MethodPost, "/users/"+url.PathEscape(loginName)+"/command/reset-password", request, options)
+}
+
+// Delete deletes the user
+func (manager *UsersManager) Delete(loginName string, options ...OperationOption) error {
+	return manager.DeleteWithContext(context.Background(), loginName, options...)
+}
+
+// DeleteWithContext is like Delete but gives up when ctx is cancelled
+func (manager *UsersManager) DeleteWithContext(ctx context.Context, loginName string, options ...OperationOption) error {
+	return manager.send(ctx, http.MethodDelete, "/users/"+url.PathEscape(loginName), nil, options)
+}
+
+// send marshals the request, unless it is nil, and sends it
+func (manager *UsersManager) send(ctx context.Context, method string, path string, request interface{}, options []OperationOption) error {
+	var body []byte
+	if request != nil {
+		var err error
+		body, err = json.Marshal(request)
+		if err != nil {
+			return err
+		}
+	}
+	return manager.api.send(ctx, method, path, body, nil, options, userStatusErrors)
+}
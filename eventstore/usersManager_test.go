@@ -0,0 +1,81 @@
+package goes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	goes "github.com/pgermishuys/goes/eventstore"
+)
+
+func startTestUsersServer(t *testing.T, handler http.HandlerFunc) (*goes.UsersManager, chan testHTTPRequest, *httptest.Server) {
+	config, port, requests, server := startTestHTTPServer(t, handler)
+	return goes.NewUsersManager(config, port), requests, server
+}
+
+func TestUsersManager_Create(t *testing.T) {
+	manager, requests, server := startTestUsersServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+
+	if err := manager.Create("ouro", "Ouro Boros", "secret", []string{"$admins"}); err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	request := <-requests
+	if request.method != http.MethodPost || request.uri != "/users/" || request.login != "admin" {
+		t.Fatalf("Expected the user to be created by admin got %+v", request)
+	}
+	expected := `{"loginName":"ouro","fullName":"Ouro Boros","password":"secret","groups":["$admins"]}`
+	if request.body != expected {
+		t.Fatalf("Expected %v got %v", expected, request.body)
+	}
+}
+
+func TestUsersManager_Commands(t *testing.T) {
+	manager, requests, server := startTestUsersServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+
+	for _, test := range []struct {
+		command func() error
+		method  string
+		uri     string
+		body    string
+	}{
+		{func() error { return manager.Update("ouro", "Ouro", []string{"ops"}) }, http.MethodPut, "/users/ouro", `{"fullName":"Ouro","groups":["ops"]}`},
+		{func() error { return manager.Disable("ouro") }, http.MethodPost, "/users/ouro/command/disable", ""},
+		{func() error { return manager.Enable("ouro") }, http.MethodPost, "/users/ouro/command/enable", ""},
+		{func() error { return manager.ChangePassword("ouro", "secret", "changed") }, http.MethodPost, "/users/ouro/command/change-password", `{"currentPassword":"secret","newPassword":"changed"}`},
+		{func() error { return manager.ResetPassword("ouro", "reset") }, http.MethodPost, "/users/ouro/command/reset-password", `{"newPassword":"reset"}`},
+		{func() error { return manager.Delete("ouro") }, http.MethodDelete, "/users/ouro", ""},
+	} {
+		if err := test.command(); err != nil {
+			t.Fatalf("Unexpected failure %+v", err)
+		}
+		request := <-requests
+		if request.method != test.method || request.uri != test.uri || request.body != test.body {
+			t.Fatalf("Expected %v %v %v got %v %v %v", test.method, test.uri, test.body, request.method, request.uri, request.body)
+		}
+	}
+}
+
+func TestUsersManager_Errors(t *testing.T) {
+	for _, test := range []struct {
+		status   int
+		expected error
+	}{
+		{http.StatusConflict, goes.ErrUserAlreadyExists},
+		{http.StatusNotFound, goes.ErrUserNotFound},
+		{http.StatusUnauthorized, goes.ErrNotAuthenticated},
+	} {
+		manager, requests, server := startTestUsersServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+		})
+		err := manager.Create("ouro", "Ouro Boros", "secret", nil)
+		<-requests
+		server.Close()
+		if err != test.expected {
+			t.Fatalf("Expected %v got %v", test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package goes
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// The expected versions that can be used when writing to a stream instead of a specific event number
+const (
+	// ExpectedVersionAny disables the optimistic concurrency check
+	ExpectedVersionAny = -2
+	// ExpectedVersionNoStream expects the stream to not exist yet
+	ExpectedVersionNoStream = -1
+	// ExpectedVersionEmptyStream expects the stream to exist but to contain no events
+	ExpectedVersionEmptyStream = -1
+	// ExpectedVersionStreamExists expects the stream to exist, regardless of its version
+	ExpectedVersionStreamExists = -4
+	// ExpectedVersionUnset writes at the DefaultExpectedVersion of the configuration
+	ExpectedVersionUnset = math.MinInt64
+)
+
+// WriteResult describes the outcome of a successful write to a stream
+type WriteResult struct {
+	FirstEventNumber int64
+	LastEventNumber  int64
+	// Position is the position of the write in the transaction log
+	Position Position
+}
+
+// WriteEvents appends the events to the stream, provided that the stream is at the expected version.
+// Writing the same events, identified by their EventID, with the same expected version again does not append them a second
+// time and returns the result of the original write, so a write that failed with a timeout can safely be retried.
+func (connection *EventStoreConnection) WriteEvents(stream string, expectedVersion int64, events []EventData, options ...OperationOption) (*WriteResult, error) {
+	return connection.WriteEventsWithContext(context.Background(), stream, expectedVersion, events, options...)
+}
+
+// WriteEventsWithContext is like WriteEvents but gives up when ctx is cancelled
+func (connection *EventStoreConnection) WriteEventsWithContext(ctx context.Context, stream string, expectedVersion int64, events []EventData, options ...OperationOption) (result *WriteResult, err error) {
+	ctx, span := connection.startSpan(ctx, writeEvents, stream)
+	defer func() {
+		endSpan(span, err)
+	}()
+	expectedVersion = connection.expectedVersion(expectedVersion)
+	credentials := connection.credentials(options)
+	version, err := int32Argument("expectedVersion", expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	writeEventsData := &protobuf.WriteEvents{
+		EventStreamId:   proto.String(stream),
+		ExpectedVersion: proto.Int32(version),
+		Events:          marshalEventData(connection.prepareEvents(ctx, events)),
+		RequireMaster:   proto.Bool(connection.requireMaster(options)),
+	}
+	data, err := proto.Marshal(writeEventsData)
+	if err != nil {
+		connection.logger().Errorf("marshaling error: %s", err)
+		return nil, err
+	}
+
+	pkg, err := newPackage(writeEvents, data, uuid.NewV4().Bytes(), credentials.Login, credentials.Password)
+	if err != nil {
+		connection.logger().Errorf("failed to create new write events package")
+		return nil, err
+	}
+
+	message := &protobuf.WriteEventsCompleted{}
+	_, err = performRetriedOperation(ctx, connection, pkg, writeEventsCompleted, func(result TCPPackage) error {
+		if err := proto.Unmarshal(result.Data, message); err != nil {
+			connection.logger().Errorf("unmarshaling error: %s", err)
+			return err
+		}
+		return operationResultError(message.GetResult())
+	})
+	if message.GetResult() == protobuf.OperationResult_WrongExpectedVersion {
+		return nil, newWrongExpectedVersionError(ctx, connection, stream, expectedVersion, credentials)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &WriteResult{
+		FirstEventNumber: int64(message.GetFirstEventNumber()),
+		LastEventNumber:  int64(message.GetLastEventNumber()),
+		Position: Position{
+			CommitPosition:  message.GetCommitPosition(),
+			PreparePosition: message.GetPreparePosition(),
+		},
+	}, nil
+}
+
+// expectedVersion returns the DefaultExpectedVersion in place of ExpectedVersionUnset
+func (connection *EventStoreConnection) expectedVersion(expectedVersion int64) int64 {
+	if expectedVersion != ExpectedVersionUnset {
+		return expectedVersion
+	}
+	if connection.Config.DefaultExpectedVersion == 0 {
+		return ExpectedVersionAny
+	}
+	return connection.Config.DefaultExpectedVersion
+}
+
+// prepareEvents adds the trace context to the events and passes them to the BeforeWrite hook. The events are not
+// modified, the prepared copies are returned instead.
+func (connection *EventStoreConnection) prepareEvents(ctx context.Context, events []EventData) []EventData {
+	events = connection.injectTraceContext(ctx, events)
+	beforeWrite := connection.Config.BeforeWrite
+	if beforeWrite == nil {
+		return events
+	}
+	prepared := make([]EventData, len(events))
+	for i := range events {
+		prepared[i] = events[i]
+		beforeWrite(&prepared[i])
+	}
+	return prepared
+}
+
+// AppendToStream appends a single event to the stream, provided that the stream is at the expected version
+func (connection *EventStoreConnection) AppendToStream(stream string, expectedVersion int64, evnt EventData, options ...OperationOption) (*WriteResult, error) {
+	return connection.AppendToStreamWithContext(context.Background(), stream, expectedVersion, evnt, options...)
+}
+
+// AppendToStreamWithContext is like AppendToStream but gives up when ctx is cancelled
+func (connection *EventStoreConnection) AppendToStreamWithContext(ctx context.Context, stream string, expectedVersion int64, evnt EventData, options ...OperationOption) (*WriteResult, error) {
+	return connection.WriteEventsWithContext(ctx, stream, expectedVersion, []EventData{evnt}, options...)
+}
+
+// AppendJSON appends an event with a new id to the stream, whose data and metadata are marshalled as json. The event
+// has no metadata when metadata is nil.
+func (connection *EventStoreConnection) AppendJSON(stream string, expectedVersion int64, eventType string, data interface{}, metadata interface{}, options ...OperationOption) (*WriteResult, error) {
+	return connection.AppendJSONWithContext(context.Background(), stream, expectedVersion, eventType, data, metadata, options...)
+}
+
+// AppendJSONWithContext is like AppendJSON but gives up when ctx is cancelled
+func (connection *EventStoreConnection) AppendJSONWithContext(ctx context.Context, stream string, expectedVersion int64, eventType string, data interface{}, metadata interface{}, options ...OperationOption) (*WriteResult, error) {
+	evnt, err := NewJSONEventData(eventType, data)
+	if err != nil {
+		return nil, err
+	}
+	if metadata != nil {
+		evnt.Metadata, err = json.Marshal(metadata)
+		if err != nil {
+			return nil, err
+		}
+		evnt.IsJSONMetadata = true
+	}
+	return connection.AppendToStreamWithContext(ctx, stream, expectedVersion, evnt, options...)
+}
@@ -0,0 +1,51 @@
+package goes
+
+import (
+	"context"
+)
+
+// WriteEventsBatch appends the events to the stream in chunks of at most batchSize events, so that a large append does not
+// exceed the maximum size of a single write on the server. The first chunk is written at the expected version and every
+// following chunk is written at the last event number of the chunk before it, so the chunks form a contiguous sequence that
+// still fails when the stream is written to concurrently. A batchSize of zero or less writes all events in a single chunk.
+//
+// Unlike a transaction the batch is not atomic: when a chunk fails the chunks before it remain written and an
+// *ErrBatchWriteFailed is returned describing how far the batch got.
+func (connection *EventStoreConnection) WriteEventsBatch(stream string, expectedVersion int64, events []EventData, batchSize int, options ...OperationOption) (*WriteResult, error) {
+	return connection.WriteEventsBatchWithContext(context.Background(), stream, expectedVersion, events, batchSize, options...)
+}
+
+// WriteEventsBatchWithContext is like WriteEventsBatch but gives up when ctx is cancelled
+func (connection *EventStoreConnection) WriteEventsBatchWithContext(ctx context.Context, stream string, expectedVersion int64, events []EventData, batchSize int, options ...OperationOption) (*WriteResult, error) {
+	if batchSize <= 0 || batchSize > len(events) {
+		batchSize = len(events)
+	}
+	var result *WriteResult
+	written := 0
+	for {
+		end := written + batchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		chunk, err := connection.WriteEventsWithContext(ctx, stream, expectedVersion, events[written:end], options...)
+		if err != nil {
+			return nil, &ErrBatchWriteFailed{
+				Stream:  stream,
+				Written: written,
+				Result:  result,
+				Err:     err,
+			}
+		}
+		if result == nil {
+			result = chunk
+		} else {
+			result.LastEventNumber = chunk.LastEventNumber
+			result.Position = chunk.Position
+		}
+		expectedVersion = chunk.LastEventNumber
+		written = end
+		if written >= len(events) {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package goes_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pgermishuys/goes/eventstore"
+	"github.com/pgermishuys/goes/protobuf"
+	"github.com/satori/go.uuid"
+)
+
+// serveTestBatchWrites appends every write to an in-memory stream and rejects writes once failAt writes have been accepted
+func serveTestBatchWrites(t *testing.T, failAt int, writes chan<- *protobuf.WriteEvents) func(net.Conn) {
+	return func(socket net.Conn) {
+		version := int32(goes.ExpectedVersionNoStream)
+		for accepted := 0; ; accepted++ {
+			pkg, err := readTestPackage(socket)
+			if err != nil {
+				return
+			}
+			message := &protobuf.WriteEvents{}
+			if err := proto.Unmarshal(pkg.Data, message); err != nil {
+				t.Errorf("Unexpected failure unmarshalling write events: %s", err.Error())
+				return
+			}
+			writes <- message
+			completed := &protobuf.WriteEventsCompleted{
+				Result:           protobuf.OperationResult_Success.Enum(),
+				FirstEventNumber: proto.Int32(version + 1),
+				LastEventNumber:  proto.Int32(version + int32(len(message.Events))),
+				CommitPosition:   proto.Int64(int64(accepted)),
+				PreparePosition:  proto.Int64(int64(accepted)),
+			}
+			if accepted == failAt {
+				completed.Result = protobuf.OperationResult_AccessDenied.Enum()
+			} else {
+				version += int32(len(message.Events))
+			}
+			data, err := proto.Marshal(completed)
+			if err != nil {
+				t.Errorf("Unexpected failure marshalling write events completed: %s", err.Error())
+				return
+			}
+			socket.Write(encodeTestPackage(testPackage{
+				Command:       writeEventsCompletedCommand,
+				CorrelationID: pkg.CorrelationID,
+				Data:          data,
+			}))
+		}
+	}
+}
+
+func createTestBatch(count int) []goes.EventData {
+	events := make([]goes.EventData, count)
+	for i := range events {
+		events[i] = goes.EventData{EventID: uuid.NewV4(), EventType: "TestEvent", IsJSON: true, Data: []byte("{}")}
+	}
+	return events
+}
+
+func TestWriteEventsBatch_SplitsTheEventsIntoChunks(t *testing.T) {
+	writes := make(chan *protobuf.WriteEvents, 10)
+	conn, listener := startTestServer(t, serveTestBatchWrites(t, -1, writes))
+	defer listener.Close()
+	defer conn.Close()
+
+	result, err := conn.WriteEventsBatch("testStream", goes.ExpectedVersionNoStream, createTestBatch(5), 2)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if result.FirstEventNumber != 0 || result.LastEventNumber != 4 {
+		t.Fatalf("Expected events %d to %d got %d to %d", 0, 4, result.FirstEventNumber, result.LastEventNumber)
+	}
+	if result.Position.CommitPosition != 2 {
+		t.Fatalf("Expected %v got %v", 2, result.Position.CommitPosition)
+	}
+
+	expected := []struct {
+		expectedVersion int32
+		events          int
+	}{{-1, 2}, {1, 2}, {3, 1}}
+	for _, chunk := range expected {
+		write := <-writes
+		if write.GetExpectedVersion() != chunk.expectedVersion {
+			t.Fatalf("Expected %v got %v", chunk.expectedVersion, write.GetExpectedVersion())
+		}
+		if len(write.Events) != chunk.events {
+			t.Fatalf("Expected %v got %v", chunk.events, len(write.Events))
+		}
+	}
+}
+
+func TestWriteEventsBatch_WithoutBatchSize(t *testing.T) {
+	writes := make(chan *protobuf.WriteEvents, 10)
+	conn, listener := startTestServer(t, serveTestBatchWrites(t, -1, writes))
+	defer listener.Close()
+	defer conn.Close()
+
+	result, err := conn.WriteEventsBatch("testStream", goes.ExpectedVersionAny, createTestBatch(3), 0)
+	if err != nil {
+		t.Fatalf("Unexpected failure %+v", err)
+	}
+	if result.LastEventNumber != 2 {
+		t.Fatalf("Expected %v got %v", 2, result.LastEventNumber)
+	}
+	if write := <-writes; len(write.Events) != 3 {
+		t.Fatalf("Expected %v got %v", 3, len(write.Events))
+	}
+}
+
+func TestWriteEventsBatch_WhenAMiddleChunkFails(t *testing.T) {
+	writes := make(chan *protobuf.WriteEvents, 10)
+	conn, listener := startTestServer(t, serveTestBatchWrites(t, 1, writes))
+	defer listener.Close()
+	defer conn.Close()
+
+	result, err := conn.WriteEventsBatch("testStream", goes.ExpectedVersionNoStream, createTestBatch(6), 2)
+	if result != nil {
+		t.Fatalf("Expected no result got %+v", result)
+	}
+	batchErr, ok := err.(*goes.ErrBatchWriteFailed)
+	if !ok {
+		t.Fatalf("Expected %T got %+v", batchErr, err)
+	}
+	if batchErr.Err != goes.ErrAccessDenied {
+		t.Fatalf("Expected %v got %v", goes.ErrAccessDenied, batchErr.Err)
+	}
+	if batchErr.Written != 2 {
+		t.Fatalf("Expected %v got %v", 2, batchErr.Written)
+	}
+	if batchErr.Result == nil || batchErr.Result.LastEventNumber != 1 {
+		t.Fatalf("Expected the first chunk to be written got %+v", batchErr.Result)
+	}
+	if len(writes) != 2 {
+		t.Fatalf("Expected %v writes got %v", 2, len(writes))
+	}
+}
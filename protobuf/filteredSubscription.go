@@ -0,0 +1,160 @@
+package protobuf
+
+import proto "github.com/golang/protobuf/proto"
+
+// Filter, FilteredSubscribeToStream and CheckpointReached are declared in messages.proto but were added after protobuf.go was
+// generated. Remove them from this file when protobuf.go is regenerated.
+
+type Filter_FilterContext int32
+
+const (
+	Filter_StreamId  Filter_FilterContext = 0
+	Filter_EventType Filter_FilterContext = 1
+)
+
+var Filter_FilterContext_name = map[int32]string{
+	0: "StreamId",
+	1: "EventType",
+}
+var Filter_FilterContext_value = map[string]int32{
+	"StreamId":  0,
+	"EventType": 1,
+}
+
+func (x Filter_FilterContext) Enum() *Filter_FilterContext {
+	p := new(Filter_FilterContext)
+	*p = x
+	return p
+}
+func (x Filter_FilterContext) String() string {
+	return proto.EnumName(Filter_FilterContext_name, int32(x))
+}
+
+type Filter_FilterType int32
+
+const (
+	Filter_Regex  Filter_FilterType = 0
+	Filter_Prefix Filter_FilterType = 1
+)
+
+var Filter_FilterType_name = map[int32]string{
+	0: "Regex",
+	1: "Prefix",
+}
+var Filter_FilterType_value = map[string]int32{
+	"Regex":  0,
+	"Prefix": 1,
+}
+
+func (x Filter_FilterType) Enum() *Filter_FilterType {
+	p := new(Filter_FilterType)
+	*p = x
+	return p
+}
+func (x Filter_FilterType) String() string {
+	return proto.EnumName(Filter_FilterType_name, int32(x))
+}
+
+type Filter struct {
+	Context          *Filter_FilterContext `protobuf:"varint,1,req,name=context,enum=main.Filter_FilterContext" json:"context,omitempty"`
+	Type             *Filter_FilterType    `protobuf:"varint,2,req,name=type,enum=main.Filter_FilterType" json:"type,omitempty"`
+	Data             []string              `protobuf:"bytes,3,rep,name=data" json:"data,omitempty"`
+	XXX_unrecognized []byte                `json:"-"`
+}
+
+func (m *Filter) Reset()         { *m = Filter{} }
+func (m *Filter) String() string { return proto.CompactTextString(m) }
+func (*Filter) ProtoMessage()    {}
+
+func (m *Filter) GetContext() Filter_FilterContext {
+	if m != nil && m.Context != nil {
+		return *m.Context
+	}
+	return Filter_StreamId
+}
+
+func (m *Filter) GetType() Filter_FilterType {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return Filter_Regex
+}
+
+func (m *Filter) GetData() []string {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type FilteredSubscribeToStream struct {
+	EventStreamId      *string `protobuf:"bytes,1,req,name=event_stream_id" json:"event_stream_id,omitempty"`
+	ResolveLinkTos     *bool   `protobuf:"varint,2,req,name=resolve_link_tos" json:"resolve_link_tos,omitempty"`
+	Filter             *Filter `protobuf:"bytes,3,req,name=filter" json:"filter,omitempty"`
+	CheckpointInterval *int32  `protobuf:"varint,4,req,name=checkpoint_interval" json:"checkpoint_interval,omitempty"`
+	XXX_unrecognized   []byte  `json:"-"`
+}
+
+func (m *FilteredSubscribeToStream) Reset()         { *m = FilteredSubscribeToStream{} }
+func (m *FilteredSubscribeToStream) String() string { return proto.CompactTextString(m) }
+func (*FilteredSubscribeToStream) ProtoMessage()    {}
+
+func (m *FilteredSubscribeToStream) GetEventStreamId() string {
+	if m != nil && m.EventStreamId != nil {
+		return *m.EventStreamId
+	}
+	return ""
+}
+
+func (m *FilteredSubscribeToStream) GetResolveLinkTos() bool {
+	if m != nil && m.ResolveLinkTos != nil {
+		return *m.ResolveLinkTos
+	}
+	return false
+}
+
+func (m *FilteredSubscribeToStream) GetFilter() *Filter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
+func (m *FilteredSubscribeToStream) GetCheckpointInterval() int32 {
+	if m != nil && m.CheckpointInterval != nil {
+		return *m.CheckpointInterval
+	}
+	return 0
+}
+
+type CheckpointReached struct {
+	CommitPosition   *int64 `protobuf:"varint,1,req,name=commit_position" json:"commit_position,omitempty"`
+	PreparePosition  *int64 `protobuf:"varint,2,req,name=prepare_position" json:"prepare_position,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *CheckpointReached) Reset()         { *m = CheckpointReached{} }
+func (m *CheckpointReached) String() string { return proto.CompactTextString(m) }
+func (*CheckpointReached) ProtoMessage()    {}
+
+func (m *CheckpointReached) GetCommitPosition() int64 {
+	if m != nil && m.CommitPosition != nil {
+		return *m.CommitPosition
+	}
+	return 0
+}
+
+func (m *CheckpointReached) GetPreparePosition() int64 {
+	if m != nil && m.PreparePosition != nil {
+		return *m.PreparePosition
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*Filter)(nil), "main.Filter")
+	proto.RegisterType((*FilteredSubscribeToStream)(nil), "main.FilteredSubscribeToStream")
+	proto.RegisterType((*CheckpointReached)(nil), "main.CheckpointReached")
+	proto.RegisterEnum("main.Filter_FilterContext", Filter_FilterContext_name, Filter_FilterContext_value)
+	proto.RegisterEnum("main.Filter_FilterType", Filter_FilterType_name, Filter_FilterType_value)
+}
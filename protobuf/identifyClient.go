@@ -0,0 +1,43 @@
+package protobuf
+
+import proto "github.com/golang/protobuf/proto"
+
+// IdentifyClient and ClientIdentified are declared in messages.proto but were added after protobuf.go was
+// generated. Remove them from this file when protobuf.go is regenerated.
+
+type IdentifyClient struct {
+	Version          *int32  `protobuf:"varint,1,req,name=version" json:"version,omitempty"`
+	ConnectionName   *string `protobuf:"bytes,2,opt,name=connection_name" json:"connection_name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *IdentifyClient) Reset()         { *m = IdentifyClient{} }
+func (m *IdentifyClient) String() string { return proto.CompactTextString(m) }
+func (*IdentifyClient) ProtoMessage()    {}
+
+func (m *IdentifyClient) GetVersion() int32 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *IdentifyClient) GetConnectionName() string {
+	if m != nil && m.ConnectionName != nil {
+		return *m.ConnectionName
+	}
+	return ""
+}
+
+type ClientIdentified struct {
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *ClientIdentified) Reset()         { *m = ClientIdentified{} }
+func (m *ClientIdentified) String() string { return proto.CompactTextString(m) }
+func (*ClientIdentified) ProtoMessage()    {}
+
+func init() {
+	proto.RegisterType((*IdentifyClient)(nil), "main.IdentifyClient")
+	proto.RegisterType((*ClientIdentified)(nil), "main.ClientIdentified")
+}